@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// crashGuard wraps the app's model to write a crash report when Update,
+// View or a command panics. The panic is then passed on to Bubble Tea,
+// which leaves the alt screen and raw mode before Run returns.
+type crashGuard struct {
+	model  tea.Model
+	report *crashReport
+}
+
+// crashReport records where the report of the first panic was written
+type crashReport struct {
+	once sync.Once
+	path string
+	err  error
+}
+
+func newCrashGuard(m tea.Model) crashGuard {
+	return crashGuard{model: m, report: &crashReport{}}
+}
+
+func (g crashGuard) Init() tea.Cmd {
+	defer g.recover()
+	return g.wrap(g.model.Init())
+}
+
+func (g crashGuard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer g.recover()
+	var cmd tea.Cmd
+	g.model, cmd = g.model.Update(msg)
+	return g, g.wrap(cmd)
+}
+
+func (g crashGuard) View() string {
+	defer g.recover()
+	return g.model.View()
+}
+
+// wrap guards cmd, and the commands of a batch it returns, which Bubble Tea
+// runs in their own goroutines
+func (g crashGuard) wrap(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		defer g.recover()
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			wrapped := make(tea.BatchMsg, len(batch))
+			for i, c := range batch {
+				wrapped[i] = g.wrap(c)
+			}
+			return wrapped
+		}
+		return msg
+	}
+}
+
+// recover writes the crash report for a panic in progress and panics again
+func (g crashGuard) recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	g.report.once.Do(func() {
+		g.report.path, g.report.err = writeCrashReport(r, stack)
+	})
+	panic(r)
+}
+
+// Path returns the crash report's path, or "" if nothing panicked
+func (r *crashReport) Path() (string, error) {
+	return r.path, r.err
+}
+
+// writeCrashReport saves the panic value, the stack trace and what's needed
+// to reproduce the crash to a file in the temp directory
+func writeCrashReport(r any, stack []byte) (string, error) {
+	f, err := os.CreateTemp("", "git-diffs-crash-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s crashed at %s\n\n", versionString(), time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "panic: %v\n\n", r)
+	fmt.Fprintf(&b, "args: %q\n", os.Args[1:])
+	if wd, err := os.Getwd(); err == nil {
+		fmt.Fprintf(&b, "dir:  %s\n", wd)
+	}
+	fmt.Fprintf(&b, "go:   %s %s/%s\n\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	b.Write(stack)
+
+	if _, err := f.WriteString(b.String()); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,198 @@
+// Package annotate writes the findings of a review as reviewdog's RDJSON
+// and as SARIF, so CI bots can post them on the hosted pull request.
+package annotate
+
+import (
+	"encoding/json"
+)
+
+// Kinds of annotations, used as SARIF rule IDs
+const (
+	KindLint    = "lint"
+	KindTodo    = "todo"
+	KindComment = "comment"
+)
+
+// Annotation is a finding on lines of a file in the head version
+type Annotation struct {
+	Kind    string
+	Path    string // Relative to the repository root, with forward slashes
+	Line    int
+	EndLine int    // Last line when the finding covers several
+	Code    string // Short identifier, such as the TODO marker
+	Message string
+}
+
+// Tool names the producer in both formats
+const Tool = "git-diffs"
+
+// toolURI is the producer's home page in SARIF
+const toolURI = "https://github.com/matthewmyrick/git-diffs"
+
+// severity maps a kind to RDJSON's severities; SARIF uses the lowercase
+// level of the same name
+func severity(kind string) string {
+	if kind == KindLint {
+		return "WARNING"
+	}
+	return "INFO"
+}
+
+// endLine returns the last line of an annotation
+func (a Annotation) endLine() int {
+	return max(a.EndLine, a.Line)
+}
+
+// rdjsonPosition and the types below follow reviewdog's DiagnosticResult
+type rdjsonPosition struct {
+	Line int `json:"line"`
+}
+
+type rdjsonRange struct {
+	Start rdjsonPosition `json:"start"`
+	End   rdjsonPosition `json:"end"`
+}
+
+type rdjsonLocation struct {
+	Path  string      `json:"path"`
+	Range rdjsonRange `json:"range"`
+}
+
+type rdjsonCode struct {
+	Value string `json:"value"`
+}
+
+type rdjsonSource struct {
+	Name string `json:"name"`
+}
+
+type rdjsonDiagnostic struct {
+	Message  string         `json:"message"`
+	Location rdjsonLocation `json:"location"`
+	Severity string         `json:"severity"`
+	Source   rdjsonSource   `json:"source"`
+	Code     *rdjsonCode    `json:"code,omitempty"`
+}
+
+type rdjsonResult struct {
+	Source      rdjsonSource       `json:"source"`
+	Diagnostics []rdjsonDiagnostic `json:"diagnostics"`
+}
+
+// RDJSON returns the annotations as a reviewdog DiagnosticResult, read by
+// reviewdog -f=rdjson
+func RDJSON(annotations []Annotation) ([]byte, error) {
+	result := rdjsonResult{Source: rdjsonSource{Name: Tool}, Diagnostics: []rdjsonDiagnostic{}}
+	for _, a := range annotations {
+		d := rdjsonDiagnostic{
+			Message: a.Message,
+			Location: rdjsonLocation{
+				Path: a.Path,
+				Range: rdjsonRange{
+					Start: rdjsonPosition{Line: a.Line},
+					End:   rdjsonPosition{Line: a.endLine()},
+				},
+			},
+			Severity: severity(a.Kind),
+			Source:   rdjsonSource{Name: Tool + "/" + a.Kind},
+		}
+		if a.Code != "" {
+			d.Code = &rdjsonCode{Value: a.Code}
+		}
+		result.Diagnostics = append(result.Diagnostics, d)
+	}
+	return json.MarshalIndent(result, "", "  ")
+}
+
+// sarifLog and the types below are the parts of SARIF 2.1.0 used
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+// SARIF returns the annotations as a SARIF 2.1.0 log with one run, as
+// code scanning uploads expect
+func SARIF(annotations []Annotation) ([]byte, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           Tool,
+			InformationURI: toolURI,
+			Rules: []sarifRule{
+				{ID: KindLint, ShortDescription: sarifMessage{Text: "Linter diagnostic"}},
+				{ID: KindTodo, ShortDescription: sarifMessage{Text: "TODO, FIXME or HACK marker in an added line"}},
+				{ID: KindComment, ShortDescription: sarifMessage{Text: "Review comment"}},
+			},
+		}},
+		Results: []sarifResult{},
+	}
+	for _, a := range annotations {
+		message := a.Message
+		if a.Code != "" {
+			message = a.Code + ": " + message
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  a.Kind,
+			Level:   map[string]string{"WARNING": "warning", "INFO": "note"}[severity(a.Kind)],
+			Message: sarifMessage{Text: message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: a.Path},
+				Region:           sarifRegion{StartLine: a.Line, EndLine: a.endLine()},
+			}}},
+		})
+	}
+	return json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/platform"
+)
+
+// actionDoneMsg reports the outcome of a file action, shown in the footer
+// until the next key press
+type actionDoneMsg struct {
+	notice string
+}
+
+// cursorAbsPath returns the absolute path of the file list item under the
+// cursor and whether it is a folder
+func (m Model) cursorAbsPath() (string, bool, error) {
+	path, isFolder := m.fileList.CursorPath()
+	if path == "" {
+		return "", false, errors.New("no file selected")
+	}
+	locator, ok := m.source.(Locator)
+	if !ok {
+		return "", false, errors.New("entries in this view are not files on disk")
+	}
+	abs, err := locator.AbsPath(path)
+	return abs, isFolder, err
+}
+
+// copyPath copies the absolute path of the item under the cursor
+func (m Model) copyPath() tea.Cmd {
+	path, _, err := m.cursorAbsPath()
+	return func() tea.Msg {
+		if err == nil {
+			err = platform.CopyToClipboard(path)
+		}
+		if err != nil {
+			return actionDoneMsg{notice: "Copy failed: " + err.Error()}
+		}
+		return actionDoneMsg{notice: "Copied " + path}
+	}
+}
+
+// copyPatch copies the displayed diff, or just the hunk under the cursor, as
+// a unified patch
+func (m Model) copyPatch(hunkOnly bool) tea.Cmd {
+	diff := m.diffView.Diff()
+	what := m.diffView.FilePath()
+	var hunks []int
+	if hunkOnly && diff != nil {
+		h := m.diffView.CursorHunk()
+		hunks = []int{h}
+		what = fmt.Sprintf("hunk %d/%d of %s", h+1, len(diff.Hunks), what)
+	}
+	patch := ""
+	if diff != nil && (!hunkOnly || hunks[0] >= 0) {
+		patch = diff.Patch(hunks...)
+	}
+	return func() tea.Msg {
+		if patch == "" {
+			return actionDoneMsg{notice: "No patch to copy"}
+		}
+		if err := platform.CopyToClipboard(patch); err != nil {
+			return actionDoneMsg{notice: "Copy failed: " + err.Error()}
+		}
+		return actionDoneMsg{notice: "Copied patch of " + what}
+	}
+}
+
+// reveal opens the folder containing the item under the cursor, or the
+// folder itself, in the file manager
+func (m Model) reveal() tea.Cmd {
+	path, isFolder, err := m.cursorAbsPath()
+	return func() tea.Msg {
+		if err != nil {
+			return actionDoneMsg{notice: "Open failed: " + err.Error()}
+		}
+		dir := path
+		if !isFolder {
+			dir = filepath.Dir(path)
+		}
+		// Deleted files may have taken their folders with them
+		for {
+			if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+		if err := platform.Open(dir); err != nil {
+			return actionDoneMsg{notice: "Open failed: " + err.Error()}
+		}
+		return actionDoneMsg{notice: "Opened " + dir}
+	}
+}
+
+// copyPermalink copies the web address of the line under the diff cursor at
+// the head commit, on the forge origin points at
+func (m Model) copyPermalink() tea.Cmd {
+	path := m.diffView.FilePath()
+	_, line := m.diffView.CursorLine()
+	repo, sha := m.repo, m.headSHA
+	var err error
+	switch {
+	case path == "" || repo == nil:
+		err = errors.New("no file displayed")
+	case line == 0:
+		err = errors.New("the line under the cursor was removed")
+	case sha == "" || m.headIsUncommitted():
+		err = errors.New("the head is not a commit")
+	}
+	return func() tea.Msg {
+		if err != nil {
+			return actionDoneMsg{notice: "No permalink: " + err.Error()}
+		}
+		web, err := repo.WebURL("origin")
+		if err != nil {
+			return actionDoneMsg{notice: "No permalink: " + err.Error()}
+		}
+		link := git.Permalink(web, sha, path, line)
+		if err := platform.CopyToClipboard(link); err != nil {
+			return actionDoneMsg{notice: "Copy failed: " + err.Error()}
+		}
+		return actionDoneMsg{notice: "Copied " + link}
+	}
+}
+
+// headIsUncommitted reports whether the diff ends at the working tree or
+// the index, whose lines may not be at the same place in any commit
+func (m Model) headIsUncommitted() bool {
+	switcher, ok := m.source.(HeadSwitcher)
+	if !ok || m.commit != nil {
+		return false
+	}
+	head := switcher.Head()
+	return head == git.WorkTree || head == git.Index
+}
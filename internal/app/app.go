@@ -1,19 +1,30 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/matthewmyrick/git-diffs/internal/config"
 	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/lint"
 	"github.com/matthewmyrick/git-diffs/internal/ui"
 	"github.com/matthewmyrick/git-diffs/internal/ui/diffview"
 	"github.com/matthewmyrick/git-diffs/internal/ui/filelist"
 	"github.com/matthewmyrick/git-diffs/internal/ui/filepicker"
+	"github.com/matthewmyrick/git-diffs/internal/ui/picker"
 	"github.com/matthewmyrick/git-diffs/internal/ui/searchoverlay"
+	"github.com/matthewmyrick/git-diffs/internal/ui/summary"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
 )
 
 // Pane represents which pane is currently focused
@@ -22,121 +33,443 @@ type Pane int
 const (
 	PaneFileList Pane = iota
 	PaneDiffView
+	PanePinned // Diff pinned above the diff view
 )
 
 // Model is the main application model
 type Model struct {
+	source        Source
 	repo          *git.Repo
 	baseBranch    string
+	baseLabel     string // Shown for baseBranch when set
+	baseStrategy  string
+	compare       string
+	ignoreEOL     bool
+	fullContext   bool
+	staged        int          // Files with staged changes, for the status bar
+	exclude       []string     // Configured patterns of files to hide
+	showExcluded  bool         // Show the files matching exclude patterns
+	excluded      int          // Files matching exclude patterns, for the status bar
+	ownedBy       []string     // The user's handles and teams in CODEOWNERS
+	onlyMine      bool         // Only list the files owned by ownedBy
+	hasCodeowners bool         // The repository has a CODEOWNERS file
+	commit        *git.Commit  // Commit the changeset is scoped to, if any
+	oldestCommit  *git.Commit  // First commit when scoped to several, ending at commit
+	commits       []git.Commit // Last listed commits of the range
 	currentBranch string
+	title         string
+	location      string
+	loaded        bool
 	files         []git.ChangedFile
 	fileList      filelist.Model
 	diffView      diffview.Model
+	pinned        *diffview.Model // Diff pinned above the diff view, if any
 	searchOverlay searchoverlay.Model
 	filePicker    filepicker.Model
+	picker        picker.Model
 	focusedPane   Pane
 	width         int
 	height        int
 	err           error
 	keys          ui.KeyMap
+	timer         *startupTimer
+	pendingJump   *pendingJump
+	restore       *diffPosition
+	recent        []diffPosition // Files viewed this session, most recent first
+	reading       bool           // Space and shift+space page through hunks
+	summary       summary.Model
+	summarizing   bool // The summary is shown instead of the panes
+	startSummary  bool // Show the summary once the files are first listed
+	diffs         *diffCache
+	loading       loadState
+	filterInput   textinput.Model
+	filtering     bool
+	headInput     textinput.Model
+	enteringHead  bool // The ref prompt for the head is open
+	commentInput  textinput.Model
+	commenting    *commentTarget // The comment prompt is open
+	reviewInput   textinput.Model
+	refDiffInput  textinput.Model
+	refDiffPath   string // File the ref prompt is open for
+	gotoInput     textinput.Model
+	jumping       bool    // The : prompt is open
+	reviewing     bool    // The review summary prompt is open
+	review        *review // Review being finished
+	notice        string
+	toast         toast  // Transient error shown in the footer
+	confirm       string // Key that must be pressed again to confirm an action
+	fetch         bool
+	readOnly      bool              // Nothing may change the repository
+	embedded      bool              // Mounted inside another program
+	inline        int               // Height rendered at in the normal screen buffer; 0 for the alt screen
+	version       string            // Build version shown in the header
+	difftool      string            // External diff tool command template
+	lint          string            // Linter command template
+	editor        string            // GUI editor files open in; empty for $EDITOR
+	editorCommand string            // Launcher of the GUI editor
+	commands      map[string]string // Custom command templates by key
+	hyperlinks    bool              // Link file names and line numbers (OSC 8)
+	linkURL       string            // URL template links open; empty for file:// links
+	headSHA       string            // Commit the diff ends at, for {sha} in linkURL
+	marks         map[string]mark
+	threads       []*thread        // Comment threads on hunks
+	nextThread    int              // ID of the last thread started
+	pendingMark   pendingMark      // Mark key waiting for the mark's letter
+	count         int              // Count prefix typed before a motion, e.g. 15 in 15j
+	chord         []string         // Keys of an unfinished multi-key sequence
+	chordCount    int              // Count typed before the chord, at least 1
+	todos         []todo           // Last listed TODO/FIXME/HACK lines
+	diagnostics   lint.Diagnostics // Messages of the last lint run
+	merge         mergeStatus      // Whether the head merges cleanly into the base
 }
 
+// pendingJump is a diff line to jump to once the file's diff has loaded
+type pendingJump struct {
+	filePath string
+	line     git.DiffLine
+}
+
+// diffPosition is a diff view position to restore once a reloaded diff
+// arrives
+type diffPosition struct {
+	filePath string
+	offset   int
+	cursor   int
+}
+
+// Options configures the application
+type Options struct {
+	BaseBranch string          // Base branch to compare against (empty: auto-detect)
+	Head       string          // Ref the default source diffs to (empty: HEAD)
+	Source     Source          // Alternative changeset source (default: compare against BaseBranch)
+	PathFilter []string        // Include/exclude globs for the default source ("!" excludes)
+	Worktree   string          // Worktree to diff, as a path or branch name (default: cwd)
+	Workspace  []string        // Repositories to switch between (default: just the current one)
+	Fetch      bool            // Fetch the base's remote before each load
+	Compare    git.CompareMode // Merge-base (base...HEAD) or direct (base..HEAD) comparison
+	Debug      bool            // Log startup timings
+	Started    time.Time       // Process start time, used for startup timings
+	Config     config.Config   // User settings (zero value disables optional behavior)
+	Embedded   bool            // Mounted inside another program: no alt screen, quitting sends QuitMsg
+	Inline     int             // Render in the normal screen buffer at most this many lines high (0: alt screen)
+	Notice     string          // Shown in the footer until the first key press
+	ReadOnly   bool            // Disable reverting, fetching, custom commands and the merge check
+	Version    string          // Shown in the header (empty: not shown)
+}
+
+// diffPrefetchedMsg is sent when a diff has been loaded in the background
+type diffPrefetchedMsg struct {
+	diff    *git.FileDiff
+	key     diffKey
+	pending *pendingDiff
+	err     error
+}
+
+// prefetchRadius is how many files either side of the cursor are prefetched
+const prefetchRadius = 3
+
 // filesLoadedMsg is sent when files are loaded
 type filesLoadedMsg struct {
-	files         []git.ChangedFile
-	repo          *git.Repo
-	baseBranch    string
-	currentBranch string
-	err           error
+	changeset *Changeset
+	excluded  int  // Files matching the exclude patterns
+	owned     bool // Owners were read from a CODEOWNERS file
+	err       error
+	loadTook  time.Duration
+}
+
+// fetchDoneMsg is sent when fetching the base's remote has finished
+type fetchDoneMsg struct {
+	remote string
+	err    error
 }
 
 // diffLoadedMsg is sent when a diff is loaded
 type diffLoadedMsg struct {
 	diff     *git.FileDiff
 	filePath string
+	key      diffKey
 	err      error
 }
 
-// New creates a new application model
-func New(baseBranch string) Model {
+// New creates a new application model. Heavy components (syntax styles,
+// search indexes) are initialized on first use to keep startup fast.
+func New(opts Options) Model {
 	fl := filelist.New()
 	fl.SetFocused(true) // Start with file list focused
+	fl.SetIcons(opts.Config.Icons)
+	fl.SetNumbers(opts.Config.FileNumbers)
+	fl.SetSizeBadges(opts.Config.SizeBadges)
+
+	source := opts.Source
+	if source == nil {
+		source = NewRepoSource(opts.BaseBranch, opts.Head, opts.Compare, opts.Worktree, opts.Workspace, opts.PathFilter)
+	}
 
-	return Model{
-		baseBranch:    baseBranch,
+	fi := textinput.New()
+	fi.Prompt = "Path filter: "
+	fi.Placeholder = "src/** !**/testdata/**"
+	fi.CharLimit = 500
+
+	hi := textinput.New()
+	hi.Prompt = "Head ref: "
+	hi.Placeholder = "HEAD~2"
+	hi.CharLimit = 200
+
+	gi := textinput.New()
+	gi.CharLimit = 10
+
+	di := textinput.New()
+	di.Placeholder = "v1.0 v2.0, or one ref to compare with HEAD"
+	di.CharLimit = 200
+
+	ci := textinput.New()
+	ci.Placeholder = "Why not reuse the parser here?"
+	ci.CharLimit = 1000
+
+	ri := textinput.New()
+	ri.Prompt = "Review summary: "
+	ri.Placeholder = "optional"
+	ri.CharLimit = 2000
+
+	m := Model{
+		source:        source,
+		baseBranch:    opts.BaseBranch,
 		fileList:      fl,
 		diffView:      diffview.New(),
 		searchOverlay: searchoverlay.New(),
 		filePicker:    filepicker.New(),
+		picker:        picker.New(),
+		summary:       summary.New(),
+		startSummary:  opts.Config.StartSummary,
 		focusedPane:   PaneFileList,
 		keys:          ui.DefaultKeyMap(),
+		timer:         newStartupTimer(opts.Debug, opts.Started),
+		filterInput:   fi,
+		headInput:     hi,
+		commentInput:  ci,
+		reviewInput:   ri,
+		gotoInput:     gi,
+		refDiffInput:  di,
+		diffs:         newDiffCache(diffCacheSize, diffWorkers),
+		loading:       newLoadState(),
+		fetch:         opts.Fetch,
+		embedded:      opts.Embedded,
+		inline:        opts.Inline,
+		version:       opts.Version,
+		notice:        opts.Notice,
+		difftool:      opts.Config.Difftool,
+		lint:          opts.Config.Lint,
+		editor:        opts.Config.Editor,
+		editorCommand: opts.Config.EditorCommand,
+		commands:      opts.Config.Commands,
+		exclude:       opts.Config.Exclude,
+		ownedBy:       opts.Config.OwnedBy,
+		hyperlinks:    opts.Config.Hyperlinks,
+		linkURL:       opts.Config.LinkURL,
+		marks:         make(map[string]mark),
+	}
+	if opts.ReadOnly {
+		m.readOnly = true
+		m.fetch = false
+		m.commands = nil
+		m.keys.RevertHunk.SetEnabled(false)
+		m.keys.RevertFile.SetEnabled(false)
+		m.keys.IntentToAdd.SetEnabled(false)
+	}
+	m.loading.repoSince = time.Now()
+	if _, ok := source.(Fetcher); ok && m.fetch {
+		m.loading.fetching = true
 	}
+	m.diffView.SetMaxLines(opts.Config.MaxDiffLines)
+	m.diffView.SetTabWidth(opts.Config.TabWidth)
+	m.diffView.SetElide(opts.Config.ElideUnchanged)
+	m.diffView.SetShowWhitespace(opts.Config.ShowWhitespace)
+	m.diffView.SetColorMoved(opts.Config.ColorMoved)
+	m.diffView.SetIntraline(opts.Config.Intraline)
+	m.diffView.SetStyle(opts.Config.Style)
+	m.diffView.SetLineNumbers(opts.Config.LineNumbers)
+	m.diffView.SetRelativeNumbers(opts.Config.RelativeLineNumbers)
+	return m
 }
 
 // Init implements tea.Model
 func (m Model) Init() tea.Cmd {
+	load := m.loadRepo()
+	if m.loading.fetching {
+		load = m.fetchRemote()
+	}
+	if m.embedded || m.inline > 0 {
+		return tea.Batch(load, m.loading.spinner.Tick)
+	}
 	return tea.Batch(
-		m.loadRepo(),
+		load,
+		m.loading.spinner.Tick,
 		tea.EnterAltScreen,
 	)
 }
 
 func (m Model) loadRepo() tea.Cmd {
+	source, exclude, showExcluded := m.source, m.exclude, m.showExcluded
+	ownedBy, onlyMine := m.ownedBy, m.onlyMine
 	return func() tea.Msg {
-		repo, err := git.NewRepo(".")
+		start := time.Now()
+		changeset, err := source.Load()
 		if err != nil {
 			return filesLoadedMsg{err: err}
 		}
-
-		currentBranch, err := repo.GetCurrentBranch()
+		excluded, err := excludeFiles(changeset, exclude, showExcluded)
 		if err != nil {
 			return filesLoadedMsg{err: err}
 		}
-
-		baseBranch := m.baseBranch
-		if baseBranch == "" {
-			baseBranch, err = repo.GetDefaultBranch()
-			if err != nil {
-				baseBranch = "HEAD"
-			}
-		}
-
-		files, err := repo.GetChangedFiles(baseBranch, "HEAD")
+		owned, err := assignOwners(changeset, ownedBy, onlyMine)
 		if err != nil {
-			files, err = repo.GetChangedFiles(baseBranch, "")
-			if err != nil {
-				return filesLoadedMsg{err: err}
-			}
+			return filesLoadedMsg{err: err}
 		}
 
 		return filesLoadedMsg{
-			files:         files,
-			repo:          repo,
-			baseBranch:    baseBranch,
-			currentBranch: currentBranch,
+			changeset: changeset,
+			excluded:  excluded,
+			owned:     owned,
+			loadTook:  time.Since(start),
 		}
 	}
 }
 
-func (m Model) loadDiff(filePath string) tea.Cmd {
+// fetchRemote fetches the base's remote ahead of loading the changeset
+func (m Model) fetchRemote() tea.Cmd {
+	fetcher := m.source.(Fetcher)
 	return func() tea.Msg {
-		if m.repo == nil {
-			return diffLoadedMsg{err: fmt.Errorf("repository not loaded")}
+		remote, err := fetcher.Fetch()
+		return fetchDoneMsg{remote: remote, err: err}
+	}
+}
+
+func (m Model) loadDiff(file git.ChangedFile) tea.Cmd {
+	// Only the latest file picked matters, so a load still running for
+	// another one is cancelled rather than replacing it when it finishes
+	ctx := m.diffs.startLoad()
+	key := m.diffKeyFor(file)
+	if diff, ok := m.diffs.get(key); ok {
+		return func() tea.Msg {
+			return diffLoadedMsg{diff: diff, filePath: file.Path, key: key}
 		}
+	}
 
-		diff, err := m.repo.GetFileDiff(m.baseBranch, "HEAD", filePath)
+	source := m.source
+	return func() tea.Msg {
+		diff, err := fileDiff(ctx, source, file)
 		if err != nil {
-			diff, err = m.repo.GetFileDiff(m.baseBranch, "", filePath)
-			if err != nil {
-				return diffLoadedMsg{err: err, filePath: filePath}
-			}
+			return diffLoadedMsg{err: err, filePath: file.Path}
 		}
 
 		return diffLoadedMsg{
 			diff:     diff,
-			filePath: filePath,
+			filePath: file.Path,
+			key:      key,
+		}
+	}
+}
+
+// prefetch loads diffs that aren't cached yet in the background, a few at a
+// time. Prefetches for files that are no longer near the cursor are
+// cancelled, so scrolling through a long list doesn't queue up git
+// processes.
+func (m Model) prefetch(files []git.ChangedFile) tea.Cmd {
+	keep := make(map[diffKey]bool, len(files))
+	for _, file := range files {
+		keep[m.diffKeyFor(file)] = true
+	}
+	// Content search needs every diff, so it keeps its loads while open
+	if !m.filePicker.IsActive() {
+		m.diffs.cancelPrefetches(keep)
+	}
+	return m.fetchDiffs(files)
+}
+
+// fetchDiffs loads the diffs of files that aren't cached or being loaded
+// yet on the prefetch workers, each arriving as a diffPrefetchedMsg
+func (m Model) fetchDiffs(files []git.ChangedFile) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, file := range files {
+		key := m.diffKeyFor(file)
+		ctx, pending, ok := m.diffs.claim(key)
+		if !ok {
+			continue
+		}
+		source, diffs := m.source, m.diffs
+		cmds = append(cmds, func() tea.Msg {
+			if !diffs.acquire(ctx) {
+				return diffPrefetchedMsg{key: key, pending: pending, err: ctx.Err()}
+			}
+			defer diffs.done()
+			diff, err := fileDiff(ctx, source, file)
+			return diffPrefetchedMsg{diff: diff, key: key, pending: pending, err: err}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// loadPickerDiffs hands the file picker's content search the diffs it asks
+// for: the cached ones at once, the others through the prefetch workers as
+// they load
+func (m Model) loadPickerDiffs(files []git.ChangedFile) tea.Cmd {
+	cached := make(map[string]*git.FileDiff)
+	var missing []git.ChangedFile
+	for _, file := range files {
+		if diff, ok := m.diffs.get(m.diffKeyFor(file)); ok {
+			cached[file.Path] = diff
+		} else {
+			missing = append(missing, file)
+		}
+	}
+	cmds := []tea.Cmd{m.fetchDiffs(missing)}
+	if len(cached) > 0 {
+		cmds = append(cmds, func() tea.Msg {
+			return filepicker.DiffsLoadedMsg{Diffs: cached}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// fileDiff loads a diff from source, under ctx if the source can be
+// cancelled. Other sources run to the end, but their diff is dropped when
+// ctx was cancelled meanwhile.
+func fileDiff(ctx context.Context, source Source, file git.ChangedFile) (*git.FileDiff, error) {
+	if canceler, ok := source.(DiffCanceler); ok {
+		return canceler.FileDiffContext(ctx, file)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	diff, err := source.FileDiff(file)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	return diff, err
+}
+
+// diffKeyFor builds the diff cache key for a file
+func (m Model) diffKeyFor(file git.ChangedFile) diffKey {
+	key := diffKey{
+		base:        m.baseBranch,
+		head:        m.currentBranch,
+		compare:     m.compare,
+		ignoreEOL:   m.ignoreEOL,
+		fullContext: m.fullContext,
+		path:        file.Path,
+	}
+	if m.commit != nil {
+		key.head = m.commit.SHA
+	}
+	if m.oldestCommit != nil {
+		key.base = m.oldestCommit.SHA + "^"
+	}
+	if m.repo != nil {
+		if info, err := os.Stat(filepath.Join(m.repo.Root(), file.Path)); err == nil {
+			key.mtime = info.ModTime()
 		}
 	}
+	return key
 }
 
 // Update implements tea.Model
@@ -147,9 +480,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		if m.inline > 0 {
+			m.height = min(msg.Height, m.inline)
+		}
 		m.updateLayout()
 		m.searchOverlay.SetSize(m.width, m.height)
 		m.filePicker.SetSize(m.width, m.height)
+		m.picker.SetSize(m.width, m.height)
 
 	case searchoverlay.CloseMsg:
 		// Search overlay closed
@@ -162,18 +499,132 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case filepicker.CloseMsg:
-		// File picker closed
+		// File picker closed; its content search loads aren't needed
+		m.diffs.cancelPrefetches(nil)
 		return m, nil
 
 	case filepicker.FileSelectedMsg:
 		// File selected from picker - load diff and switch to diff pane
 		if msg.File != nil {
 			m.setFocus(PaneDiffView)
-			cmds = append(cmds, m.loadDiff(msg.File.Path))
+			cmds = append(cmds, m.startDiffLoad(*msg.File))
+		}
+		return m, tea.Batch(cmds...)
+
+	case filepicker.ContentSelectedMsg:
+		// Content match selected - load the file and jump to the line
+		if msg.File != nil {
+			m.setFocus(PaneDiffView)
+			m.pendingJump = &pendingJump{filePath: msg.File.Path, line: msg.Line}
+			cmds = append(cmds, m.startDiffLoad(*msg.File))
 		}
 		return m, tea.Batch(cmds...)
 
+	case filepicker.DiffsWantedMsg:
+		return m, m.loadPickerDiffs(msg.Files)
+
+	case filepicker.DiffsLoadedMsg:
+		var cmd tea.Cmd
+		m.filePicker, cmd = m.filePicker.Update(msg)
+		return m, cmd
+
+	case worktreesLoadedMsg:
+		if msg.err != nil {
+			m.notice = msg.err.Error()
+			return m, nil
+		}
+		m.openWorktreePicker(msg.worktrees)
+		return m, nil
+
+	case mergeCheckedMsg:
+		// Without merge-tree support the badge is left out
+		m.merge = mergeStatus{checked: msg.err == nil, conflicts: msg.conflicts}
+		return m, nil
+
+	case lintDoneMsg:
+		m.showLint(msg)
+		return m, nil
+
+	case todosFoundMsg:
+		m.openTodoPicker(msg.todos)
+		return m, nil
+
+	case refsLoadedMsg:
+		if msg.err != nil {
+			m.notice = msg.err.Error()
+			return m, nil
+		}
+		m.openHeadPicker(msg.refs)
+		return m, nil
+
+	case commitsLoadedMsg:
+		if msg.err != nil {
+			m.notice = msg.err.Error()
+			return m, nil
+		}
+		m.openCommitPicker(msg.commits)
+		return m, nil
+
+	case picker.CloseMsg:
+		return m, nil
+
+	case picker.SelectedMsg:
+		switch msg.Kind {
+		case pickWorktree:
+			return m, m.switchWorktree(msg.Item.Value)
+		case pickRepo:
+			return m, m.switchRepo(msg.Item.Value)
+		case pickCommit:
+			if len(msg.Range) > 1 {
+				return m, m.scopeToCommitRange(msg.Range)
+			}
+			return m, m.scopeToCommit(msg.Item.Value)
+		case pickHead:
+			return m, m.switchHead(msg.Item.Value)
+		case pickTodo:
+			return m, m.jumpToTodo(msg.Item.Value)
+		case pickConflict:
+			return m, m.showConflictFile(msg.Item.Value)
+		case pickMark:
+			return m, m.jumpToMark(msg.Item.Value)
+		case pickVerdict:
+			m.pickReviewDestination(msg.Item.Value)
+		case pickReviewOutput:
+			return m, m.finishReview(msg.Item.Value)
+		case pickRecent:
+			return m, m.openRecent(msg.Item.Value)
+		case pickExport:
+			m.notice = "Exporting annotations…"
+			return m, m.exportAnnotations(msg.Item.Value)
+		}
+		return m, nil
+
+	case toastExpiredMsg:
+		m.expireToast(msg)
+		return m, nil
+
+	case actionDoneMsg:
+		m.notice = msg.notice
+		return m, nil
+
+	case difftoolReadyMsg:
+		return m, runDifftool(msg)
+
+	case refDiffMsg:
+		return m, m.showRefDiff(msg)
+
+	case intentAddedMsg:
+		return m, m.showIntentAdded(msg)
+
+	case commitsCountedMsg:
+		m.summary.SetCommits(msg.n)
+		return m, nil
+
 	case tea.KeyMsg:
+		m.notice = ""
+		confirmed := m.confirm != "" && msg.String() == m.confirm
+		m.confirm = ""
+
 		// If file picker is active, pass all keys to it
 		if m.filePicker.IsActive() {
 			var cmd tea.Cmd
@@ -181,6 +632,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 
+		// If the picker is open, pass all keys to it
+		if m.picker.IsActive() {
+			var cmd tea.Cmd
+			m.picker, cmd = m.picker.Update(msg)
+			return m, cmd
+		}
+
 		// If search overlay is active, pass all keys to it
 		if m.searchOverlay.IsActive() {
 			var cmd tea.Cmd
@@ -188,9 +646,57 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 
+		// Path filter prompt takes all keys while open
+		if m.filtering {
+			return m.updatePathFilter(msg)
+		}
+		if m.enteringHead {
+			return m.updateHeadPrompt(msg)
+		}
+		if m.commenting != nil {
+			return m.updateCommentPrompt(msg)
+		}
+		if m.reviewing {
+			return m.updateReviewPrompt(msg)
+		}
+		if m.jumping {
+			return m.updateGotoPrompt(msg)
+		}
+		if m.refDiffPath != "" {
+			return m.updateRefDiffPrompt(msg)
+		}
+		if m.summarizing {
+			return m.updateSummary(msg, confirmed)
+		}
+
+		// The key after m or ' names the mark
+		if pending := m.pendingMark; pending != "" {
+			m.pendingMark = ""
+			return m, m.finishMark(pending, msg.String())
+		}
+
+		// Custom commands take precedence over the built-in keys
+		if template, ok := m.commands[msg.String()]; ok && !m.fileList.IsSearching() {
+			return m, m.customCommand(template)
+		}
+
+		// Digits build a count for the next motion, e.g. 15j
+		if !m.fileList.IsSearching() && m.countDigit(msg) {
+			return m, nil
+		}
+		repeat := m.takeCount()
+
+		// Multi-key sequences such as gg and ]c, which keep the count
+		if len(m.chord) > 0 || m.startsChord(msg) {
+			return m.updateChord(msg, repeat)
+		}
+		if !m.isMotion(msg) {
+			repeat = 1
+		}
+
 		// Global quit
 		if key.Matches(msg, m.keys.Quit) && !m.fileList.IsSearching() {
-			return m, tea.Quit
+			return m, m.quit(msg.String(), confirmed)
 		}
 
 		// Global file picker with backslash (works from anywhere)
@@ -205,8 +711,228 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, textinput.Blink
 		}
 
+		// Path filter prompt from the file list
+		if key.Matches(msg, m.keys.PathFilter) && m.focusedPane == PaneFileList && !m.fileList.IsSearching() {
+			if filterable, ok := m.source.(PathFilterable); ok {
+				m.filtering = true
+				m.filterInput.SetValue(strings.Join(filterable.PathFilter(), " "))
+				m.filterInput.CursorEnd()
+				m.filterInput.Focus()
+				return m, textinput.Blink
+			}
+		}
+
+		// Reload the changeset, keeping our place
+		if key.Matches(msg, m.keys.Refresh) && !m.fileList.IsSearching() {
+			return m, m.startRepoLoad()
+		}
+
+		// Flip back to the previously viewed file, or pick a recent one
+		if !m.fileList.IsSearching() {
+			if key.Matches(msg, m.keys.AlternateFile) {
+				return m, m.toggleRecent()
+			}
+			if key.Matches(msg, m.keys.RecentFiles) {
+				m.openRecentPicker()
+				return m, nil
+			}
+		}
+
+		// Marks: m sets one in the diff view, ' jumps to one from anywhere
+		if !m.fileList.IsSearching() {
+			if key.Matches(msg, m.keys.SetMark) && m.focusedPane == PaneDiffView {
+				m.pendingMark = markSet
+				m.notice = "Mark: press a letter"
+				return m, nil
+			}
+			if key.Matches(msg, m.keys.JumpMark) {
+				m.pendingMark = markJump
+				m.notice = "Jump to mark: press a letter"
+				return m, nil
+			}
+			if key.Matches(msg, m.keys.Marks) {
+				m.openMarkPicker()
+				return m, nil
+			}
+			if key.Matches(msg, m.keys.Todos) {
+				m.notice = "Scanning added lines…"
+				return m, m.findTodos()
+			}
+			if key.Matches(msg, m.keys.Lint) {
+				return m, m.runLint()
+			}
+			if key.Matches(msg, m.keys.Conflicts) {
+				m.openConflictPicker()
+				return m, nil
+			}
+		}
+
+		// Switch between merge-base and direct comparison
+		if key.Matches(msg, m.keys.CompareMode) && !m.fileList.IsSearching() {
+			if switcher, ok := m.source.(CompareSwitcher); ok {
+				mode := git.CompareDirect
+				if switcher.CompareMode() == git.CompareDirect {
+					mode = git.CompareMergeBase
+				}
+				switcher.SetCompareMode(mode)
+				return m, m.startRepoLoad()
+			}
+		}
+
+		// Reveal or hide the files matching exclude patterns
+		if key.Matches(msg, m.keys.Excluded) && !m.fileList.IsSearching() {
+			m.showExcluded = !m.showExcluded
+			return m, m.startRepoLoad()
+		}
+
+		// Only list the files the user owns according to CODEOWNERS
+		if key.Matches(msg, m.keys.Mine) && !m.fileList.IsSearching() {
+			return m, m.toggleMine()
+		}
+
+		// Sum up the changeset instead of showing the panes
+		if key.Matches(msg, m.keys.Summary) && !m.fileList.IsSearching() {
+			return m, m.toggleSummary()
+		}
+
+		// Switch between the staged and the unstaged changes
+		if key.Matches(msg, m.keys.Stage) && !m.fileList.IsSearching() {
+			if switcher, ok := m.source.(StageSwitcher); ok {
+				switcher.SetStaged(!switcher.Staged())
+				return m, m.startRepoLoad()
+			}
+		}
+
+		// Hide or show CRLF/LF-only changes
+		if key.Matches(msg, m.keys.LineEndings) && !m.fileList.IsSearching() {
+			if ignorer, ok := m.source.(LineEndingIgnorer); ok {
+				ignorer.SetIgnoreLineEndings(!ignorer.IgnoreLineEndings())
+				return m, m.startRepoLoad()
+			}
+		}
+
+		// Show whole files or only the lines around each change
+		if key.Matches(msg, m.keys.FullContext) && !m.fileList.IsSearching() {
+			if expander, ok := m.source.(ContextExpander); ok {
+				expander.SetFullContext(!expander.FullContext())
+				return m, m.startRepoLoad()
+			}
+		}
+
+		// Write a summary and verdict of the review
+		if key.Matches(msg, m.keys.FinishReview) && !m.fileList.IsSearching() {
+			return m, m.startReview()
+		}
+
+		// Export lint messages, TODOs and comments for CI bots
+		if key.Matches(msg, m.keys.Export) && !m.fileList.IsSearching() {
+			m.openExportPicker()
+			return m, nil
+		}
+
+		// Open the file at the cursor line in an editor
+		if key.Matches(msg, m.keys.OpenEditor) && !m.fileList.IsSearching() {
+			return m, m.openInEditor()
+		}
+
+		// Open the file at the cursor line in a tmux pane alongside
+		if key.Matches(msg, m.keys.TmuxSplit) && !m.fileList.IsSearching() {
+			return m, m.openInTmuxSplit()
+		}
+
+		// Open the file in the external diff tool
+		if key.Matches(msg, m.keys.Difftool) && !m.fileList.IsSearching() {
+			return m, m.openDifftool()
+		}
+
+		// Compare the selected file between two refs
+		if key.Matches(msg, m.keys.RefDiff) && !m.fileList.IsSearching() {
+			return m, m.startRefDiff()
+		}
+
+		// File actions from the file list
+		if m.focusedPane == PaneFileList && !m.fileList.IsSearching() {
+			if key.Matches(msg, m.keys.GoTo) {
+				return m, m.startGoto()
+			}
+			if key.Matches(msg, m.keys.FileNumbers) {
+				m.fileList.SetNumbers(!m.fileList.Numbers())
+				return m, nil
+			}
+			if key.Matches(msg, m.keys.FileOrder) {
+				order := (m.fileList.Order() + 1) % (filelist.OrderHeavy + 1)
+				m.fileList.SetOrder(order)
+				m.notice = "Files ordered " + order.String()
+				return m, nil
+			}
+			if key.Matches(msg, m.keys.CopyPath) {
+				return m, m.copyPath()
+			}
+			if key.Matches(msg, m.keys.Reveal) {
+				return m, m.reveal()
+			}
+			if key.Matches(msg, m.keys.IntentToAdd) {
+				return m, m.intentToAdd()
+			}
+			if key.Matches(msg, m.keys.Worktrees) {
+				return m, m.loadWorktrees()
+			}
+			if key.Matches(msg, m.keys.Repos) {
+				m.openRepoPicker()
+				return m, nil
+			}
+			if key.Matches(msg, m.keys.Head) {
+				return m, m.loadRefs()
+			}
+			if key.Matches(msg, m.keys.Commits) {
+				return m, m.loadCommits()
+			}
+			if key.Matches(msg, m.keys.Back) && m.commit != nil {
+				return m, m.scopeToCommit("")
+			}
+		}
+
+		// Copy the displayed changes as a patch, or undo them in the working
+		// tree after confirmation
+		if m.focusedPane == PaneDiffView {
+			if key.Matches(msg, m.keys.CopyHunk) || key.Matches(msg, m.keys.CopyPatch) {
+				return m, m.copyPatch(key.Matches(msg, m.keys.CopyHunk))
+			}
+			if key.Matches(msg, m.keys.Permalink) {
+				return m, m.copyPermalink()
+			}
+			if key.Matches(msg, m.keys.Comment) {
+				return m, m.startComment()
+			}
+			if key.Matches(msg, m.keys.GoTo) {
+				return m, m.startGoto()
+			}
+			if key.Matches(msg, m.keys.ReadingMode) {
+				m.toggleReading()
+				return m, nil
+			}
+			if key.Matches(msg, m.keys.Pin) {
+				m.togglePin()
+				return m, nil
+			}
+			if m.reading && key.Matches(msg, m.keys.NextHunk, m.keys.PrevHunk) {
+				m.pageHunk(key.Matches(msg, m.keys.NextHunk), repeat)
+				return m, nil
+			}
+			if key.Matches(msg, m.keys.RevertHunk) || key.Matches(msg, m.keys.RevertFile) {
+				cmd := m.revert(msg.String(), confirmed, key.Matches(msg, m.keys.RevertHunk))
+				return m, cmd
+			}
+		}
+
+		// Unpin from the pinned diff itself
+		if key.Matches(msg, m.keys.Pin) && m.focusedPane == PanePinned {
+			m.togglePin()
+			return m, nil
+		}
+
 		// Escape to go back to file list from diff view
-		if key.Matches(msg, m.keys.Escape) && m.focusedPane == PaneDiffView {
+		if key.Matches(msg, m.keys.Escape) && m.focusedPane != PaneFileList {
 			m.setFocus(PaneFileList)
 			return m, nil
 		}
@@ -214,39 +940,65 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Pane switching with ctrl+g (left) and ctrl+h (right) - wraps around
 		if !m.fileList.IsSearching() {
 			if key.Matches(msg, m.keys.PaneRight) {
-				// Wrap around: FileList -> DiffView -> FileList
-				if m.focusedPane == PaneFileList {
+				// Wrap around: FileList -> DiffView -> FileList, visiting
+				// the pinned diff after the file list when there is one
+				switch {
+				case m.focusedPane == PaneFileList && m.pinned != nil:
+					m.setFocus(PanePinned)
+				case m.focusedPane == PaneFileList || m.focusedPane == PanePinned:
 					m.setFocus(PaneDiffView)
-				} else {
+				default:
 					m.setFocus(PaneFileList)
 				}
 				return m, nil
 			}
 			if key.Matches(msg, m.keys.PaneLeft) {
-				// Wrap around: DiffView -> FileList -> DiffView
-				if m.focusedPane == PaneDiffView {
+				// Wrap around: DiffView -> FileList -> DiffView, the other
+				// way round
+				switch {
+				case m.focusedPane == PaneDiffView && m.pinned != nil:
+					m.setFocus(PanePinned)
+				case m.focusedPane == PaneDiffView || m.focusedPane == PanePinned:
 					m.setFocus(PaneFileList)
-				} else {
+				default:
 					m.setFocus(PaneDiffView)
 				}
 				return m, nil
 			}
 		}
 
-		// Pass to focused pane
+		// Pass to focused pane, repeating counted motions
 		switch m.focusedPane {
 		case PaneFileList:
 			var cmd tea.Cmd
-			m.fileList, cmd = m.fileList.Update(msg)
-			if cmd != nil {
-				cmds = append(cmds, cmd)
+			prevCursor := m.fileList.Cursor()
+			for range repeat {
+				m.fileList, cmd = m.fileList.Update(msg)
+				if cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			}
+			// Warm the cache around the cursor so Enter is instant
+			if m.fileList.Cursor() != prevCursor {
+				cmds = append(cmds, m.prefetch(m.fileList.Neighbors(prefetchRadius)))
 			}
 
 		case PaneDiffView:
 			var cmd tea.Cmd
-			m.diffView, cmd = m.diffView.Update(msg)
-			if cmd != nil {
-				cmds = append(cmds, cmd)
+			for range repeat {
+				m.diffView, cmd = m.diffView.Update(msg)
+				if cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			}
+
+		case PanePinned:
+			var cmd tea.Cmd
+			for range repeat {
+				*m.pinned, cmd = m.pinned.Update(msg)
+				if cmd != nil {
+					cmds = append(cmds, cmd)
+				}
 			}
 		}
 
@@ -254,42 +1006,170 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// User pressed Enter on a file - load diff and switch to diff pane
 		if msg.File != nil {
 			m.setFocus(PaneDiffView)
-			cmds = append(cmds, m.loadDiff(msg.File.Path))
+			cmds = append(cmds, m.startDiffLoad(*msg.File))
+		}
+
+	case spinner.TickMsg:
+		if m.loading.active() {
+			var cmd tea.Cmd
+			m.loading.spinner, cmd = m.loading.spinner.Update(msg)
+			return m, cmd
+		}
+
+	case fetchDoneMsg:
+		// A failed fetch (e.g. offline) still shows the local state
+		m.loading.fetching = false
+		if msg.err != nil {
+			return m, tea.Batch(m.showToast("Fetch failed: "+msg.err.Error()), m.loadRepo())
 		}
+		return m, m.loadRepo()
 
 	case filesLoadedMsg:
+		m.loading.repoSince = time.Time{}
+		if msg.err != nil && m.loaded {
+			// A failed reload keeps the files that were already listed
+			return m, m.showToast("Reload failed: " + msg.err.Error())
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			return m, nil
 		}
-		m.files = msg.files
+		m.timer.phase("load changeset", msg.loadTook)
+		m.diffs.clear()
+		cs := msg.changeset
+		m.loaded = true
+		m.files = cs.Files
+		if cs.RawView {
+			m.fileList.SetViewMode(filelist.ViewRaw)
+		}
 		m.fileList.SetFiles(m.files)
-		m.repo = msg.repo
-		m.baseBranch = msg.baseBranch
-		m.currentBranch = msg.currentBranch
+		m.summary.SetFiles(m.files)
+		m.repo = cs.Repo
+		m.baseBranch = cs.BaseBranch
+		m.baseLabel = cs.BaseLabel
+		m.baseStrategy = cs.BaseStrategy
+		m.compare = cs.Compare
+		m.ignoreEOL = cs.IgnoreEOL
+		m.fullContext = cs.FullContext
+		m.staged = cs.Staged
+		m.excluded = msg.excluded
+		m.hasCodeowners = msg.owned
+		m.commit = cs.Commit
+		m.oldestCommit = cs.OldestCommit
+		m.currentBranch = cs.CurrentBranch
+		m.title = cs.Title
+		m.location = cs.Location
+		m.headSHA = cs.HeadSHA
+		links := m.fileLinks()
+		m.fileList.SetLinks(links)
+		m.diffView.SetLinks(links)
+		// Line numbers may have moved since the linter ran
+		m.diffView.SetDiagnostics(nil)
+		m.merge = mergeStatus{}
+		cmds = append(cmds, m.checkMerge())
+		if m.startSummary {
+			m.startSummary = false
+			m.summarizing = true
+		}
+		if m.summarizing {
+			cmds = append(cmds, m.countCommits())
+		}
 
 		// Setup file picker
 		m.filePicker.SetFiles(m.files)
-		m.filePicker.SetRepo(m.repo, m.baseBranch)
+		m.filePicker.SetDiffLoader(m.source.FileDiff)
 		m.filePicker.SetSize(m.width, m.height)
 
-		// Load first file diff
-		if len(m.files) > 0 {
-			cmds = append(cmds, m.loadDiff(m.files[0].Path))
+		// Reload the diff being viewed if it's still changed, otherwise
+		// load the first file
+		m.restore = nil
+		current := m.diffView.FilePath()
+		for _, f := range m.files {
+			if f.Path == current {
+				offset, cursor := m.diffView.Position()
+				m.restore = &diffPosition{filePath: current, offset: offset, cursor: cursor}
+				cmds = append(cmds, m.startDiffLoad(f))
+				break
+			}
+		}
+		if m.restore == nil {
+			if len(m.files) > 0 {
+				cmds = append(cmds, m.startDiffLoad(m.files[0]))
+			} else {
+				m.diffView.Clear()
+			}
+		}
+
+	case diffPrefetchedMsg:
+		if !m.diffs.release(msg.key, msg.pending) {
+			// Cancelled, maybe for a changeset or settings since replaced
+			return m, nil
+		}
+		if msg.err == nil {
+			m.diffs.put(msg.key, msg.diff)
 		}
+		// Content search greps the diffs as they arrive
+		m.filePicker, _ = m.filePicker.Update(filepicker.DiffsLoadedMsg{
+			Diffs: map[string]*git.FileDiff{msg.key.path: msg.diff},
+		})
 
 	case diffLoadedMsg:
-		if msg.err != nil {
-			m.err = msg.err
+		if errors.Is(msg.err, context.Canceled) {
+			// Superseded by a later load
 			return m, nil
 		}
+		if msg.filePath == m.loading.diffPath {
+			m.loading.diffPath = ""
+		}
+		if msg.err != nil {
+			// The file may have vanished or git timed out; the rest of the
+			// changeset is still usable
+			m.diffView.Clear()
+			m.restore, m.pendingJump = nil, nil
+			return m, m.showToast(msg.err.Error())
+		}
+		m.diffs.put(msg.key, msg.diff)
+		cmds = append(cmds, m.prefetch(m.fileList.Neighbors(prefetchRadius)))
+		m.visitFile(msg.filePath)
 		m.diffView.SetDiff(msg.diff, msg.filePath)
+		m.showThreads()
+		if m.restore != nil && m.restore.filePath == msg.filePath {
+			m.diffView.SetPosition(m.restore.offset, m.restore.cursor)
+		}
+		m.restore = nil
+		if m.pendingJump != nil && m.pendingJump.filePath == msg.filePath {
+			m.diffView.JumpToDiffLine(m.pendingJump.line)
+		}
+		m.pendingJump = nil
 		m.err = nil
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// updatePathFilter handles keys while the path filter prompt is open. Enter
+// applies the globs and reloads the changed files.
+func (m Model) updatePathFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.filtering = false
+		m.filterInput.Blur()
+		return m, nil
+	case "enter":
+		m.filtering = false
+		m.filterInput.Blur()
+		if filterable, ok := m.source.(PathFilterable); ok {
+			filterable.SetPathFilter(strings.Fields(m.filterInput.Value()))
+			return m, m.startRepoLoad()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	return m, cmd
+}
+
 func (m *Model) openSearchOverlay() {
 	// Get searchable lines from the diff view
 	diffLines := m.diffView.GetSearchableLines()
@@ -320,6 +1200,9 @@ func (m *Model) setFocus(pane Pane) {
 	m.focusedPane = pane
 	m.fileList.SetFocused(pane == PaneFileList)
 	m.diffView.SetFocused(pane == PaneDiffView)
+	if m.pinned != nil {
+		m.pinned.SetFocused(pane == PanePinned)
+	}
 }
 
 func (m *Model) updateLayout() {
@@ -335,6 +1218,14 @@ func (m *Model) updateLayout() {
 	diffViewWidth := m.width - fileListWidth
 
 	m.fileList.SetSize(fileListWidth, contentHeight)
+	m.summary.SetSize(m.width, contentHeight)
+	if m.pinned != nil {
+		// The pinned diff takes the top half of the diff column
+		pinnedHeight := contentHeight / 2
+		m.pinned.SetSize(diffViewWidth, pinnedHeight)
+		m.diffView.SetSize(diffViewWidth, contentHeight-pinnedHeight)
+		return
+	}
 	m.diffView.SetSize(diffViewWidth, contentHeight)
 }
 
@@ -356,10 +1247,24 @@ func (m Model) View() string {
 	b.WriteString("\n")
 
 	// Main content
-	fileListView := m.fileList.View()
-	diffViewView := m.diffView.View()
+	fileList := m.fileList
+	if !m.loaded {
+		fileList.SetLoading(m.loading.status(m.loading.repoLabel(), m.loading.repoSince))
+	}
+	fileListView := fileList.View()
+	diffView := m.diffView
+	if m.loading.diffPath != "" {
+		diffView.SetLoading(m.diffPlaceholder())
+	}
+	diffViewView := diffView.View()
+	if m.pinned != nil {
+		diffViewView = lipgloss.JoinVertical(lipgloss.Left, m.pinned.View(), diffViewView)
+	}
 
 	content := lipgloss.JoinHorizontal(lipgloss.Top, fileListView, diffViewView)
+	if m.summarizing {
+		content = m.summary.View()
+	}
 	b.WriteString(content)
 	b.WriteString("\n")
 
@@ -368,11 +1273,20 @@ func (m Model) View() string {
 
 	baseView := b.String()
 
+	if m.loaded {
+		m.timer.firstFrame()
+	}
+
 	// Render file picker overlay on top if active
 	if m.filePicker.IsActive() {
 		return m.filePicker.RenderOverlay(baseView)
 	}
 
+	// Render picker on top if active
+	if m.picker.IsActive() {
+		return m.picker.RenderOverlay(baseView)
+	}
+
 	// Render search overlay on top if active
 	if m.searchOverlay.IsActive() {
 		return m.searchOverlay.RenderOverlay(baseView)
@@ -382,14 +1296,56 @@ func (m Model) View() string {
 }
 
 func (m Model) renderHeader() string {
-	branchInfo := fmt.Sprintf("%s → %s", m.currentBranch, m.baseBranch)
-	if m.currentBranch == "" {
+	base := m.baseBranch
+	if m.baseLabel != "" {
+		base = m.baseLabel
+	}
+	branchInfo := fmt.Sprintf("%s → %s", m.currentBranch, base)
+	if m.baseStrategy != "" && m.baseStrategy != m.baseBranch {
+		branchInfo += fmt.Sprintf(" (%s)", m.baseStrategy)
+	}
+	if m.title != "" {
+		branchInfo = m.title
+	} else if m.currentBranch == "" {
 		branchInfo = "Loading..."
 	}
 
 	fileCount := fmt.Sprintf("[%d files changed]", len(m.files))
 
-	title := fmt.Sprintf(" Git Diffs: %s  %s ", branchInfo, fileCount)
+	if !m.loading.repoSince.IsZero() {
+		status := m.loading.status(m.loading.repoLabel(), m.loading.repoSince)
+		if m.loaded {
+			fileCount += "  " + status
+		} else {
+			branchInfo, fileCount = status, ""
+		}
+	}
+
+	if m.location != "" {
+		branchInfo = m.location + ": " + branchInfo
+	}
+	if m.compare != "" {
+		branchInfo += fmt.Sprintf(" [%s]", m.compare)
+	}
+	if m.ignoreEOL {
+		branchInfo += " [ignoring EOL]"
+	}
+	if m.fullContext {
+		branchInfo += " [full files]"
+	}
+	branchInfo += m.merge.badge()
+	// Breadcrumb back to the whole range
+	if m.oldestCommit != nil {
+		branchInfo += " › " + m.commitRangeLabel()
+	} else if m.commit != nil {
+		branchInfo += fmt.Sprintf(" › %s %s", m.commit.Short, m.commit.Subject)
+	}
+
+	name := "Git Diffs"
+	if m.version != "" {
+		name += " " + m.version
+	}
+	title := fmt.Sprintf(" %s: %s  %s ", name, branchInfo, fileCount)
 
 	return ui.HeaderStyle.
 		Width(m.width).
@@ -397,20 +1353,136 @@ func (m Model) renderHeader() string {
 }
 
 func (m Model) renderFooter() string {
+	if m.filtering {
+		return ui.FooterStyle.
+			Width(m.width).
+			Render(m.filterInput.View() + "  (enter apply, esc cancel)")
+	}
+	if m.enteringHead {
+		return ui.FooterStyle.
+			Width(m.width).
+			Render(m.headInput.View() + "  (enter switch, esc cancel)")
+	}
+	if m.commenting != nil {
+		return ui.FooterStyle.
+			Width(m.width).
+			Render(m.commentInput.View() + "  (enter post, esc cancel)")
+	}
+	if m.jumping {
+		return ui.FooterStyle.
+			Width(m.width).
+			Render(m.gotoInput.View() + "  (enter go, esc cancel)")
+	}
+	if m.refDiffPath != "" {
+		return ui.FooterStyle.
+			Width(m.width).
+			Render(m.refDiffInput.View() + "  (enter compare, esc cancel)")
+	}
+	if m.reviewing {
+		return ui.FooterStyle.
+			Width(m.width).
+			Render(m.reviewInput.View() + "  (enter pick verdict, esc cancel)")
+	}
+
+	// The status bar takes what it needs, up to half the width
+	status := ui.StatusBarStyle.Render(text.Truncate(m.statusText(), m.width/2-2, "…"))
+
 	var help string
-	if m.focusedPane == PaneFileList {
-		help = "↑↓ navigate  ←→ expand/collapse  [ ] view  / search  \\ files  Enter select  ^g/^h pane  q quit"
+	footer := ui.FooterStyle
+	if m.notice != "" {
+		help = m.notice
+	} else if m.toast.text != "" {
+		help, footer = m.toast.text, ui.ToastStyle
+	} else if hint := m.lintHint(); hint != "" {
+		help = hint
+	} else if m.summarizing {
+		help = "s/Enter/Esc files  q quit"
+	} else if m.focusedPane == PaneFileList {
+		help = "↑↓ navigate  Enter select  ←→ expand/collapse  -/+ all  zR/zM all  [ ] view  / search  s summary  f filter  :/# go to/number files  ^o order  ^^/B recent  r refresh  c commits  m merge-base  ' mark  ` marks  T todos  L lint  V review  E export  M conflicts  I excluded  U owned by you  e ignore EOL  O editor  | tmux  D difftool  ^r compare refs  y copy path  o open dir  a add -N  W worktree  R repo  H head  \\ files  ^g/^h pane  q quit"
 	} else {
-		help = "↑↓ navigate  [ ] view  / search  \\ files  ^g/^h pane  Esc files  q quit"
+		help = "↑↓ navigate  gg/G top/bottom  [ ] view  / search  w whitespace  n/N line numbers  x/X revert hunk/file  p/P copy hunk/file patch  Y permalink  C comment  Z reading mode  A pin  : go to line  s summary  ^^/B recent  m/' mark/jump  ` marks  T todos  L lint  V review  E export  M conflicts  e ignore EOL  O editor  | tmux  D difftool  ^r compare refs  r refresh  \\ files  ^g/^h pane  Esc files  q quit"
 	}
-	return ui.FooterStyle.
-		Width(m.width).
-		Render(help)
+	// Keep the footer on one line; FooterStyle pads one cell either side
+	width := m.width - lipgloss.Width(status)
+	return status + footer.
+		Width(width).
+		Render(text.Truncate(help, width-2, "…"))
+}
+
+// statusText describes where we are: the file's place in the changeset,
+// the hunk and lines under the diff cursor, the view mode, the filters in
+// effect and the number of staged files
+func (m Model) statusText() string {
+	var parts []string
+
+	path := m.diffView.FilePath()
+	if m.focusedPane == PaneFileList || path == "" {
+		path = ""
+		if f := m.fileList.SelectedFile(); f != nil {
+			path = f.Path
+		}
+	}
+	for i, f := range m.files {
+		if f.Path == path {
+			parts = append(parts, fmt.Sprintf("%d/%d", i+1, len(m.files)))
+			break
+		}
+	}
+
+	if m.diffView.FilePath() != "" {
+		if diff := m.diffView.Diff(); diff != nil {
+			if h := m.diffView.CursorHunk(); h >= 0 {
+				hunk := fmt.Sprintf("hunk %d/%d", h+1, len(diff.Hunks))
+				if m.reading {
+					hunk = "reading " + hunk
+				}
+				parts = append(parts, hunk)
+			}
+		}
+		switch old, new := m.diffView.CursorLine(); {
+		case old > 0 && new > 0:
+			parts = append(parts, fmt.Sprintf("old %d new %d", old, new))
+		case old > 0:
+			parts = append(parts, fmt.Sprintf("old %d", old))
+		case new > 0:
+			parts = append(parts, fmt.Sprintf("new %d", new))
+		}
+		parts = append(parts, m.diffView.GetViewMode())
+	}
+
+	if order := m.fileList.Order(); order != filelist.OrderPath {
+		parts = append(parts, order.String())
+	}
+	if filterable, ok := m.source.(PathFilterable); ok && len(filterable.PathFilter()) > 0 {
+		parts = append(parts, "filter: "+strings.Join(filterable.PathFilter(), " "))
+	}
+	if q := m.fileList.SearchQuery(); q != "" {
+		parts = append(parts, "search: "+q)
+	}
+	if m.staged > 0 {
+		parts = append(parts, fmt.Sprintf("%d staged", m.staged))
+	}
+	if excluded := m.excludedStatus(); excluded != "" {
+		parts = append(parts, excluded)
+	}
+	if m.onlyMine {
+		parts = append(parts, "owned by you")
+	}
+	if m.readOnly {
+		parts = append(parts, "read-only")
+	}
+	if m.count > 0 {
+		parts = append(parts, fmt.Sprintf("count %d", m.count))
+	}
+	if len(m.chord) > 0 {
+		parts = append(parts, m.pendingChord())
+	}
+	return strings.Join(parts, " │ ")
 }
 
 func (m Model) renderError() string {
 	errorBox := ui.ErrorStyle.
-		Width(m.width - 4).
+		Width(m.width-4).
 		Padding(1, 2).
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(ui.ColorDanger).
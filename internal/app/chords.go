@@ -0,0 +1,75 @@
+package app
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startsChord reports whether a key begins a multi-key sequence such as gg
+func (m Model) startsChord(msg tea.KeyMsg) bool {
+	return !m.fileList.IsSearching() && m.keys.ChordPrefix([]string{msg.String()})
+}
+
+// updateChord adds a key to the pending chord and runs the chord once it is
+// complete, count times where that makes sense. A key that completes no
+// chord cancels it.
+func (m Model) updateChord(msg tea.KeyMsg, count int) (tea.Model, tea.Cmd) {
+	if len(m.chord) == 0 {
+		m.chordCount = count
+	}
+	seq := append(slices.Clone(m.chord), msg.String())
+	m.chord = nil
+
+	switch {
+	case m.keys.GoTop.Matches(seq):
+		return m.Update(tea.KeyMsg{Type: tea.KeyHome})
+	case m.keys.GoDiff.Matches(seq):
+		m.setFocus(PaneDiffView)
+	case m.keys.ExpandFolds.Matches(seq), m.keys.FoldAll.Matches(seq):
+		m.fileList.ExpandAll(m.keys.ExpandFolds.Matches(seq))
+	case m.keys.NextChange.Matches(seq), m.keys.PrevChange.Matches(seq):
+		m.pageHunk(m.keys.NextChange.Matches(seq), m.chordCount)
+	case m.keys.NextFile.Matches(seq):
+		return m, m.stepFile(m.chordCount)
+	case m.keys.PrevFile.Matches(seq):
+		return m, m.stepFile(-m.chordCount)
+	case m.keys.ChordPrefix(seq):
+		m.chord = seq
+	case seq[0] == "[" || seq[0] == "]":
+		// On their own [ and ] switch tabs, and the key after them is
+		// handled as usual
+		cmd := m.switchTab(seq[0])
+		next, nextCmd := m.Update(msg)
+		return next, tea.Batch(cmd, nextCmd)
+	}
+	return m, nil
+}
+
+// switchTab passes a lone [ or ] to the focused pane, which switches its
+// tabs with it
+func (m *Model) switchTab(bracket string) tea.Cmd {
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(bracket)}
+	var cmd tea.Cmd
+	switch m.focusedPane {
+	case PaneFileList:
+		m.fileList, cmd = m.fileList.Update(msg)
+	case PaneDiffView:
+		m.diffView, cmd = m.diffView.Update(msg)
+	case PanePinned:
+		*m.pinned, cmd = m.pinned.Update(msg)
+	}
+	return cmd
+}
+
+// pendingChord shows the count and keys of an unfinished chord, e.g. "g…"
+// or "3]…"
+func (m Model) pendingChord() string {
+	keys := strings.Join(m.chord, "") + "…"
+	if m.chordCount > 1 {
+		return strconv.Itoa(m.chordCount) + keys
+	}
+	return keys
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// customCommand runs a user-defined command from the [commands] config
+// section
+func (m Model) customCommand(template string) tea.Cmd {
+	args := strings.Fields(template)
+	background := len(args) > 0 && args[len(args)-1] == "&"
+	if background {
+		args = args[:len(args)-1]
+	}
+	if len(args) == 0 {
+		return func() tea.Msg {
+			return actionDoneMsg{notice: "Command failed: empty command"}
+		}
+	}
+
+	r := m.commandReplacer()
+	for i, arg := range args {
+		args[i] = r.Replace(arg)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	if m.repo != nil {
+		cmd.Dir = m.repo.Root()
+	}
+	name := filepath.Base(args[0])
+
+	// Commands ending in & run detached, like GUI editors
+	if background {
+		return func() tea.Msg {
+			if err := cmd.Start(); err != nil {
+				return actionDoneMsg{notice: "Command failed: " + err.Error()}
+			}
+			go cmd.Wait()
+			return actionDoneMsg{notice: "Started " + name}
+		}
+	}
+	return tea.Exec(pausedCommand{cmd}, func(err error) tea.Msg {
+		var exitErr *exec.ExitError
+		switch {
+		case errors.As(err, &exitErr):
+			return actionDoneMsg{notice: fmt.Sprintf("%s exited with status %d", name, exitErr.ExitCode())}
+		case err != nil:
+			return actionDoneMsg{notice: "Command failed: " + err.Error()}
+		}
+		return actionDoneMsg{notice: "Ran " + name}
+	})
+}
+
+// commandReplacer fills in the placeholders of custom commands from the
+// selected file and the line under the diff cursor
+func (m Model) commandReplacer() *strings.Replacer {
+	var file, abs, root string
+	if f := m.difftoolFile(); f != nil {
+		file = f.Path
+	}
+	if m.repo != nil {
+		root = m.repo.Root()
+		if file != "" {
+			abs = filepath.Join(root, file)
+		}
+	}
+	dir := path.Dir(file)
+	return strings.NewReplacer(
+		"{path}", file,
+		"{abs}", abs,
+		"{line}", strconv.Itoa(m.fileLine(file)),
+		"{dir}", dir,
+		"{package}", "./"+strings.TrimPrefix(dir, "."),
+		"{root}", root,
+		"{base}", m.baseBranch,
+		"{sha}", m.headSHA,
+	)
+}
+
+// fileLine returns the line under the diff cursor when file is shown,
+// preferring the new side, and 1 otherwise
+func (m Model) fileLine(file string) int {
+	if file != "" && file == m.diffView.FilePath() {
+		if old, new := m.diffView.CursorLine(); new > 0 {
+			return new
+		} else if old > 0 {
+			return old
+		}
+	}
+	return 1
+}
+
+// pausedCommand runs a command with the terminal and waits for Enter
+// afterwards, so its output can be read before the diff comes back
+type pausedCommand struct {
+	*exec.Cmd
+}
+
+func (c pausedCommand) Run() error {
+	err := c.Cmd.Run()
+	fmt.Fprint(c.Stdout, "\nPress Enter to return to git-diffs")
+	bufio.NewReader(c.Stdin).ReadString('\n')
+	return err
+}
+
+func (c pausedCommand) SetStdin(r io.Reader)  { c.Stdin = r }
+func (c pausedCommand) SetStdout(w io.Writer) { c.Stdout = w }
+func (c pausedCommand) SetStderr(w io.Writer) { c.Stderr = w }
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui/diffview"
+)
+
+// thread is a comment thread on a hunk. Like marks, threads only live for
+// the session.
+type thread struct {
+	id       int
+	path     string
+	header   string       // The hunk's @@ line, which finds it again after a reload
+	hunk     git.DiffHunk // Where the hunk was when commented on, without its lines
+	comments []string     // The first comment and its replies
+}
+
+// commentTarget is what the open comment prompt posts to: a reply to a
+// thread, or a new thread on a hunk
+type commentTarget struct {
+	path   string
+	thread int // ID of the thread replied to; 0 starts one
+	hunk   git.DiffHunk
+	header string
+}
+
+// hunkHeader returns the @@ line of a hunk
+func hunkHeader(hunk git.DiffHunk) string {
+	if len(hunk.Lines) > 0 && hunk.Lines[0].Type == git.DiffLineHeader {
+		return hunk.Lines[0].Content
+	}
+	return ""
+}
+
+// startComment opens the comment prompt for the thread or hunk under the
+// diff cursor
+func (m *Model) startComment() tea.Cmd {
+	diff := m.diffView.Diff()
+	path := m.diffView.FilePath()
+	if diff == nil || path == "" {
+		m.notice = "Nothing to comment on"
+		return nil
+	}
+
+	target := commentTarget{path: path}
+	if id, ok := m.diffView.CursorThread(); ok {
+		target.thread = id
+		m.commentInput.Prompt = "Reply: "
+	} else {
+		h := m.diffView.CursorHunk()
+		if h < 0 || h >= len(diff.Hunks) {
+			m.notice = "Nothing to comment on"
+			return nil
+		}
+		target.hunk = diff.Hunks[h]
+		target.hunk.Lines = nil
+		target.header = hunkHeader(diff.Hunks[h])
+		m.commentInput.Prompt = fmt.Sprintf("Comment on hunk %d/%d: ", h+1, len(diff.Hunks))
+	}
+	m.commenting = &target
+	m.commentInput.SetValue("")
+	m.commentInput.Focus()
+	return textinput.Blink
+}
+
+// updateCommentPrompt handles keys while the comment prompt is open. Enter
+// posts the comment.
+func (m Model) updateCommentPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.commenting = nil
+		m.commentInput.Blur()
+		return m, nil
+	case "enter":
+		target := *m.commenting
+		m.commenting = nil
+		m.commentInput.Blur()
+		if body := strings.TrimSpace(m.commentInput.Value()); body != "" {
+			m.postComment(target, body)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.commentInput, cmd = m.commentInput.Update(msg)
+	return m, cmd
+}
+
+// postComment adds body to the thread target replies to, or starts a thread
+func (m *Model) postComment(target commentTarget, body string) {
+	id := target.thread
+	if id == 0 {
+		m.nextThread++
+		id = m.nextThread
+		m.threads = append(m.threads, &thread{
+			id:     id,
+			path:   target.path,
+			header: target.header,
+			hunk:   target.hunk,
+		})
+	}
+	for _, t := range m.threads {
+		if t.id == id {
+			t.comments = append(t.comments, body)
+		}
+	}
+	m.showThreads()
+	m.diffView.OpenThread(id)
+}
+
+// showThreads passes the threads on the displayed diff's hunks to the diff
+// view. Threads whose hunk changed are kept but no longer shown inline.
+func (m *Model) showThreads() {
+	diff := m.diffView.Diff()
+	path := m.diffView.FilePath()
+	var shown []diffview.Thread
+	if diff != nil {
+		for _, t := range m.threads {
+			if t.path != path {
+				continue
+			}
+			for h, hunk := range diff.Hunks {
+				if hunkHeader(hunk) == t.header {
+					shown = append(shown, diffview.Thread{ID: t.id, Hunk: h, Comments: t.comments})
+					break
+				}
+			}
+		}
+	}
+	m.diffView.SetThreads(shown)
+}
+
+// commentCount returns the number of comments in all threads
+func (m Model) commentCount() int {
+	n := 0
+	for _, t := range m.threads {
+		n += len(t.comments)
+	}
+	return n
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui/picker"
+)
+
+// pickCommit identifies the commit list in picker messages
+const pickCommit = "commit"
+
+// commitsLoadedMsg is sent when the commits of the range have been listed
+type commitsLoadedMsg struct {
+	commits []git.Commit
+	err     error
+}
+
+// loadCommits lists the commits between the base and HEAD for the picker
+func (m Model) loadCommits() tea.Cmd {
+	scoper, ok := m.source.(CommitScoper)
+	if !ok {
+		return func() tea.Msg {
+			return commitsLoadedMsg{err: errors.New("commits are not available in this view")}
+		}
+	}
+	return func() tea.Msg {
+		commits, err := scoper.Commits()
+		return commitsLoadedMsg{commits: commits, err: err}
+	}
+}
+
+// openCommitPicker shows the whole range followed by its commits, newest
+// first, with the current scope selected
+func (m *Model) openCommitPicker(commits []git.Commit) {
+	m.commits = commits
+	items := []picker.Item{{
+		Label:  "All commits",
+		Detail: m.baseBranch + "..HEAD",
+	}}
+	current := 0
+	for _, c := range commits {
+		if m.commit != nil && c.SHA == m.commit.SHA {
+			current = len(items)
+		}
+		items = append(items, picker.Item{
+			Label:  c.Short + " " + c.Subject,
+			Detail: c.Author + ", " + c.Date,
+			Value:  c.SHA,
+		})
+	}
+	m.picker.Open(pickCommit, "Commits", items, current)
+	_, ranged := m.source.(CommitRanger)
+	m.picker.SetRangeSelect(ranged)
+}
+
+// scopeToCommit reloads the changeset for a single commit, or the whole
+// range when sha is empty
+func (m *Model) scopeToCommit(sha string) tea.Cmd {
+	scoper, ok := m.source.(CommitScoper)
+	if !ok {
+		return nil
+	}
+
+	var commit *git.Commit
+	for i := range m.commits {
+		if m.commits[i].SHA == sha {
+			commit = &m.commits[i]
+		}
+	}
+	if current := scoper.Commit(); m.oldestCommit == nil && ((current == nil && commit == nil) || (current != nil && commit != nil && current.SHA == commit.SHA)) {
+		return nil
+	}
+
+	scoper.SetCommit(commit)
+	return m.startRepoLoad()
+}
+
+// scopeToCommitRange reloads the changeset for the commits of a range
+// selection in the picker, which lists them newest first. A selection that
+// takes in the "All commits" entry only counts its commits.
+func (m *Model) scopeToCommitRange(items []picker.Item) tea.Cmd {
+	ranger, ok := m.source.(CommitRanger)
+	if !ok {
+		return nil
+	}
+	var shas []string
+	for _, item := range items {
+		if item.Value != "" {
+			shas = append(shas, item.Value)
+		}
+	}
+	if len(shas) < 2 {
+		sha := ""
+		if len(shas) == 1 {
+			sha = shas[0]
+		}
+		return m.scopeToCommit(sha)
+	}
+
+	newest, oldest := m.findCommit(shas[0]), m.findCommit(shas[len(shas)-1])
+	if newest == nil || oldest == nil {
+		return nil
+	}
+	if m.oldestCommit != nil && m.commit != nil && m.oldestCommit.SHA == oldest.SHA && m.commit.SHA == newest.SHA {
+		return nil
+	}
+	ranger.SetCommitRange(oldest, newest)
+	return m.startRepoLoad()
+}
+
+// findCommit returns the listed commit with sha
+func (m Model) findCommit(sha string) *git.Commit {
+	for i := range m.commits {
+		if m.commits[i].SHA == sha {
+			return &m.commits[i]
+		}
+	}
+	return nil
+}
+
+// commitRangeLabel describes the commits the changeset is scoped to, for
+// the header
+func (m Model) commitRangeLabel() string {
+	label := m.oldestCommit.Short + ".." + m.commit.Short
+	first, last := -1, -1
+	for i, c := range m.commits {
+		if c.SHA == m.commit.SHA {
+			first = i
+		}
+		if c.SHA == m.oldestCommit.SHA {
+			last = i
+		}
+	}
+	if first >= 0 && last >= first {
+		label += fmt.Sprintf(" (%d commits)", last-first+1)
+	}
+	return label
+}
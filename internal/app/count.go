@@ -0,0 +1,42 @@
+package app
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxCount caps a count prefix, so a held digit key can't queue up an
+// endless motion
+const maxCount = 9999
+
+// countDigit adds a typed digit to the pending count prefix and reports
+// whether the key was one. A leading 0 isn't a count.
+func (m *Model) countDigit(msg tea.KeyMsg) bool {
+	if msg.Type != tea.KeyRunes || len(msg.Runes) != 1 || msg.Alt {
+		return false
+	}
+	r := msg.Runes[0]
+	if r < '0' || r > '9' || (r == '0' && m.count == 0) {
+		return false
+	}
+	m.count = min(m.count*10+int(r-'0'), maxCount)
+	return true
+}
+
+// takeCount returns how many times the next key repeats, at least once, and
+// clears the pending count
+func (m *Model) takeCount() int {
+	n := max(m.count, 1)
+	m.count = 0
+	return n
+}
+
+// isMotion reports whether a key moves the cursor, so a count prefix
+// repeats it. Chords such as ]c take the count in updateChord.
+func (m Model) isMotion(msg tea.KeyMsg) bool {
+	if m.reading && m.focusedPane == PaneDiffView && key.Matches(msg, m.keys.NextHunk, m.keys.PrevHunk) {
+		return true
+	}
+	return key.Matches(msg, m.keys.Up, m.keys.Down, m.keys.PageUp, m.keys.PageDown,
+		m.keys.HalfPageUp, m.keys.HalfPageDown, m.keys.ScrollUp, m.keys.ScrollDown)
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"container/list"
+	"context"
+	"time"
+
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// diffCacheSize is the number of parsed diffs kept in memory
+const diffCacheSize = 64
+
+// diffWorkers is how many diffs are prefetched at once. Loads for the diff
+// pane don't wait for a worker.
+const diffWorkers = 4
+
+// diffKey identifies a diff. The working tree mtime catches edits made to a
+// file since its diff was loaded.
+type diffKey struct {
+	base        string
+	head        string
+	compare     string
+	ignoreEOL   bool
+	fullContext bool
+	path        string
+	mtime       time.Time
+}
+
+type diffEntry struct {
+	key  diffKey
+	diff *git.FileDiff
+}
+
+// diffCache is a least-recently-used cache of parsed diffs, which also
+// tracks the diffs being loaded so superseded loads can be cancelled. It's
+// only accessed from Update, so it needs no locking.
+type diffCache struct {
+	size    int
+	order   *list.List // Front is most recently used
+	entries map[diffKey]*list.Element
+	pending map[diffKey]*pendingDiff // Diffs being prefetched
+	workers chan struct{}            // Holds a token per running prefetch
+	cancel  context.CancelFunc       // Cancels the load for the diff pane
+}
+
+// pendingDiff is a prefetch in flight
+type pendingDiff struct {
+	cancel context.CancelFunc
+}
+
+func newDiffCache(size, workers int) *diffCache {
+	return &diffCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[diffKey]*list.Element),
+		pending: make(map[diffKey]*pendingDiff),
+		workers: make(chan struct{}, workers),
+	}
+}
+
+// claim marks key as being prefetched and returns the context to prefetch
+// it under. It returns false if the diff is already cached or being
+// fetched.
+func (c *diffCache) claim(key diffKey) (context.Context, *pendingDiff, bool) {
+	if _, ok := c.entries[key]; ok || c.pending[key] != nil {
+		return nil, nil, false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &pendingDiff{cancel: cancel}
+	c.pending[key] = p
+	return ctx, p, true
+}
+
+// release clears the prefetch mark for key and reports whether the
+// prefetch is still wanted. It isn't once it has been cancelled, whether or
+// not key has been claimed again since.
+func (c *diffCache) release(key diffKey, p *pendingDiff) bool {
+	p.cancel()
+	if c.pending[key] != p {
+		return false
+	}
+	delete(c.pending, key)
+	return true
+}
+
+// cancelPrefetches cancels the prefetches of the diffs not in keep, which
+// the cursor has moved away from
+func (c *diffCache) cancelPrefetches(keep map[diffKey]bool) {
+	for key, p := range c.pending {
+		if !keep[key] {
+			p.cancel()
+			delete(c.pending, key)
+		}
+	}
+}
+
+// startLoad cancels the load for the diff pane, if one is running, and
+// returns the context for the next one
+func (c *diffCache) startLoad() context.Context {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+	return ctx
+}
+
+// acquire waits for a free prefetch worker. It returns false if ctx is done
+// first; otherwise the worker must be handed back with done.
+func (c *diffCache) acquire(ctx context.Context) bool {
+	select {
+	case c.workers <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// done hands back a worker taken with acquire
+func (c *diffCache) done() {
+	<-c.workers
+}
+
+// get returns the cached diff for key and marks it as recently used
+func (c *diffCache) get(key diffKey) (*git.FileDiff, bool) {
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*diffEntry).diff, true
+}
+
+// put stores a diff, evicting the least recently used one when full
+func (c *diffCache) put(key diffKey, diff *git.FileDiff) {
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*diffEntry).diff = diff
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&diffEntry{key: key, diff: diff})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*diffEntry).key)
+	}
+}
+
+// clear drops every cached diff and cancels the loads in flight
+func (c *diffCache) clear() {
+	c.order.Init()
+	clear(c.entries)
+	c.cancelPrefetches(nil)
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+}
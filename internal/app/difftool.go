@@ -0,0 +1,130 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// difftoolReadyMsg carries an external diff tool command whose files have
+// been written, ready to take over the terminal
+type difftoolReadyMsg struct {
+	cmd  *exec.Cmd
+	dir  string // Temporary directory holding the files, removed afterwards
+	path string
+}
+
+// difftoolFile returns the file to open in the external diff tool: the one
+// shown in the diff pane, or the one under the cursor in the file list
+func (m Model) difftoolFile() *git.ChangedFile {
+	if m.focusedPane == PaneDiffView {
+		path := m.diffView.FilePath()
+		for _, f := range m.fileList.Files() {
+			if f.Path == path {
+				return &f
+			}
+		}
+		return nil
+	}
+	return m.fileList.SelectedFile()
+}
+
+// openDifftool writes both versions of the selected file to temporary files
+// and builds the configured difftool command comparing them
+func (m Model) openDifftool() tea.Cmd {
+	template := m.difftool
+	file := m.difftoolFile()
+	versioner, ok := m.source.(Versioner)
+	return func() tea.Msg {
+		switch {
+		case template == "":
+			return actionDoneMsg{notice: "Set difftool in the config file to open files in an external diff tool"}
+		case file == nil:
+			return actionDoneMsg{notice: "Difftool failed: no file selected"}
+		case !ok:
+			return actionDoneMsg{notice: "Difftool failed: entries in this view are not files"}
+		}
+
+		before, after, err := versioner.Versions(*file)
+		if err != nil {
+			return actionDoneMsg{notice: "Difftool failed: " + err.Error()}
+		}
+		dir, oldFile, newFile, err := writeVersions(file.Path, before, after)
+		if err != nil {
+			return actionDoneMsg{notice: "Difftool failed: " + err.Error()}
+		}
+
+		args := difftoolArgs(template, oldFile, newFile, file.Path)
+		if len(args) == 0 {
+			os.RemoveAll(dir)
+			return actionDoneMsg{notice: "Difftool failed: empty command"}
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		if locator, ok := m.source.(Locator); ok {
+			if abs, err := locator.AbsPath("."); err == nil {
+				cmd.Dir = abs
+			}
+		}
+		return difftoolReadyMsg{cmd: cmd, dir: dir, path: file.Path}
+	}
+}
+
+// runDifftool hands the terminal to the difftool until it exits, then
+// removes its files. Like `git difftool`, GUI tools need to be told to wait,
+// e.g. `code --wait --diff`, and the tool's exit status is ignored since
+// diff tools commonly exit non-zero when the files differ.
+func runDifftool(msg difftoolReadyMsg) tea.Cmd {
+	return tea.ExecProcess(msg.cmd, func(err error) tea.Msg {
+		os.RemoveAll(msg.dir)
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			return actionDoneMsg{notice: "Difftool failed: " + err.Error()}
+		}
+		return actionDoneMsg{notice: "Closed difftool for " + msg.path}
+	})
+}
+
+// writeVersions writes the old and new contents of a file to a temporary
+// directory, keeping its name so tools can detect the language. A missing
+// side is written as an empty file.
+func writeVersions(path string, before, after []byte) (dir, oldFile, newFile string, err error) {
+	dir, err = os.MkdirTemp("", "git-diffs-")
+	if err != nil {
+		return "", "", "", err
+	}
+	name := filepath.Base(path)
+	oldFile = filepath.Join(dir, "old", name)
+	newFile = filepath.Join(dir, "new", name)
+	if err = writeFile(oldFile, before); err == nil {
+		err = writeFile(newFile, after)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", "", err
+	}
+	return dir, oldFile, newFile, nil
+}
+
+// writeFile writes data to path, creating its folder
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// difftoolArgs splits the difftool template into arguments and fills in the
+// {old}, {new} and {path} placeholders
+func difftoolArgs(template, oldFile, newFile, path string) []string {
+	r := strings.NewReplacer("{old}", oldFile, "{new}", newFile, "{path}", path)
+	args := strings.Fields(template)
+	for i, arg := range args {
+		args[i] = r.Replace(arg)
+	}
+	return args
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// Editors the editor setting opens files in, by the way their launchers
+// take a line
+const (
+	editorVSCode    = "vscode"    // code --goto path:line
+	editorJetBrains = "jetbrains" // idea --line line path
+)
+
+// editorArgs returns the command opening path at line in a GUI editor,
+// started with launcher or the editor's usual one
+func editorArgs(editor, launcher, path string, line int) []string {
+	switch editor {
+	case editorVSCode:
+		if launcher == "" {
+			launcher = "code"
+		}
+		return []string{launcher, "--goto", path + ":" + strconv.Itoa(line)}
+	case editorJetBrains:
+		if launcher == "" {
+			launcher = "idea"
+		}
+		return []string{launcher, "--line", strconv.Itoa(line), path}
+	}
+	return nil
+}
+
+// terminalEditorArgs returns the command opening path at line in $VISUAL
+// or $EDITOR, using the +line argument most terminal editors accept
+func terminalEditorArgs(path string, line int) []string {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		return nil
+	}
+	return append(args, "+"+strconv.Itoa(line), path)
+}
+
+// openInEditor opens the selected file at the line under the diff cursor:
+// in the configured GUI editor, which runs alongside, or in $VISUAL or
+// $EDITOR, which takes over the terminal until it exits
+func (m *Model) openInEditor() tea.Cmd {
+	file := m.difftoolFile()
+	locator, ok := m.source.(Locator)
+	switch {
+	case file == nil:
+		m.notice = "Nothing to open"
+		return nil
+	case !ok:
+		m.notice = "Opening needs the changed files on disk"
+		return nil
+	case file.Status == git.StatusDeleted:
+		m.notice = file.Path + " was deleted"
+		return nil
+	}
+	abs, err := locator.AbsPath(file.Path)
+	if err != nil {
+		m.notice = "Open failed: " + err.Error()
+		return nil
+	}
+	line := m.fileLine(file.Path)
+
+	if args := editorArgs(m.editor, m.editorCommand, abs, line); args != nil {
+		name := filepath.Base(args[0])
+		return func() tea.Msg {
+			cmd := exec.Command(args[0], args[1:]...)
+			if err := cmd.Start(); err != nil {
+				return actionDoneMsg{notice: "Open failed: " + err.Error()}
+			}
+			go cmd.Wait()
+			return actionDoneMsg{notice: "Opened " + file.Path + " in " + name}
+		}
+	}
+
+	args := terminalEditorArgs(abs, line)
+	if args == nil {
+		m.notice = "Set editor in the config file, or $EDITOR, to open files"
+		return nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = filepath.Dir(abs)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			return actionDoneMsg{notice: "Open failed: " + err.Error()}
+		}
+		return actionDoneMsg{notice: "Closed " + file.Path}
+	})
+}
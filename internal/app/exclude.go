@@ -0,0 +1,60 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// excludeFiles hides the files of cs matching the configured patterns or
+// those of the repository's exclude file, unless show is set, and returns
+// how many match
+func excludeFiles(cs *Changeset, patterns []string, show bool) (int, error) {
+	if cs.Repo != nil {
+		fromFile, err := cs.Repo.ExcludePatterns()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", git.ExcludeFile, err)
+		}
+		patterns = append(patterns[:len(patterns):len(patterns)], fromFile...)
+	}
+	if len(patterns) == 0 {
+		return 0, nil
+	}
+
+	excluder := git.NewExcluder(patterns)
+	kept := make([]git.ChangedFile, 0, len(cs.Files))
+	for _, f := range cs.Files {
+		if !excluder.Excluded(f.Path) {
+			kept = append(kept, f)
+		}
+	}
+	excluded := len(cs.Files) - len(kept)
+	if !show {
+		cs.Files = kept
+	}
+	return excluded, nil
+}
+
+// LoadChangeset loads source's changes without the files matching the
+// exclude patterns, as the file list shows them
+func LoadChangeset(source Source, exclude []string) (*Changeset, error) {
+	cs, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := excludeFiles(cs, exclude, false); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// excludedStatus describes the excluded files for the status bar
+func (m Model) excludedStatus() string {
+	switch {
+	case m.excluded == 0:
+		return ""
+	case m.showExcluded:
+		return fmt.Sprintf("%d excluded shown", m.excluded)
+	}
+	return fmt.Sprintf("%d excluded", m.excluded)
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/annotate"
+	"github.com/matthewmyrick/git-diffs/internal/ui/picker"
+)
+
+// pickExport identifies the annotation format picker in picker messages
+const pickExport = "export"
+
+// Formats annotations export to, by file extension
+const (
+	exportRDJSON = "rdjson"
+	exportSARIF  = "sarif"
+)
+
+// openExportPicker asks which format to export the annotations in
+func (m *Model) openExportPicker() {
+	m.picker.Open(pickExport, "Export annotations", []picker.Item{
+		{Label: "RDJSON", Detail: "for reviewdog -f=rdjson", Value: exportRDJSON},
+		{Label: "SARIF", Detail: "for code scanning uploads", Value: exportSARIF},
+	}, 0)
+}
+
+// exportAnnotations writes the lint diagnostics of the last lint run, the
+// TODO markers in added lines and the comment threads in format, next to
+// the review written with V
+func (m Model) exportAnnotations(format string) tea.Cmd {
+	files, source := m.files, m.source
+	diagnostics, threads := m.diagnostics, m.threads
+	path := filepath.Join(m.outputDir(), "git-diffs-annotations."+format)
+
+	return func() tea.Msg {
+		var annotations []annotate.Annotation
+		for file, lines := range diagnostics {
+			for line, msgs := range lines {
+				for _, msg := range msgs {
+					annotations = append(annotations, annotate.Annotation{
+						Kind: annotate.KindLint, Path: file, Line: line, Message: msg,
+					})
+				}
+			}
+		}
+		for _, t := range scanTodos(files, source) {
+			annotations = append(annotations, annotate.Annotation{
+				Kind:    annotate.KindTodo,
+				Path:    t.file.Path,
+				Line:    t.line.NewLineNum,
+				Code:    t.marker,
+				Message: strings.TrimSpace(t.line.Content),
+			})
+		}
+		for _, t := range threads {
+			// Comments on hunks that only delete lines point at the line
+			// before the deletion, the closest line of the head version
+			start := max(t.hunk.NewStart, 1)
+			end := max(start, t.hunk.NewStart+t.hunk.NewCount-1)
+			annotations = append(annotations, annotate.Annotation{
+				Kind:    annotate.KindComment,
+				Path:    t.path,
+				Line:    start,
+				EndLine: end,
+				Message: strings.Join(t.comments, "\n\n"),
+			})
+		}
+		sortAnnotations(annotations)
+
+		encode := annotate.RDJSON
+		if format == exportSARIF {
+			encode = annotate.SARIF
+		}
+		data, err := encode(annotations)
+		if err == nil {
+			err = os.WriteFile(path, append(data, '\n'), 0o644)
+		}
+		if err != nil {
+			return actionDoneMsg{notice: "Export failed: " + err.Error()}
+		}
+		return actionDoneMsg{notice: fmt.Sprintf("Exported %s to %s", plural(len(annotations), "annotation"), path)}
+	}
+}
+
+// sortAnnotations orders annotations by file and line, as the diagnostics
+// map doesn't keep an order
+func sortAnnotations(annotations []annotate.Annotation) {
+	slices.SortStableFunc(annotations, func(a, b annotate.Annotation) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return a.Line - b.Line
+	})
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startGoto opens the : prompt, which takes the number of a file to jump to
+// in the file list, and a line number in the diff view
+func (m *Model) startGoto() tea.Cmd {
+	m.jumping = true
+	m.gotoInput.Prompt = "Go to file: "
+	m.gotoInput.Placeholder = ""
+	if m.focusedPane == PaneDiffView {
+		m.gotoInput.Prompt = "Go to line: "
+		m.gotoInput.Placeholder = "new line, or -n for an old one"
+	}
+	m.gotoInput.SetValue("")
+	m.gotoInput.Focus()
+	return textinput.Blink
+}
+
+// updateGotoPrompt handles keys while the : prompt is open
+func (m Model) updateGotoPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.jumping = false
+		m.gotoInput.Blur()
+		return m, nil
+	case "enter":
+		m.jumping = false
+		m.gotoInput.Blur()
+		value := strings.TrimSpace(m.gotoInput.Value())
+		if value == "" {
+			return m, nil
+		}
+		if m.focusedPane == PaneDiffView {
+			m.gotoLine(value)
+			return m, nil
+		}
+		return m, m.gotoFile(value)
+	}
+
+	var cmd tea.Cmd
+	m.gotoInput, cmd = m.gotoInput.Update(msg)
+	return m, cmd
+}
+
+// gotoLine moves the diff cursor to the line typed at the : prompt: a line
+// of the new version, or of the old one after a -
+func (m *Model) gotoLine(value string) {
+	number, old := strings.CutPrefix(value, "-")
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		m.notice = fmt.Sprintf("Not a line number: %s", value)
+		return
+	}
+	side := "new"
+	if old {
+		side = "old"
+	}
+	if !m.diffView.JumpToFileLine(n, old) {
+		m.notice = fmt.Sprintf("Line %d of the %s file isn't in the diff; showing the nearest", n, side)
+	}
+}
+
+// gotoFile shows the file with the number typed at the : prompt
+func (m *Model) gotoFile(value string) tea.Cmd {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		m.notice = fmt.Sprintf("Not a file number: %s", value)
+		return nil
+	}
+	file := m.fileList.JumpToNumber(n)
+	if file == nil {
+		m.notice = fmt.Sprintf("No file %d", n)
+		return nil
+	}
+	m.setFocus(PaneDiffView)
+	return m.startDiffLoad(*file)
+}
+
+// stepFile shows the diff of the nth file after the selected one, or before
+// it when n is negative, stopping at the first and last files
+func (m *Model) stepFile(n int) tea.Cmd {
+	file := m.fileList.StepFile(n)
+	switch {
+	case file != nil:
+		return tea.Batch(m.startDiffLoad(*file), m.prefetch(m.fileList.Neighbors(prefetchRadius)))
+	case n > 0:
+		m.notice = "Last file"
+	default:
+		m.notice = "First file"
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui/picker"
+)
+
+// pickHead identifies the head list in picker messages
+const pickHead = "head"
+
+// headOther is the picker value that asks for a ref to type in
+const headOther = ":other"
+
+// refsLoadedMsg is sent when the branches and tags have been listed
+type refsLoadedMsg struct {
+	refs []string
+	err  error
+}
+
+// loadRefs lists the branches and tags for the head picker
+func (m Model) loadRefs() tea.Cmd {
+	switcher, ok := m.source.(HeadSwitcher)
+	if !ok {
+		return func() tea.Msg {
+			return refsLoadedMsg{err: errors.New("switching the head is not available in this view")}
+		}
+	}
+	return func() tea.Msg {
+		refs, err := switcher.Refs()
+		return refsLoadedMsg{refs: refs, err: err}
+	}
+}
+
+// openHeadPicker shows HEAD, the working tree and the index followed by the
+// branches and tags, with the current head selected
+func (m *Model) openHeadPicker(refs []string) {
+	switcher, ok := m.source.(HeadSwitcher)
+	if !ok {
+		return
+	}
+	items := []picker.Item{
+		{Label: "HEAD", Detail: "committed changes", Value: "HEAD"},
+		{Label: "Working tree", Detail: "including uncommitted changes", Value: git.WorkTree},
+		{Label: "Index", Detail: "including staged changes", Value: git.Index},
+	}
+	for _, ref := range refs {
+		items = append(items, picker.Item{Label: ref, Value: ref})
+	}
+	items = append(items, picker.Item{Label: "Other ref…", Detail: "a commit, tag or expression such as HEAD~2", Value: headOther})
+
+	current := 0
+	for i, item := range items {
+		if item.Value == switcher.Head() {
+			current = i
+			items[i].Detail = strings.TrimSpace(item.Detail + " (current)")
+		}
+	}
+	m.picker.Open(pickHead, "Head", items, current)
+}
+
+// switchHead reloads the changeset ending at head, or opens the prompt for
+// a ref when head is headOther
+func (m *Model) switchHead(head string) tea.Cmd {
+	switcher, ok := m.source.(HeadSwitcher)
+	if !ok {
+		return nil
+	}
+	if head == headOther {
+		m.enteringHead = true
+		m.headInput.SetValue("")
+		m.headInput.Focus()
+		return textinput.Blink
+	}
+	if head == switcher.Head() {
+		return nil
+	}
+	switcher.SetHead(head)
+	return m.startRepoLoad()
+}
+
+// updateHeadPrompt handles keys while the ref prompt is open. Enter switches
+// the head to the typed ref.
+func (m Model) updateHeadPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.enteringHead = false
+		m.headInput.Blur()
+		return m, nil
+	case "enter":
+		m.enteringHead = false
+		m.headInput.Blur()
+		if ref := strings.TrimSpace(m.headInput.Value()); ref != "" {
+			return m, m.switchHead(ref)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.headInput, cmd = m.headInput.Update(msg)
+	return m, cmd
+}
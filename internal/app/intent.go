@@ -0,0 +1,44 @@
+package app
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// intentAddedMsg is sent when an untracked file has been added with intent
+type intentAddedMsg struct {
+	path string
+	err  error
+}
+
+// intentToAdd records the untracked file under the cursor in the index with
+// git add -N, so its lines become unstaged additions that can be reverted or
+// staged like those of any tracked file
+func (m *Model) intentToAdd() tea.Cmd {
+	adder, ok := m.source.(IntentAdder)
+	if !ok {
+		m.notice = "Untracked files are only listed by git-diffs status"
+		return nil
+	}
+	file := m.fileList.SelectedFile()
+	if file == nil || file.Status != git.StatusUnknown {
+		m.notice = "Select an untracked file to add it with intent"
+		return nil
+	}
+	path := file.Path
+	return func() tea.Msg {
+		return intentAddedMsg{path: path, err: adder.IntentToAdd(path)}
+	}
+}
+
+// showIntentAdded reloads the changes to list the added file as a tracked
+// addition
+func (m *Model) showIntentAdded(msg intentAddedMsg) tea.Cmd {
+	if msg.err != nil {
+		return m.showToast(msg.err.Error())
+	}
+	m.notice = fmt.Sprintf("Added %s with intent; its lines are now unstaged additions", msg.path)
+	return m.startRepoLoad()
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+)
+
+// fileLinks returns what file names and line numbers link to: the files on
+// disk, or the link_url template filled in. Nil when links are off or the
+// changeset's paths aren't files.
+func (m Model) fileLinks() ui.LinkFunc {
+	if !m.hyperlinks {
+		return nil
+	}
+	locator, ok := m.source.(Locator)
+	if !ok {
+		return nil
+	}
+
+	if m.linkURL == "" {
+		// file:// URLs name the host so terminals can tell remote files apart
+		host, _ := os.Hostname()
+		return func(path string, line int) string {
+			abs, err := locator.AbsPath(path)
+			if err != nil || line > 0 {
+				return ""
+			}
+			return (&url.URL{Scheme: "file", Host: host, Path: filepath.ToSlash(abs)}).String()
+		}
+	}
+
+	template, sha := m.linkURL, m.headSHA
+	if sha == "" && strings.Contains(template, "{sha}") {
+		return nil
+	}
+	lines := strings.Contains(template, "{line}")
+	return func(path string, line int) string {
+		if line > 0 && !lines {
+			return ""
+		}
+		return strings.NewReplacer(
+			"{path}", escapePath(path),
+			"{line}", strconv.Itoa(max(line, 1)),
+			"{sha}", sha,
+		).Replace(template)
+	}
+}
+
+// escapePath escapes each segment of a slash-separated path for a URL
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/lint"
+)
+
+// lintDoneMsg is sent when the linter has finished
+type lintDoneMsg struct {
+	diagnostics lint.Diagnostics
+	err         error
+}
+
+// runLint runs the configured linter over the changed files that still
+// exist, in the background
+func (m *Model) runLint() tea.Cmd {
+	if m.lint == "" {
+		m.notice = "No linter configured; set lint in the config file"
+		return nil
+	}
+	if _, ok := m.source.(Locator); !ok || m.repo == nil {
+		m.notice = "Linting needs the changed files on disk"
+		return nil
+	}
+	var paths []string
+	for _, f := range m.files {
+		if f.Status != git.StatusDeleted {
+			paths = append(paths, f.Path)
+		}
+	}
+	command, root := m.lint, m.repo.Root()
+	m.notice = "Linting " + strings.Fields(command)[0] + "…"
+	return func() tea.Msg {
+		diags, err := lint.Run(command, root, paths)
+		return lintDoneMsg{diagnostics: diags, err: err}
+	}
+}
+
+// showLint marks the diagnostics in the diff view and sums them up
+func (m *Model) showLint(msg lintDoneMsg) {
+	if msg.err != nil {
+		m.notice = "Lint failed: " + msg.err.Error()
+		return
+	}
+	m.diagnostics = msg.diagnostics
+	m.diffView.SetDiagnostics(msg.diagnostics)
+	switch n := msg.diagnostics.Count(); n {
+	case 0:
+		m.notice = "Lint: no problems"
+	case 1:
+		m.notice = "Lint: 1 problem"
+	default:
+		files := "1 file"
+		if len(msg.diagnostics) > 1 {
+			files = fmt.Sprintf("%d files", len(msg.diagnostics))
+		}
+		m.notice = fmt.Sprintf("Lint: %d problems in %s", n, files)
+	}
+}
+
+// lintHint returns the linter messages for the diff cursor line, shown in
+// the footer in place of the key hints
+func (m Model) lintHint() string {
+	if m.focusedPane != PaneDiffView {
+		return ""
+	}
+	msgs := m.diffView.CursorDiagnostics()
+	if len(msgs) == 0 {
+		return ""
+	}
+	return "lint: " + strings.Join(msgs, "; ")
+}
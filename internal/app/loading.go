@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+)
+
+// loadState tracks the background loads shown with a spinner
+type loadState struct {
+	spinner   spinner.Model
+	repoSince time.Time // Zero when the changeset isn't loading
+	fetching  bool      // The base's remote is being fetched before loading
+	diffPath  string    // File whose diff is loading, if any
+	diffSince time.Time
+}
+
+func newLoadState() loadState {
+	return loadState{
+		spinner: spinner.New(
+			spinner.WithSpinner(spinner.MiniDot),
+			spinner.WithStyle(lipgloss.NewStyle().Foreground(ui.ColorPrimary)),
+		),
+	}
+}
+
+// active reports whether anything is loading, which keeps the spinner ticking
+func (l loadState) active() bool {
+	return !l.repoSince.IsZero() || l.diffPath != ""
+}
+
+// status renders the spinner, a label and the time spent so far
+func (l loadState) status(label string, since time.Time) string {
+	elapsed := time.Since(since).Seconds()
+	return fmt.Sprintf("%s %s %.1fs", l.spinner.View(), label, elapsed)
+}
+
+// repoLabel describes the current stage of loading the changeset
+func (l loadState) repoLabel() string {
+	if l.fetching {
+		return "Fetching…"
+	}
+	return "Loading changes…"
+}
+
+// startRepoLoad reloads the changeset, showing a spinner in the header. With
+// fetching enabled the base's remote is fetched first.
+func (m *Model) startRepoLoad() tea.Cmd {
+	m.loading.repoSince = time.Now()
+	if _, ok := m.source.(Fetcher); ok && m.fetch {
+		m.loading.fetching = true
+		return tea.Batch(m.fetchRemote(), m.loading.spinner.Tick)
+	}
+	return tea.Batch(m.loadRepo(), m.loading.spinner.Tick)
+}
+
+// startDiffLoad loads a file's diff, showing a placeholder in the diff pane
+// unless the diff is cached or already displayed
+func (m *Model) startDiffLoad(file git.ChangedFile) tea.Cmd {
+	if _, ok := m.diffs.get(m.diffKeyFor(file)); ok || file.Path == m.diffView.FilePath() {
+		return m.loadDiff(file)
+	}
+	m.loading.diffPath = file.Path
+	m.loading.diffSince = time.Now()
+	return tea.Batch(m.loadDiff(file), m.loading.spinner.Tick)
+}
+
+// diffPlaceholder renders the diff pane while a diff loads
+func (m Model) diffPlaceholder() string {
+	return m.loading.status("Loading "+filepath.Base(m.loading.diffPath)+"…", m.loading.diffSince)
+}
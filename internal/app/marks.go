@@ -0,0 +1,120 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui/picker"
+)
+
+// pickMark identifies the bookmark list in picker messages
+const pickMark = "mark"
+
+// mark is a bookmarked line of a file's diff, set like a vim mark
+type mark struct {
+	file git.ChangedFile
+	line git.DiffLine
+}
+
+// pendingMark is the mark key waiting for the letter that names the mark
+type pendingMark string
+
+const (
+	markSet  pendingMark = "m"
+	markJump pendingMark = "'"
+)
+
+// isMarkName reports whether key names a mark: a single letter
+func isMarkName(key string) bool {
+	return len(key) == 1 && (key[0] >= 'a' && key[0] <= 'z' || key[0] >= 'A' && key[0] <= 'Z')
+}
+
+// finishMark sets or jumps to the mark named by key, the key pressed after
+// m or '
+func (m *Model) finishMark(pending pendingMark, key string) tea.Cmd {
+	if !isMarkName(key) {
+		return nil
+	}
+	if pending == markSet {
+		m.setMark(key)
+		return nil
+	}
+	return m.jumpToMark(key)
+}
+
+// setMark bookmarks the diff line under the cursor
+func (m *Model) setMark(name string) {
+	line, ok := m.diffView.CursorDiffLine()
+	if !ok {
+		m.notice = "Nothing to mark"
+		return
+	}
+	path := m.diffView.FilePath()
+	for _, f := range m.files {
+		if f.Path == path {
+			m.marks[name] = mark{file: f, line: line}
+			m.notice = fmt.Sprintf("Marked %s as %s", markLocation(path, line), name)
+			return
+		}
+	}
+	m.notice = "Nothing to mark"
+}
+
+// jumpToMark shows the file of a mark with the cursor on its line
+func (m *Model) jumpToMark(name string) tea.Cmd {
+	mk, ok := m.marks[name]
+	if !ok {
+		m.notice = fmt.Sprintf("Mark %s is not set", name)
+		return nil
+	}
+	if !slices.ContainsFunc(m.files, func(f git.ChangedFile) bool { return f.Path == mk.file.Path }) {
+		m.notice = fmt.Sprintf("%s is no longer changed", mk.file.Path)
+		return nil
+	}
+	m.setFocus(PaneDiffView)
+	if mk.file.Path == m.diffView.FilePath() {
+		m.diffView.JumpToDiffLine(mk.line)
+		return nil
+	}
+	m.pendingJump = &pendingJump{filePath: mk.file.Path, line: mk.line}
+	return m.startDiffLoad(mk.file)
+}
+
+// openMarkPicker lists the marks in alphabetical order
+func (m *Model) openMarkPicker() {
+	if len(m.marks) == 0 {
+		m.notice = "No marks set; press m and a letter in the diff view to set one"
+		return
+	}
+	names := make([]string, 0, len(m.marks))
+	for name := range m.marks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	items := make([]picker.Item, len(names))
+	for i, name := range names {
+		mk := m.marks[name]
+		items[i] = picker.Item{
+			Label:  name + "  " + markLocation(mk.file.Path, mk.line),
+			Detail: strings.TrimSpace(mk.line.Content),
+			Value:  name,
+		}
+	}
+	m.picker.Open(pickMark, "Marks", items, 0)
+}
+
+// markLocation describes a marked line as path:line, using the old line
+// number for deletions
+func markLocation(path string, line git.DiffLine) string {
+	switch {
+	case line.Type == git.DiffLineDeletion:
+		return fmt.Sprintf("%s:%d (old)", path, line.OldLineNum)
+	case line.NewLineNum > 0:
+		return fmt.Sprintf("%s:%d", path, line.NewLineNum)
+	}
+	return path
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/ui/picker"
+)
+
+// pickConflict identifies the would-be conflicts in picker messages
+const pickConflict = "conflict"
+
+// mergeCheckedMsg is sent when the test merge of head into base is done
+type mergeCheckedMsg struct {
+	conflicts []string
+	err       error
+}
+
+// mergeStatus is the outcome of the test merge shown in the header
+type mergeStatus struct {
+	checked   bool
+	conflicts []string // Files that would conflict
+}
+
+// checkMerge tests merging the head into the base in the background. The
+// test merge writes objects, so it's skipped in read-only mode.
+func (m Model) checkMerge() tea.Cmd {
+	checker, ok := m.source.(MergeChecker)
+	if !ok || m.readOnly {
+		return nil
+	}
+	return func() tea.Msg {
+		conflicts, err := checker.MergeConflicts()
+		return mergeCheckedMsg{conflicts: conflicts, err: err}
+	}
+}
+
+// badge returns the header badge for the merge status; empty until checked
+func (s mergeStatus) badge() string {
+	switch {
+	case !s.checked:
+		return ""
+	case len(s.conflicts) == 0:
+		return " [merges cleanly]"
+	case len(s.conflicts) == 1:
+		return " [1 file would conflict]"
+	}
+	return fmt.Sprintf(" [%d files would conflict]", len(s.conflicts))
+}
+
+// openConflictPicker lists the files that would conflict when merging
+func (m *Model) openConflictPicker() {
+	switch {
+	case m.readOnly:
+		m.notice = "The merge check is off in read-only mode"
+		return
+	case !m.merge.checked:
+		m.notice = "Merge not checked yet"
+		return
+	case len(m.merge.conflicts) == 0:
+		m.notice = fmt.Sprintf("Merges cleanly into %s", m.baseBranch)
+		return
+	}
+	items := make([]picker.Item, len(m.merge.conflicts))
+	for i, path := range m.merge.conflicts {
+		items[i] = picker.Item{Label: path, Value: path}
+	}
+	m.picker.Open(pickConflict, fmt.Sprintf("Would conflict with %s (%d)", m.baseBranch, len(items)), items, 0)
+}
+
+// showConflictFile opens the diff of a file that would conflict
+func (m *Model) showConflictFile(path string) tea.Cmd {
+	for _, f := range m.files {
+		if f.Path == path {
+			m.setFocus(PaneDiffView)
+			return m.startDiffLoad(f)
+		}
+	}
+	m.notice = path + " is not in the changed files"
+	return nil
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// assignOwners names the owners of the files of cs from the repository's
+// CODEOWNERS file, keeping only the files owned by one of mine when
+// onlyMine is set. It reports whether there is a CODEOWNERS file.
+func assignOwners(cs *Changeset, mine []string, onlyMine bool) (bool, error) {
+	if cs.Repo == nil {
+		return false, nil
+	}
+	codeowners, err := cs.Repo.Codeowners()
+	if err != nil {
+		return false, fmt.Errorf("failed to read CODEOWNERS: %w", err)
+	}
+	if codeowners == nil {
+		return false, nil
+	}
+	kept := cs.Files[:0:0]
+	for _, f := range cs.Files {
+		f.Owners = codeowners.Owners(f.Path)
+		if !onlyMine || ownedBy(f, mine) {
+			kept = append(kept, f)
+		}
+	}
+	cs.Files = kept
+	return true, nil
+}
+
+// ownedBy reports whether one of owners owns f. Handles compare without
+// regard to case, as GitHub's do.
+func ownedBy(f git.ChangedFile, owners []string) bool {
+	return slices.ContainsFunc(f.Owners, func(owner string) bool {
+		return slices.ContainsFunc(owners, func(mine string) bool {
+			return strings.EqualFold(owner, mine)
+		})
+	})
+}
+
+// toggleMine shows only the files owned by the configured owners, or all
+// of them again
+func (m *Model) toggleMine() tea.Cmd {
+	switch {
+	case len(m.ownedBy) == 0:
+		m.notice = "Set owned_by in the config file to your CODEOWNERS handles and teams"
+		return nil
+	case !m.hasCodeowners && !m.onlyMine:
+		m.notice = "The repository has no CODEOWNERS file"
+		return nil
+	}
+	m.onlyMine = !m.onlyMine
+	return m.startRepoLoad()
+}
@@ -0,0 +1,23 @@
+package app
+
+// togglePin pins the diff being viewed above the diff view, where it stays
+// while other files are browsed below it, or closes the pinned diff
+func (m *Model) togglePin() {
+	if m.pinned != nil {
+		m.pinned = nil
+		if m.focusedPane == PanePinned {
+			m.setFocus(PaneDiffView)
+		}
+		m.updateLayout()
+		return
+	}
+	if m.diffView.Diff() == nil {
+		m.notice = "Nothing to pin"
+		return
+	}
+	pinned := m.diffView.Pin()
+	m.pinned = &pinned
+	m.setFocus(PaneDiffView)
+	m.updateLayout()
+	m.notice = "Pinned " + m.diffView.FilePath() + "; ctrl+g/ctrl+h move between the diffs"
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// unsaved describes the review state that quitting would discard, or ""
+// when there is none. Marks and comments only live for the session.
+func (m Model) unsaved() string {
+	var lost []string
+	if n := len(m.marks); n > 0 {
+		lost = append(lost, plural(n, "mark"))
+	}
+	if n := m.commentCount(); n > 0 {
+		lost = append(lost, plural(n, "comment"))
+	}
+	return strings.Join(lost, " and ")
+}
+
+// plural formats a count of things, e.g. "1 mark" or "3 marks"
+func plural(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
+
+// QuitMsg is sent instead of quitting the program when the model is
+// embedded, so the host can close it
+type QuitMsg struct{}
+
+// quit exits, asking for the key to be pressed again first when review
+// state would be lost. ctrl+c always quits at once.
+func (m *Model) quit(pressed string, confirmed bool) tea.Cmd {
+	lost := m.unsaved()
+	if lost == "" || confirmed || pressed == "ctrl+c" {
+		if m.embedded {
+			return func() tea.Msg { return QuitMsg{} }
+		}
+		return tea.Quit
+	}
+	m.confirm = pressed
+	m.notice = fmt.Sprintf("Quitting discards %s. Press %s again to quit", lost, pressed)
+	return nil
+}
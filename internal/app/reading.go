@@ -0,0 +1,37 @@
+package app
+
+// toggleReading turns the hunk reading mode on or off. Turning it on
+// centers the hunk under the cursor.
+func (m *Model) toggleReading() {
+	m.reading = !m.reading
+	if !m.reading {
+		m.notice = "Reading mode off"
+		return
+	}
+	m.diffView.JumpToHunk(max(m.diffView.CursorHunk(), 0))
+	m.notice = "Reading mode: space for the next hunk, shift+space or b for the previous"
+}
+
+// pageHunk moves n hunks forward, or back, in the diff shown, stopping at
+// the first and last hunks
+func (m *Model) pageHunk(next bool, n int) {
+	diff := m.diffView.Diff()
+	if diff == nil || len(diff.Hunks) == 0 {
+		m.notice = "No hunks"
+		return
+	}
+	h := m.diffView.CursorHunk()
+	switch {
+	case next && h >= len(diff.Hunks)-1:
+		m.notice = "Last hunk of " + m.diffView.FilePath()
+		return
+	case !next && h <= 0:
+		m.notice = "First hunk of " + m.diffView.FilePath()
+		return
+	case next:
+		h = min(h+n, len(diff.Hunks)-1)
+	default:
+		h = max(h-n, 0)
+	}
+	m.diffView.JumpToHunk(h)
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui/picker"
+)
+
+// pickRecent identifies the recently viewed files in picker messages
+const pickRecent = "recent"
+
+// maxRecent bounds the recently viewed files remembered
+const maxRecent = 20
+
+// visitFile records that the diff of path is about to be shown, keeping
+// where the view was in the file shown before so returning restores it.
+// The files are kept most recent first.
+func (m *Model) visitFile(path string) {
+	current := m.diffView.FilePath()
+	if current == path {
+		return
+	}
+	visited := []diffPosition{{filePath: path}}
+	if current != "" {
+		offset, cursor := m.diffView.Position()
+		visited = append(visited, diffPosition{filePath: current, offset: offset, cursor: cursor})
+	}
+	m.recent = slices.DeleteFunc(m.recent, func(p diffPosition) bool {
+		return p.filePath == path || p.filePath == current
+	})
+	m.recent = append(visited, m.recent...)
+	if len(m.recent) > maxRecent {
+		m.recent = m.recent[:maxRecent]
+	}
+}
+
+// toggleRecent goes back to the file viewed before the current one, like
+// vim's alternate file
+func (m *Model) toggleRecent() tea.Cmd {
+	if len(m.recent) < 2 {
+		m.notice = "No previous file"
+		return nil
+	}
+	return m.openRecent(m.recent[1].filePath)
+}
+
+// openRecent shows a recently viewed file where its view was left
+func (m *Model) openRecent(path string) tea.Cmd {
+	i := slices.IndexFunc(m.recent, func(p diffPosition) bool { return p.filePath == path })
+	j := slices.IndexFunc(m.files, func(f git.ChangedFile) bool { return f.Path == path })
+	if i < 0 || j < 0 {
+		m.notice = fmt.Sprintf("%s is no longer changed", path)
+		return nil
+	}
+	position := m.recent[i]
+	m.restore = &position
+	return m.startDiffLoad(m.files[j])
+}
+
+// openRecentPicker lists the recently viewed files, most recent first,
+// leaving out the one shown
+func (m *Model) openRecentPicker() {
+	var items []picker.Item
+	for _, p := range m.recent {
+		if p.filePath != m.diffView.FilePath() {
+			items = append(items, picker.Item{Label: p.filePath, Value: p.filePath})
+		}
+	}
+	if len(items) == 0 {
+		m.notice = "No other files viewed yet"
+		return
+	}
+	m.picker.Open(pickRecent, fmt.Sprintf("Recent files (%d)", len(items)), items, 0)
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// refDiffMsg is sent when a file has been diffed between two refs
+type refDiffMsg struct {
+	path     string
+	from, to string
+	diff     *git.FileDiff
+	err      error
+}
+
+// startRefDiff opens the prompt for the two refs to compare the selected
+// file between, whatever the compared range is
+func (m *Model) startRefDiff() tea.Cmd {
+	file := m.difftoolFile()
+	switch {
+	case file == nil:
+		m.notice = "Nothing to compare"
+		return nil
+	case m.repo == nil:
+		m.notice = "Comparing refs needs a git repository"
+		return nil
+	}
+	m.refDiffPath = file.Path
+	m.refDiffInput.Prompt = "Compare " + file.Path + " between: "
+	m.refDiffInput.SetValue("")
+	m.refDiffInput.Focus()
+	return textinput.Blink
+}
+
+// updateRefDiffPrompt handles keys while the ref prompt is open
+func (m Model) updateRefDiffPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.refDiffPath = ""
+		m.refDiffInput.Blur()
+		return m, nil
+	case "enter":
+		path := m.refDiffPath
+		m.refDiffPath = ""
+		m.refDiffInput.Blur()
+		value := strings.TrimSpace(m.refDiffInput.Value())
+		if value == "" {
+			return m, nil
+		}
+		return m, m.diffRefs(path, value)
+	}
+
+	var cmd tea.Cmd
+	m.refDiffInput, cmd = m.refDiffInput.Update(msg)
+	return m, cmd
+}
+
+// parseRefPair splits the refs typed at the prompt, "a b" or "a..b", with
+// the second one HEAD when left out
+func parseRefPair(value string) (from, to string, ok bool) {
+	refs := strings.Fields(value)
+	if len(refs) == 1 {
+		refs = strings.SplitN(refs[0], "..", 2)
+	}
+	switch {
+	case len(refs) == 1:
+		return refs[0], "HEAD", true
+	case len(refs) == 2 && refs[0] != "" && refs[1] != "":
+		return refs[0], refs[1], true
+	}
+	return "", "", false
+}
+
+// diffRefs diffs path between the two refs typed at the prompt
+func (m *Model) diffRefs(path, value string) tea.Cmd {
+	from, to, ok := parseRefPair(value)
+	if !ok {
+		m.notice = "Type two refs, e.g. v1.0 v2.0, or one to compare with HEAD"
+		return nil
+	}
+	repo := m.repo
+	m.notice = fmt.Sprintf("Diffing %s between %s and %s…", path, from, to)
+	return func() tea.Msg {
+		file := git.ChangedFile{Path: path, Status: git.StatusModified}
+		diff, err := repo.GetFileDiff(from, to, git.CompareDirect, file)
+		return refDiffMsg{path: path, from: from, to: to, diff: diff, err: err}
+	}
+}
+
+// showRefDiff pins the diff between two refs above the diff view, so the
+// file's current changes stay in view below it
+func (m *Model) showRefDiff(msg refDiffMsg) tea.Cmd {
+	m.notice = ""
+	if msg.err != nil {
+		return m.showToast(msg.err.Error())
+	}
+	pinned := m.diffView.Pin()
+	pinned.SetDiff(msg.diff, msg.path)
+	pinned.SetTitle(msg.from + " → " + msg.to)
+	pinned.SetThreads(nil)
+	pinned.SetDiagnostics(nil)
+	m.pinned = &pinned
+	m.updateLayout()
+	m.setFocus(PanePinned)
+	return nil
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// revert undoes the hunk under the cursor, or the whole displayed diff, in
+// the working tree. The first press only asks for confirmation; pressing the
+// same key again reverts.
+func (m *Model) revert(pressed string, confirmed, hunkOnly bool) tea.Cmd {
+	reverter, ok := m.source.(Reverter)
+	if !ok {
+		m.notice = "Changes in this view can't be reverted in the working tree"
+		return nil
+	}
+	diff := m.diffView.Diff()
+	if diff == nil || len(diff.Hunks) == 0 {
+		m.notice = "Nothing to revert"
+		return nil
+	}
+	if diff.Binary {
+		m.notice = "Binary files can't be reverted by hunk"
+		return nil
+	}
+	if diff.Encoding != "" {
+		m.notice = fmt.Sprintf("Diffs converted from %s can't be reverted", diff.Encoding)
+		return nil
+	}
+	path := m.diffView.FilePath()
+
+	var hunks []int
+	what := path
+	if hunkOnly {
+		h := m.diffView.CursorHunk()
+		if h < 0 {
+			m.notice = "Nothing to revert"
+			return nil
+		}
+		hunks = []int{h}
+		what = fmt.Sprintf("hunk %d/%d of %s", h+1, len(diff.Hunks), path)
+	}
+
+	if !confirmed {
+		m.confirm = pressed
+		m.notice = fmt.Sprintf("Press %s again to revert %s in the working tree", pressed, what)
+		return nil
+	}
+
+	return func() tea.Msg {
+		if err := reverter.Revert(diff, hunks...); err != nil {
+			return actionDoneMsg{notice: "Revert failed: " + err.Error()}
+		}
+		return actionDoneMsg{notice: "Reverted " + what + " in the working tree"}
+	}
+}
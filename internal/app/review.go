@@ -0,0 +1,213 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/platform"
+	"github.com/matthewmyrick/git-diffs/internal/ui/picker"
+)
+
+// Picker kinds of the finish review flow
+const (
+	pickVerdict      = "verdict"
+	pickReviewOutput = "review-output"
+)
+
+// Review verdicts, named like gh pr review's flags
+const (
+	verdictApprove        = "approve"
+	verdictComment        = "comment"
+	verdictRequestChanges = "request-changes"
+)
+
+// Where a finished review goes
+const (
+	outputMarkdown  = "markdown"
+	outputClipboard = "clipboard"
+	outputGitHub    = "github"
+)
+
+// review is the review being finished: the summary typed in, then the
+// verdict picked
+type review struct {
+	summary string
+	verdict string
+}
+
+// startReview opens the prompt for the review summary
+func (m *Model) startReview() tea.Cmd {
+	m.reviewing = true
+	m.reviewInput.SetValue("")
+	m.reviewInput.Focus()
+	return textinput.Blink
+}
+
+// updateReviewPrompt handles keys while the summary prompt is open. Enter
+// moves on to the verdict; the summary may be left empty.
+func (m Model) updateReviewPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.reviewing = false
+		m.reviewInput.Blur()
+		return m, nil
+	case "enter":
+		m.reviewing = false
+		m.reviewInput.Blur()
+		m.review = &review{summary: strings.TrimSpace(m.reviewInput.Value())}
+		m.picker.Open(pickVerdict, "Verdict", []picker.Item{
+			{Label: "Approve", Value: verdictApprove},
+			{Label: "Comment", Detail: "feedback without a verdict", Value: verdictComment},
+			{Label: "Request changes", Value: verdictRequestChanges},
+		}, 1)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.reviewInput, cmd = m.reviewInput.Update(msg)
+	return m, cmd
+}
+
+// pickReviewDestination records the verdict and asks where the review goes
+func (m *Model) pickReviewDestination(verdict string) {
+	if m.review == nil {
+		return
+	}
+	m.review.verdict = verdict
+	detail := fmt.Sprintf("%s, %s", plural(len(m.marks), "mark"), plural(m.commentCount(), "comment"))
+	m.picker.Open(pickReviewOutput, "Finish review ("+detail+")", []picker.Item{
+		{Label: "Markdown file", Detail: "in the repository's .git folder", Value: outputMarkdown},
+		{Label: "Clipboard", Detail: "as Markdown", Value: outputClipboard},
+		{Label: "GitHub", Detail: "gh pr review on the current branch's pull request", Value: outputGitHub},
+	}, 0)
+}
+
+// finishReview writes the review to output
+func (m Model) finishReview(output string) tea.Cmd {
+	if m.review == nil {
+		return nil
+	}
+	body := m.reviewMarkdown(*m.review, output != outputGitHub)
+	verdict := m.review.verdict
+	dir := m.outputDir()
+	repo := m.repo
+
+	return func() tea.Msg {
+		switch output {
+		case outputMarkdown:
+			path := filepath.Join(dir, "git-diffs-review.md")
+			if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+				return actionDoneMsg{notice: "Saving the review failed: " + err.Error()}
+			}
+			return actionDoneMsg{notice: "Saved the review to " + path}
+		case outputClipboard:
+			if err := platform.CopyToClipboard(body); err != nil {
+				return actionDoneMsg{notice: "Copy failed: " + err.Error()}
+			}
+			return actionDoneMsg{notice: "Copied the review"}
+		}
+
+		// The header's branch is a label, e.g. "feat (unstaged)", so the pull
+		// request is found by its number
+		var pr *git.PullRequest
+		if repo != nil {
+			pr = repo.PullRequest()
+		}
+		if pr == nil {
+			return actionDoneMsg{notice: "No open pull request for the checked out branch to submit the review to"}
+		}
+		number := strconv.Itoa(pr.Number)
+		cmd := exec.Command("gh", "pr", "review", number, "--"+verdict, "--body-file", "-")
+		cmd.Dir = repo.Root()
+		cmd.Stdin = strings.NewReader(body)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			msg := strings.TrimSpace(string(out))
+			if msg == "" {
+				msg = err.Error()
+			}
+			msg, _, _ = strings.Cut(msg, "\n")
+			return actionDoneMsg{notice: "gh pr review failed: " + msg}
+		}
+		return actionDoneMsg{notice: "Submitted the review of PR #" + number}
+	}
+}
+
+// outputDir returns where files written for the review go: the
+// repository's .git folder, or the temporary folder without a repository
+func (m Model) outputDir() string {
+	if m.repo != nil {
+		if gitDir, err := m.repo.GitDir(); err == nil {
+			return gitDir
+		}
+	}
+	return os.TempDir()
+}
+
+// reviewMarkdown formats a review: the verdict unless GitHub records it,
+// the summary, the comment threads by file and hunk, and the marked lines
+func (m Model) reviewMarkdown(r review, withVerdict bool) string {
+	var b strings.Builder
+	if withVerdict {
+		fmt.Fprintf(&b, "# Review of %s → %s\n\n", m.currentBranch, m.baseBranch)
+		verdicts := map[string]string{
+			verdictApprove:        "Approved",
+			verdictComment:        "Commented",
+			verdictRequestChanges: "Changes requested",
+		}
+		fmt.Fprintf(&b, "**%s**\n\n", verdicts[r.verdict])
+	}
+	if r.summary != "" {
+		b.WriteString(r.summary + "\n\n")
+	}
+
+	threads := slices.Clone(m.threads)
+	slices.SortStableFunc(threads, func(a, b *thread) int { return strings.Compare(a.path, b.path) })
+	if len(threads) > 0 {
+		b.WriteString("## Comments\n\n")
+	}
+	for _, t := range threads {
+		fmt.Fprintf(&b, "**%s** %s\n\n", t.path, hunkRange(t))
+		for i, c := range t.comments {
+			if i > 0 {
+				c = "↳ " + c
+			}
+			fmt.Fprintf(&b, "> %s\n", c)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(m.marks) > 0 {
+		b.WriteString("## Marked lines\n\n")
+		names := make([]string, 0, len(m.marks))
+		for name := range m.marks {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			mk := m.marks[name]
+			fmt.Fprintf(&b, "- `%s` %s: `%s`\n", name, markLocation(mk.file.Path, mk.line), strings.TrimSpace(mk.line.Content))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// hunkRange describes the lines of a thread's hunk, on the new side unless
+// it only has old lines
+func hunkRange(t *thread) string {
+	start, count, side := t.hunk.NewStart, t.hunk.NewCount, ""
+	if count == 0 {
+		start, count, side = t.hunk.OldStart, t.hunk.OldCount, " (old)"
+	}
+	if count <= 1 {
+		return fmt.Sprintf("line %d%s", start, side)
+	}
+	return fmt.Sprintf("lines %d-%d%s", start, start+count-1, side)
+}
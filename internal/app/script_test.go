@@ -0,0 +1,151 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matthewmyrick/git-diffs/internal/script"
+)
+
+// testRepo creates a repository with files committed on main and returns
+// its path
+func testRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	writeFiles(t, dir, files)
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// runKeys presses keys in a model headlessly and returns the final screen
+func runKeys(t *testing.T, m Model, keys ...string) string {
+	t.Helper()
+	return runKeysSettling(t, m, script.DefaultOptions().Settle, keys...)
+}
+
+// runKeysSettling is runKeys waiting settle after each key
+func runKeysSettling(t *testing.T, m Model, settle time.Duration, keys ...string) string {
+	t.Helper()
+	steps, err := script.Parse(strings.NewReader(strings.Join(keys, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := script.DefaultOptions()
+	opts.Width, opts.Height = 100, 24
+	opts.Settle = settle
+	frame, err := script.Run(m, steps, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frame
+}
+
+// assertContains fails unless the screen shows each of want
+func assertContains(t *testing.T, screen string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(screen, w) {
+			t.Errorf("screen doesn't show %q:\n%s", w, screen)
+		}
+	}
+}
+
+func TestScriptNavigation(t *testing.T) {
+	dir := testRepo(t, map[string]string{"a.txt": "a\n", "b.txt": "b\n", "c.txt": "c\n"})
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	writeFiles(t, dir, map[string]string{"a.txt": "a2\n", "b.txt": "b2\n", "c.txt": "c2\n"})
+	runGit(t, dir, "commit", "-q", "-am", "change")
+
+	m := New(Options{BaseBranch: "main", Worktree: dir})
+	screen := runKeys(t, m, "j", "j", "enter")
+	assertContains(t, screen, "FILES (3)", "DIFF: c.txt", "c2")
+
+	screen = runKeys(t, New(Options{BaseBranch: "main", Worktree: dir}), "G", "k", "enter")
+	assertContains(t, screen, "DIFF: b.txt", "b2")
+}
+
+func TestScriptStagingRoundTrip(t *testing.T) {
+	dir := testRepo(t, map[string]string{"staged.txt": "one\n", "unstaged.txt": "two\n"})
+	writeFiles(t, dir, map[string]string{"staged.txt": "one changed\n", "unstaged.txt": "two changed\n"})
+	runGit(t, dir, "add", "staged.txt")
+	t.Chdir(dir)
+
+	screen := runKeys(t, New(Options{Source: NewStatusSource(nil)}))
+	assertContains(t, screen, "unstaged: index → working tree", "unstaged.txt")
+
+	screen = runKeys(t, New(Options{Source: NewStatusSource(nil)}), "S")
+	assertContains(t, screen, "staged: HEAD → index", "staged.txt", "one changed")
+
+	screen = runKeys(t, New(Options{Source: NewStatusSource(nil)}), "S", "S")
+	assertContains(t, screen, "unstaged: index → working tree", "two changed")
+
+	// Reverting the unstaged hunk leaves the file as staged, which is HEAD
+	runKeys(t, New(Options{Source: NewStatusSource(nil)}), "enter", "x", "x")
+	content, err := os.ReadFile(filepath.Join(dir, "unstaged.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "two\n" {
+		t.Errorf("unstaged.txt after reverting = %q, want %q", content, "two\n")
+	}
+}
+
+func TestScriptReloadDuringPrefetch(t *testing.T) {
+	files := make(map[string]string)
+	for i := range 30 {
+		files[fmt.Sprintf("f%02d.txt", i)] = "old\n"
+	}
+	dir := testRepo(t, files)
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	for name := range files {
+		files[name] = "new\n"
+	}
+	writeFiles(t, dir, files)
+	runGit(t, dir, "commit", "-q", "-am", "change")
+
+	// Each move prefetches the files around the cursor, and r reloads
+	// while those loads are still running
+	var keys []string
+	for range 5 {
+		keys = append(keys, "j", "j", "r")
+	}
+	keys = append(keys, "enter", "wait 1s")
+	screen := runKeysSettling(t, New(Options{BaseBranch: "main", Worktree: dir}), 5*time.Millisecond, keys...)
+	assertContains(t, screen, "FILES (30)", "DIFF: f10.txt", "new")
+}
@@ -0,0 +1,1089 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// Source supplies the changeset displayed by the app. The default source
+// compares the current branch against a base branch; other modes such as
+// range-diff provide their own.
+type Source interface {
+	// Load returns the changed files and the context shown in the header
+	Load() (*Changeset, error)
+	// FileDiff returns the diff for a single changed file
+	FileDiff(file git.ChangedFile) (*git.FileDiff, error)
+}
+
+// Changeset is the result of loading a Source
+type Changeset struct {
+	Files         []git.ChangedFile
+	Repo          *git.Repo
+	BaseBranch    string
+	BaseLabel     string // BaseBranch as shown, when it's a commit hash; empty to show BaseBranch
+	CurrentBranch string
+	Title         string      // Overrides the "current → base" header when set
+	RawView       bool        // File paths are labels rather than paths, prefer the flat view
+	Location      string      // Worktree or workspace repo, when it isn't the one we started in
+	BaseStrategy  string      // How the base branch was detected; empty when given by the user
+	Compare       string      // Compare mode, e.g. "merge-base"; empty if not applicable
+	Commit        *git.Commit // Single commit the changeset is scoped to, if any
+	OldestCommit  *git.Commit // First commit when scoped to several, ending at Commit
+	IgnoreEOL     bool        // CRLF/LF-only changes are hidden
+	FullContext   bool        // File diffs show whole files
+	Staged        int         // Files with changes staged in the index
+	HeadSHA       string      // Commit the diff ends at, or that uncommitted changes sit on
+}
+
+// PathFilterable is implemented by sources that can restrict the changed
+// files with include/exclude globs
+type PathFilterable interface {
+	PathFilter() []string
+	SetPathFilter(globs []string)
+}
+
+// WorktreeSwitcher is implemented by sources that can diff another worktree
+// of the repository
+type WorktreeSwitcher interface {
+	Worktrees() ([]git.Worktree, error)
+	SetWorktree(path string)
+}
+
+// RepoSwitcher is implemented by sources that can switch between the
+// repositories of a workspace
+type RepoSwitcher interface {
+	// Repos returns the workspace repositories; empty outside a workspace
+	Repos() []string
+	SetRepo(path string)
+}
+
+// CompareSwitcher is implemented by sources that can compare with or
+// without the merge base
+type CompareSwitcher interface {
+	CompareMode() git.CompareMode
+	SetCompareMode(mode git.CompareMode)
+}
+
+// LineEndingIgnorer is implemented by sources that can hide changes that
+// only convert line endings between CRLF and LF
+type LineEndingIgnorer interface {
+	IgnoreLineEndings() bool
+	SetIgnoreLineEndings(ignore bool)
+}
+
+// ContextExpander is implemented by sources whose file diffs can show the
+// whole file rather than a few lines around each change
+type ContextExpander interface {
+	FullContext() bool
+	SetFullContext(full bool)
+}
+
+// CommitScoper is implemented by sources that can narrow the changeset to a
+// single commit of the range
+type CommitScoper interface {
+	Commits() ([]git.Commit, error)
+	// SetCommit scopes the changeset to commit, or the whole range if nil
+	SetCommit(commit *git.Commit)
+	Commit() *git.Commit
+}
+
+// CommitRanger is implemented by commit scopers that can also narrow the
+// changeset to a run of consecutive commits
+type CommitRanger interface {
+	// SetCommitRange scopes the changeset to the commits from oldest to
+	// newest, oldest^..newest
+	SetCommitRange(oldest, newest *git.Commit)
+	// CommitRange returns the first and last commit of the scope, the same
+	// one for a single commit
+	CommitRange() (oldest, newest *git.Commit)
+}
+
+// HeadSwitcher is implemented by sources that can end the diff at another
+// ref, the working tree or the index instead of HEAD
+type HeadSwitcher interface {
+	// Head returns the ref, git.WorkTree or git.Index the diff ends at
+	Head() string
+	SetHead(head string)
+	// Refs returns the branches and tags to offer as heads
+	Refs() ([]string, error)
+}
+
+// StageSwitcher is implemented by sources that show either what's staged in
+// the index or what's left unstaged in the working tree
+type StageSwitcher interface {
+	Staged() bool
+	SetStaged(staged bool)
+}
+
+// DiffCanceler is implemented by sources whose diffs can be abandoned while
+// they load, when the user has moved on to another file
+type DiffCanceler interface {
+	FileDiffContext(ctx context.Context, file git.ChangedFile) (*git.FileDiff, error)
+}
+
+// MergeChecker is implemented by sources that can tell whether the head
+// would merge cleanly into the base
+type MergeChecker interface {
+	// MergeConflicts returns the files that would conflict
+	MergeConflicts() ([]string, error)
+}
+
+// Fetcher is implemented by sources that can update the base branch from
+// its remote before loading
+type Fetcher interface {
+	// Fetch returns the remote it fetched, or "" if the base is local
+	Fetch() (string, error)
+}
+
+// Locator is implemented by sources whose changed files exist on disk
+type Locator interface {
+	// AbsPath returns the absolute path of a changed file or folder
+	AbsPath(path string) (string, error)
+}
+
+// Reverter is implemented by sources whose diffs end at the working tree's
+// HEAD, so their changes can be undone in it
+type Reverter interface {
+	// Revert applies the reverse of hunks of diff, or of all of it if none
+	// are given, to the working tree
+	Revert(diff *git.FileDiff, hunks ...int) error
+}
+
+// IntentAdder is implemented by sources listing untracked files, which can
+// be recorded in the index with git add -N to diff them as additions
+type IntentAdder interface {
+	IntentToAdd(paths ...string) error
+}
+
+// Versioner is implemented by sources that can produce both versions of a
+// changed file, for opening it in an external diff tool
+type Versioner interface {
+	// Versions returns the contents of file before and after the change,
+	// nil for a side where it doesn't exist
+	Versions(file git.ChangedFile) (before, after []byte, err error)
+}
+
+// repoSource compares HEAD (or the working tree) against a base branch
+type repoSource struct {
+	baseBranch string // As given by the user; empty to detect per repo
+	globs      []string
+	compare    git.CompareMode
+	commit     *git.Commit // Scope to a single commit of the range
+	oldest     *git.Commit // First commit when scoped to several, ending at commit
+	worktree   string      // Path or branch of the worktree to diff (default: cwd)
+	head       string      // Ref, git.WorkTree or git.Index to diff (default: HEAD)
+	repos      []string    // Workspace repositories
+	ignoreEOL  bool        // Hide CRLF/LF-only changes
+	full       bool        // Diff whole files
+	fetched    bool        // Remote refs given for base and head have been fetched
+
+	mu     sync.Mutex
+	loaded *loadedRange // Set by Load; nil before the first
+}
+
+// loadedRange is what the last Load compares. Diffs load from it on other
+// goroutines while a reload runs, so it's replaced whole, never modified.
+type loadedRange struct {
+	repo     *git.Repo
+	base     string // Base branch of the repo
+	from, to string // Revisions the changeset compares
+	used     git.CompareMode
+}
+
+// current returns the range of the last Load
+func (s *repoSource) current() (*loadedRange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded == nil {
+		return nil, fmt.Errorf("repository not loaded")
+	}
+	return s.loaded, nil
+}
+
+// NewRepoSource creates a source comparing a worktree, or the given head
+// ref, against a base branch; the default when no other source is given
+func NewRepoSource(baseBranch, head string, compare git.CompareMode, worktree string, repos, globs []string) Source {
+	return &repoSource{baseBranch: baseBranch, head: head, compare: compare, worktree: worktree, repos: repos, globs: globs}
+}
+
+func (s *repoSource) CompareMode() git.CompareMode {
+	return s.compare
+}
+
+func (s *repoSource) SetCompareMode(mode git.CompareMode) {
+	s.compare = mode
+}
+
+func (s *repoSource) IgnoreLineEndings() bool {
+	return s.ignoreEOL
+}
+
+func (s *repoSource) SetIgnoreLineEndings(ignore bool) {
+	s.ignoreEOL = ignore
+}
+
+func (s *repoSource) FullContext() bool {
+	return s.full
+}
+
+func (s *repoSource) SetFullContext(full bool) {
+	s.full = full
+}
+
+func (s *repoSource) PathFilter() []string {
+	return s.globs
+}
+
+func (s *repoSource) SetPathFilter(globs []string) {
+	s.globs = globs
+}
+
+func (s *repoSource) Worktrees() ([]git.Worktree, error) {
+	r, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	return r.repo.Worktrees()
+}
+
+func (s *repoSource) SetWorktree(path string) {
+	s.worktree = path
+	s.commit, s.oldest = nil, nil
+}
+
+func (s *repoSource) Commits() ([]git.Commit, error) {
+	r, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	return r.repo.Commits(r.base, s.headCommit())
+}
+
+func (s *repoSource) Head() string {
+	if s.head == "" {
+		return "HEAD"
+	}
+	return s.head
+}
+
+// SetHead switches the side the diff ends at. A commit scope belongs to the
+// old head's range, so it's dropped.
+func (s *repoSource) SetHead(head string) {
+	s.head = head
+	s.commit, s.oldest = nil, nil
+}
+
+func (s *repoSource) Refs() ([]string, error) {
+	r, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	return r.repo.Refs()
+}
+
+func (s *repoSource) MergeConflicts() ([]string, error) {
+	r, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	return r.repo.MergeConflicts(r.base, s.headCommit())
+}
+
+// headCommit returns the commit the head builds on: the working tree and
+// the index sit on top of HEAD
+func (s *repoSource) headCommit() string {
+	if head := s.Head(); head != git.WorkTree && head != git.Index {
+		return head
+	}
+	return "HEAD"
+}
+
+func (s *repoSource) SetCommit(commit *git.Commit) {
+	s.commit, s.oldest = commit, nil
+}
+
+func (s *repoSource) SetCommitRange(oldest, newest *git.Commit) {
+	s.commit, s.oldest = newest, oldest
+}
+
+func (s *repoSource) CommitRange() (oldest, newest *git.Commit) {
+	if s.oldest == nil {
+		return s.commit, s.commit
+	}
+	return s.oldest, s.commit
+}
+
+func (s *repoSource) Commit() *git.Commit {
+	return s.commit
+}
+
+func (s *repoSource) Repos() []string {
+	return s.repos
+}
+
+// SetRepo switches to another workspace repository. Its worktree is opened
+// by path, like one picked from the worktree list.
+func (s *repoSource) SetRepo(path string) {
+	s.worktree = path
+	s.commit, s.oldest = nil, nil
+}
+
+// openWorktree opens the selected worktree, given as a path or as the name
+// of the branch checked out in it
+func (s *repoSource) openWorktree() (*git.Repo, error) {
+	if s.worktree == "" {
+		repo, err := git.NewRepo(".")
+		if err != nil && len(s.repos) > 0 {
+			// Workspaces may be opened from a directory above the repos
+			return git.NewRepo(s.repos[0])
+		}
+		return repo, err
+	}
+	if info, err := os.Stat(s.worktree); err == nil && info.IsDir() {
+		return git.NewRepo(s.worktree)
+	}
+
+	repo, err := git.NewRepo(".")
+	if err != nil {
+		return nil, err
+	}
+	worktrees, err := repo.Worktrees()
+	if err != nil {
+		return nil, err
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == s.worktree && !wt.Bare {
+			return git.NewRepo(wt.Path)
+		}
+	}
+	return nil, fmt.Errorf("no worktree at %s or with %s checked out", s.worktree, s.worktree)
+}
+
+func (s *repoSource) Fetch() (string, error) {
+	repo, err := s.openWorktree()
+	if err != nil {
+		return "", err
+	}
+	base := s.baseBranch
+	if base == "" {
+		if base, _, err = repo.DetectBase(); err != nil {
+			return "", nil
+		}
+	}
+	remote := repo.RemoteOf(base)
+	if remote == "" {
+		return "", nil
+	}
+	return remote, repo.Fetch(remote)
+}
+
+func (s *repoSource) Load() (*Changeset, error) {
+	repo, err := s.openWorktree()
+	if err != nil {
+		return nil, err
+	}
+	repo.SetIgnoreLineEndings(s.ignoreEOL)
+	repo.SetFullContext(s.full)
+	if !s.fetched {
+		for _, ref := range []string{s.baseBranch, s.head} {
+			if err := repo.FetchMissing(ref); err != nil {
+				return nil, err
+			}
+		}
+		s.fetched = true
+	}
+
+	currentBranch, err := repo.BranchLabel()
+	if err != nil {
+		return nil, err
+	}
+
+	baseBranch, strategy := s.baseBranch, ""
+	if baseBranch == "" {
+		baseBranch, strategy, err = repo.DetectBase()
+		if err != nil {
+			baseBranch, strategy = "HEAD", "HEAD"
+		}
+	}
+
+	from, to, used := baseBranch, s.Head(), s.compare
+	switch {
+	case to == "HEAD":
+	case to == git.WorkTree || to == git.Index:
+		currentBranch += " (" + git.HeadLabel(to) + ")"
+	case git.IsSHA(to):
+		currentBranch = repo.Describe(to)
+	default:
+		currentBranch = to
+	}
+	if s.commit != nil {
+		oldest, _ := s.CommitRange()
+		from, to, used = repo.Parent(oldest.SHA), s.commit.SHA, git.CompareDirect
+	}
+
+	// Unrelated histories have no merge base, so compare the trees instead
+	// and say so in the header
+	files, err := repo.GetChangedFiles(from, to, used, s.globs...)
+	if err != nil && used == git.CompareMergeBase {
+		if related, mbErr := repo.HasMergeBase(from, to); mbErr == nil && !related {
+			used = git.CompareDirect
+			files, err = repo.GetChangedFiles(from, to, used, s.globs...)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	countConflicts(repo, to, files)
+	countSecrets(repo, from, to, used, files)
+	markGenerated(repo, files)
+
+	s.mu.Lock()
+	s.loaded = &loadedRange{repo: repo, base: baseBranch, from: from, to: to, used: used}
+	s.mu.Unlock()
+
+	cs := &Changeset{
+		Files:         files,
+		Repo:          repo,
+		BaseBranch:    baseBranch,
+		CurrentBranch: currentBranch,
+		BaseStrategy:  strategy,
+		Compare:       used.String(),
+		Commit:        s.commit,
+		OldestCommit:  s.oldest,
+		IgnoreEOL:     s.ignoreEOL,
+		FullContext:   s.full,
+	}
+	if git.IsSHA(baseBranch) {
+		cs.BaseLabel = repo.Describe(baseBranch)
+	}
+	if s.commit != nil {
+		cs.Compare = ""
+	} else if used != s.compare {
+		cs.Compare += ", no merge base"
+	}
+	if s.worktree != "" || len(s.repos) > 0 {
+		cs.Location = filepath.Base(repo.Root())
+	}
+	// Only shown in the status bar, so a failure just leaves it out
+	cs.Staged, _ = repo.StagedCount()
+	// Likewise only used in links
+	head := s.headCommit()
+	if s.commit != nil {
+		head = s.commit.SHA
+	}
+	cs.HeadSHA, _ = repo.CommitSHA(head)
+	return cs, nil
+}
+
+func (s *repoSource) FileDiff(file git.ChangedFile) (*git.FileDiff, error) {
+	return s.FileDiffContext(context.Background(), file)
+}
+
+func (s *repoSource) FileDiffContext(ctx context.Context, file git.ChangedFile) (*git.FileDiff, error) {
+	r, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	return r.repo.GetFileDiffContext(ctx, r.from, r.to, r.used, file)
+}
+
+func (s *repoSource) Versions(file git.ChangedFile) ([]byte, []byte, error) {
+	r, err := s.current()
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.repo.FileVersions(r.from, r.to, r.used, file)
+}
+
+func (s *repoSource) Revert(diff *git.FileDiff, hunks ...int) error {
+	r, err := s.current()
+	if err != nil {
+		return err
+	}
+	// Other refs' changes aren't in the working tree to undo
+	if head := s.Head(); head != "HEAD" && head != git.WorkTree {
+		return fmt.Errorf("reverting needs the diff to end at HEAD or the working tree, not %s", git.HeadLabel(head))
+	}
+	return r.repo.ApplyPatch(diff.Patch(hunks...), true)
+}
+
+func (s *repoSource) AbsPath(path string) (string, error) {
+	r, err := s.current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(r.repo.Root(), path), nil
+}
+
+// countConflicts fills in the conflict regions left in files at rev. Markers
+// can only arrive with added lines, so other files aren't searched. Counting
+// is best effort: on failure the files show no conflicts.
+func countConflicts(repo *git.Repo, rev string, files []git.ChangedFile) {
+	var paths []string
+	for _, f := range files {
+		if f.Additions > 0 {
+			paths = append(paths, f.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	counts, err := repo.ConflictCounts(rev, paths)
+	if err != nil {
+		return
+	}
+	for i := range files {
+		files[i].Conflicts = counts[files[i].Path]
+	}
+}
+
+// countSecrets fills in the added lines of files that look like
+// credentials. Like counting conflicts, it's best effort.
+func countSecrets(repo *git.Repo, from, to string, mode git.CompareMode, files []git.ChangedFile) {
+	var paths []string
+	for _, f := range files {
+		if f.Additions > 0 {
+			paths = append(paths, f.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	counts, err := repo.CountSecrets(from, to, mode, paths)
+	if err != nil {
+		return
+	}
+	for i := range files {
+		files[i].Secrets = counts[files[i].Path]
+	}
+}
+
+// markGenerated flags the generated files so the list can collapse them
+func markGenerated(repo *git.Repo, files []git.ChangedFile) {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	generated := repo.GeneratedFiles(paths)
+	for i := range files {
+		files[i].Generated = generated[files[i].Path]
+	}
+}
+
+// rangeDiffSource shows `git range-diff` output, one entry per commit pair
+type rangeDiffSource struct {
+	oldRange string
+	newRange string
+	diffs    map[string]*git.FileDiff
+}
+
+// NewRangeDiffSource creates a source comparing two iterations of a branch
+func NewRangeDiffSource(oldRange, newRange string) Source {
+	return &rangeDiffSource{oldRange: oldRange, newRange: newRange}
+}
+
+func (s *rangeDiffSource) Load() (*Changeset, error) {
+	repo, err := git.NewRepo(".")
+	if err != nil {
+		return nil, err
+	}
+
+	pairs, err := repo.RangeDiff(s.oldRange, s.newRange)
+	if err != nil {
+		return nil, err
+	}
+
+	s.diffs = make(map[string]*git.FileDiff)
+	var files []git.ChangedFile
+	for _, pair := range pairs {
+		// Labels are shown in the flat view, so keep them free of separators
+		label := fmt.Sprintf("%s:%s %s", pair.OldIndex, pair.NewIndex, strings.ReplaceAll(pair.Subject, "/", "∕"))
+
+		file := git.ChangedFile{Path: label}
+		switch pair.Relation {
+		case '=':
+			file.Status = git.StatusUnchanged
+		case '<':
+			file.Status = git.StatusDeleted
+		case '>':
+			file.Status = git.StatusAdded
+		default:
+			file.Status = git.StatusModified
+		}
+		for _, hunk := range pair.Diff.Hunks {
+			for _, line := range hunk.Lines {
+				switch line.Type {
+				case git.DiffLineAddition:
+					file.Additions++
+				case git.DiffLineDeletion:
+					file.Deletions++
+				}
+			}
+		}
+
+		if len(pair.Diff.Hunks) == 0 {
+			pair.Diff.Hunks = []git.DiffHunk{{Lines: []git.DiffLine{{
+				Type:    git.DiffLineHeader,
+				Content: rangeDiffSummary(pair),
+			}}}}
+		}
+
+		s.diffs[label] = pair.Diff
+		files = append(files, file)
+	}
+
+	return &Changeset{
+		Files:   files,
+		Repo:    repo,
+		Title:   fmt.Sprintf("range-diff %s ⇄ %s", s.oldRange, s.newRange),
+		RawView: true,
+	}, nil
+}
+
+func (s *rangeDiffSource) FileDiff(file git.ChangedFile) (*git.FileDiff, error) {
+	diff, ok := s.diffs[file.Path]
+	if !ok {
+		return nil, fmt.Errorf("no range-diff entry for %s", file.Path)
+	}
+	return diff, nil
+}
+
+// rangeDiffSummary describes a pair that has no diff-of-diffs to show
+func rangeDiffSummary(pair git.RangeDiffPair) string {
+	switch pair.Relation {
+	case '=':
+		return fmt.Sprintf("%s and %s are identical", pair.OldSHA, pair.NewSHA)
+	case '<':
+		return fmt.Sprintf("%s was dropped from the new range", pair.OldSHA)
+	case '>':
+		return fmt.Sprintf("%s is new in this range", pair.NewSHA)
+	default:
+		return fmt.Sprintf("%s → %s", pair.OldSHA, pair.NewSHA)
+	}
+}
+
+// pickSource previews cherry-picking a commit onto HEAD: the commit's own
+// changes, with the files that wouldn't apply cleanly marked as conflicts
+type pickSource struct {
+	rev       string
+	repo      *git.Repo
+	parent    string
+	commit    git.Commit
+	conflicts map[string]string
+}
+
+// NewPickSource creates a source previewing a cherry-pick of rev
+func NewPickSource(rev string) Source {
+	return &pickSource{rev: rev}
+}
+
+func (s *pickSource) Load() (*Changeset, error) {
+	repo, err := git.NewRepo(".")
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := repo.ShowCommit(s.rev)
+	if err != nil {
+		return nil, err
+	}
+	currentBranch, err := repo.BranchLabel()
+	if err != nil {
+		return nil, err
+	}
+
+	parent := repo.Parent(commit.SHA)
+	files, err := repo.GetChangedFiles(parent, commit.SHA, git.CompareDirect)
+	if err != nil {
+		return nil, err
+	}
+	conflicts, err := repo.CheckPick(commit.SHA)
+	if err != nil {
+		return nil, err
+	}
+	for i := range files {
+		if _, ok := conflicts[files[i].Path]; ok {
+			files[i].Status = git.StatusConflict
+		}
+	}
+	markGenerated(repo, files)
+
+	s.repo, s.parent, s.commit, s.conflicts = repo, parent, commit, conflicts
+
+	outcome := "applies cleanly"
+	switch n := len(conflicts); {
+	case n == 1:
+		outcome = "1 conflict"
+	case n > 1:
+		outcome = fmt.Sprintf("%d conflicts", n)
+	}
+	return &Changeset{
+		Files:         files,
+		Repo:          repo,
+		CurrentBranch: currentBranch,
+		Title:         fmt.Sprintf("pick %s %s → %s (%s)", commit.Short, commit.Subject, currentBranch, outcome),
+	}, nil
+}
+
+// FileDiff returns the commit's change to the file, headed by the reason it
+// would conflict
+func (s *pickSource) FileDiff(file git.ChangedFile) (*git.FileDiff, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("repository not loaded")
+	}
+
+	diff, err := s.repo.GetFileDiff(s.parent, s.commit.SHA, git.CompareDirect, file)
+	if err != nil {
+		return nil, err
+	}
+	if reason, ok := s.conflicts[file.Path]; ok {
+		note := git.DiffHunk{Lines: []git.DiffLine{{
+			Type:    git.DiffLineHeader,
+			Content: fmt.Sprintf("Conflict on %s: %s", s.commit.Short, reason),
+		}}}
+		diff.Hunks = append([]git.DiffHunk{note}, diff.Hunks...)
+	}
+	return diff, nil
+}
+
+func (s *pickSource) Versions(file git.ChangedFile) ([]byte, []byte, error) {
+	if s.repo == nil {
+		return nil, nil, fmt.Errorf("repository not loaded")
+	}
+	return s.repo.FileVersions(s.parent, s.commit.SHA, git.CompareDirect, file)
+}
+
+func (s *pickSource) AbsPath(path string) (string, error) {
+	if s.repo == nil {
+		return "", fmt.Errorf("repository not loaded")
+	}
+	return filepath.Join(s.repo.Root(), path), nil
+}
+
+// stashSource shows a stash entry's changes to the working tree, the diff of
+// the stash commit against its first parent. Untracked files saved with
+// --include-untracked live in another commit and aren't shown.
+type stashSource struct {
+	ref    string
+	globs  []string
+	repo   *git.Repo
+	parent string
+	commit git.Commit
+}
+
+// NewStashSource creates a source for a stash entry such as stash@{2}
+func NewStashSource(ref string, globs []string) Source {
+	return &stashSource{ref: ref, globs: globs}
+}
+
+func (s *stashSource) Load() (*Changeset, error) {
+	repo, err := git.NewRepo(".")
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := repo.ShowCommit(s.ref)
+	if err != nil {
+		return nil, err
+	}
+	currentBranch, err := repo.BranchLabel()
+	if err != nil {
+		return nil, err
+	}
+
+	parent := commit.SHA + "^1"
+	files, err := repo.GetChangedFiles(parent, commit.SHA, git.CompareDirect, s.globs...)
+	if err != nil {
+		return nil, err
+	}
+	markGenerated(repo, files)
+
+	s.repo, s.parent, s.commit = repo, parent, commit
+	return &Changeset{
+		Files:         files,
+		Repo:          repo,
+		CurrentBranch: currentBranch,
+		Title:         fmt.Sprintf("%s: %s (%s)", s.ref, commit.Subject, commit.Date),
+	}, nil
+}
+
+func (s *stashSource) FileDiff(file git.ChangedFile) (*git.FileDiff, error) {
+	return s.FileDiffContext(context.Background(), file)
+}
+
+func (s *stashSource) FileDiffContext(ctx context.Context, file git.ChangedFile) (*git.FileDiff, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("repository not loaded")
+	}
+	return s.repo.GetFileDiffContext(ctx, s.parent, s.commit.SHA, git.CompareDirect, file)
+}
+
+func (s *stashSource) Versions(file git.ChangedFile) ([]byte, []byte, error) {
+	if s.repo == nil {
+		return nil, nil, fmt.Errorf("repository not loaded")
+	}
+	return s.repo.FileVersions(s.parent, s.commit.SHA, git.CompareDirect, file)
+}
+
+func (s *stashSource) AbsPath(path string) (string, error) {
+	if s.repo == nil {
+		return "", fmt.Errorf("repository not loaded")
+	}
+	return filepath.Join(s.repo.Root(), path), nil
+}
+
+// statusSource shows the uncommitted changes of the working tree in two
+// halves: HEAD → index, what `git add` has captured, and index → working
+// tree, what's still unstaged, along with the untracked files
+type statusSource struct {
+	globs  []string
+	staged bool
+	picked bool // The side has been chosen, by the user or the first load
+
+	mu     sync.Mutex
+	loaded *loadedStatus // Set by Load; nil before the first
+}
+
+// loadedStatus is the repository and untracked files of the last Load,
+// replaced whole like a loadedRange
+type loadedStatus struct {
+	repo      *git.Repo
+	untracked map[string]bool
+}
+
+// current returns what the last Load found
+func (s *statusSource) current() (*loadedStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded == nil {
+		return nil, fmt.Errorf("repository not loaded")
+	}
+	return s.loaded, nil
+}
+
+// NewStatusSource creates a source for the staged and unstaged changes,
+// starting with the unstaged ones unless only staged changes exist
+func NewStatusSource(globs []string) Source {
+	return &statusSource{globs: globs}
+}
+
+func (s *statusSource) Staged() bool {
+	return s.staged
+}
+
+func (s *statusSource) SetStaged(staged bool) {
+	s.staged, s.picked = staged, true
+}
+
+func (s *statusSource) PathFilter() []string {
+	return s.globs
+}
+
+func (s *statusSource) SetPathFilter(globs []string) {
+	s.globs = globs
+}
+
+// sides returns the revisions the current half compares
+func (s *statusSource) sides() (from, to string) {
+	if s.staged {
+		return "HEAD", git.Index
+	}
+	return git.Index, git.WorkTree
+}
+
+func (s *statusSource) Load() (*Changeset, error) {
+	repo, err := git.NewRepo(".")
+	if err != nil {
+		return nil, err
+	}
+	currentBranch, err := repo.BranchLabel()
+	if err != nil {
+		return nil, err
+	}
+
+	staged, err := repo.StagedCount()
+	if err != nil {
+		return nil, err
+	}
+	unstaged, err := repo.UnstagedCount()
+	if err != nil {
+		return nil, err
+	}
+	if !s.picked {
+		s.staged, s.picked = unstaged == 0 && staged > 0, true
+	}
+
+	from, to := s.sides()
+	files, err := repo.GetChangedFiles(from, to, git.CompareDirect, s.globs...)
+	if err != nil {
+		return nil, err
+	}
+	countConflicts(repo, to, files)
+	countSecrets(repo, from, to, git.CompareDirect, files)
+	loaded := &loadedStatus{repo: repo, untracked: make(map[string]bool)}
+	if !s.staged {
+		untracked, err := repo.UntrackedFiles(s.globs...)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range untracked {
+			loaded.untracked[f.Path] = true
+		}
+		files = append(files, untracked...)
+	}
+	markGenerated(repo, files)
+	s.mu.Lock()
+	s.loaded = loaded
+	s.mu.Unlock()
+
+	// The other half's size tells whether toggling is worth it
+	title := fmt.Sprintf("unstaged: index → working tree · %d staged", staged)
+	if s.staged {
+		title = fmt.Sprintf("staged: HEAD → index · %d unstaged", unstaged)
+	}
+	cs := &Changeset{
+		Files:         files,
+		Repo:          repo,
+		BaseBranch:    git.HeadLabel(from),
+		CurrentBranch: currentBranch + " (" + git.HeadLabel(to) + ")",
+		Title:         title,
+	}
+	cs.HeadSHA, _ = repo.CommitSHA("HEAD")
+	return cs, nil
+}
+
+func (s *statusSource) FileDiff(file git.ChangedFile) (*git.FileDiff, error) {
+	return s.FileDiffContext(context.Background(), file)
+}
+
+func (s *statusSource) FileDiffContext(ctx context.Context, file git.ChangedFile) (*git.FileDiff, error) {
+	l, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	if l.untracked[file.Path] {
+		return l.repo.UntrackedDiff(ctx, file.Path)
+	}
+	from, to := s.sides()
+	return l.repo.GetFileDiffContext(ctx, from, to, git.CompareDirect, file)
+}
+
+func (s *statusSource) Versions(file git.ChangedFile) ([]byte, []byte, error) {
+	l, err := s.current()
+	if err != nil {
+		return nil, nil, err
+	}
+	if l.untracked[file.Path] {
+		after, err := os.ReadFile(filepath.Join(l.repo.Root(), file.Path))
+		return nil, after, err
+	}
+	from, to := s.sides()
+	return l.repo.FileVersions(from, to, git.CompareDirect, file)
+}
+
+// Revert undoes unstaged changes in the working tree. Staged changes would
+// have to be unstaged rather than reverted, so they're refused.
+func (s *statusSource) Revert(diff *git.FileDiff, hunks ...int) error {
+	l, err := s.current()
+	if err != nil {
+		return err
+	}
+	if s.staged {
+		return fmt.Errorf("reverting needs the unstaged changes, not the staged ones")
+	}
+	if l.untracked[diff.NewPath] {
+		return fmt.Errorf("%s is untracked; delete it instead", diff.NewPath)
+	}
+	return l.repo.ApplyPatch(diff.Patch(hunks...), true)
+}
+
+// IntentToAdd records untracked files in the index without their content
+func (s *statusSource) IntentToAdd(paths ...string) error {
+	l, err := s.current()
+	if err != nil {
+		return err
+	}
+	return l.repo.IntentToAdd(paths...)
+}
+
+func (s *statusSource) AbsPath(path string) (string, error) {
+	l, err := s.current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.repo.Root(), path), nil
+}
+
+// dirSource compares two directories outside of git
+type dirSource struct {
+	oldDir string
+	newDir string
+	diffs  map[string]*git.FileDiff
+}
+
+// NewDirSource creates a source comparing two arbitrary directories
+func NewDirSource(oldDir, newDir string) Source {
+	return &dirSource{oldDir: oldDir, newDir: newDir}
+}
+
+func (s *dirSource) Load() (*Changeset, error) {
+	files, diffs, err := git.DiffDirs(s.oldDir, s.newDir)
+	if err != nil {
+		return nil, err
+	}
+	s.diffs = diffs
+	for i := range files {
+		files[i].Generated = git.IsGeneratedPath(files[i].Path)
+	}
+
+	return &Changeset{
+		Files: files,
+		Title: fmt.Sprintf("dir %s ⇄ %s", s.oldDir, s.newDir),
+	}, nil
+}
+
+func (s *dirSource) FileDiff(file git.ChangedFile) (*git.FileDiff, error) {
+	diff, ok := s.diffs[file.Path]
+	if !ok {
+		return nil, fmt.Errorf("no diff for %s", file.Path)
+	}
+	return diff, nil
+}
+
+// AbsPath resolves path in the new directory, or the old one for files that
+// were deleted
+func (s *dirSource) AbsPath(path string) (string, error) {
+	abs, err := filepath.Abs(filepath.Join(s.newDir, path))
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return filepath.Abs(filepath.Join(s.oldDir, path))
+	}
+	return abs, nil
+}
+
+// Versions reads the file from the old and new directories
+func (s *dirSource) Versions(file git.ChangedFile) ([]byte, []byte, error) {
+	read := func(dir string) ([]byte, error) {
+		data, err := os.ReadFile(filepath.Join(dir, file.Path))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return data, err
+	}
+	before, err := read(s.oldDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err := read(s.newDir)
+	return before, after, err
+}
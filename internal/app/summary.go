@@ -0,0 +1,50 @@
+package app
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commitsCountedMsg is sent when the commits of the range have been counted
+// for the summary, n being -1 when they can't be
+type commitsCountedMsg struct {
+	n int
+}
+
+// toggleSummary shows or hides the summary of the changeset, in place of the
+// file list and the diff
+func (m *Model) toggleSummary() tea.Cmd {
+	m.summarizing = !m.summarizing
+	if !m.summarizing {
+		return nil
+	}
+	return m.countCommits()
+}
+
+// countCommits counts the commits of the range, for sources that have one
+func (m *Model) countCommits() tea.Cmd {
+	scoper, ok := m.source.(CommitScoper)
+	if !ok {
+		m.summary.SetCommits(-1)
+		return nil
+	}
+	return func() tea.Msg {
+		commits, err := scoper.Commits()
+		if err != nil {
+			return commitsCountedMsg{n: -1}
+		}
+		return commitsCountedMsg{n: len(commits)}
+	}
+}
+
+// updateSummary handles keys while the summary is shown: s, enter and esc
+// go to the files, and quitting still works
+func (m Model) updateSummary(msg tea.KeyMsg, confirmed bool) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Summary, m.keys.Enter, m.keys.Escape):
+		m.summarizing = false
+	case key.Matches(msg, m.keys.Quit):
+		return m, m.quit(msg.String(), confirmed)
+	}
+	return m, nil
+}
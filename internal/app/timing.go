@@ -0,0 +1,42 @@
+package app
+
+import (
+	"log"
+	"time"
+)
+
+// startupTimer records how long each startup phase took. Timings are only
+// logged when the app runs with --debug.
+type startupTimer struct {
+	enabled bool
+	start   time.Time
+	last    time.Time
+	done    bool
+}
+
+func newStartupTimer(enabled bool, start time.Time) *startupTimer {
+	if start.IsZero() {
+		start = time.Now()
+	}
+	return &startupTimer{enabled: enabled, start: start, last: start}
+}
+
+// phase logs a completed startup phase with its own duration and the total
+// elapsed time since process start
+func (t *startupTimer) phase(name string, took time.Duration) {
+	if t == nil || !t.enabled || t.done {
+		return
+	}
+	log.Printf("startup: %-14s %8s (total %s)", name, took.Round(time.Microsecond), time.Since(t.start).Round(time.Microsecond))
+	t.last = time.Now()
+}
+
+// firstFrame logs the time to the first frame that shows repository content
+// and stops the timer
+func (t *startupTimer) firstFrame() {
+	if t == nil || !t.enabled || t.done {
+		return
+	}
+	t.phase("first frame", time.Since(t.last))
+	t.done = true
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// pagerArgs returns the command showing path from line in a read-only
+// pager: bat with the line highlighted, or less without bat
+func pagerArgs(path string, line int) []string {
+	n := strconv.Itoa(line)
+	if _, err := exec.LookPath("bat"); err == nil {
+		return []string{"bat", "--paging=always", "--highlight-line", n, "--line-range", n + ":", path}
+	}
+	return []string{"less", "-N", "+" + n, path}
+}
+
+// openInTmuxSplit opens the selected file at the line under the diff cursor
+// in a new tmux pane beside git-diffs, in $VISUAL or $EDITOR, or else in bat
+func (m *Model) openInTmuxSplit() tea.Cmd {
+	if os.Getenv("TMUX") == "" {
+		m.notice = "Not running inside tmux"
+		return nil
+	}
+	file := m.difftoolFile()
+	locator, ok := m.source.(Locator)
+	switch {
+	case file == nil:
+		m.notice = "Nothing to open"
+		return nil
+	case !ok:
+		m.notice = "Opening needs the changed files on disk"
+		return nil
+	case file.Status == git.StatusDeleted:
+		m.notice = file.Path + " was deleted"
+		return nil
+	}
+	abs, err := locator.AbsPath(file.Path)
+	if err != nil {
+		m.notice = "Open failed: " + err.Error()
+		return nil
+	}
+	line := m.fileLine(file.Path)
+
+	args := terminalEditorArgs(abs, line)
+	if args == nil {
+		args = pagerArgs(abs, line)
+	}
+	name := filepath.Base(args[0])
+	split := append([]string{"split-window", "-h", "-c", filepath.Dir(abs), "--"}, args...)
+	return func() tea.Msg {
+		if out, err := exec.Command("tmux", split...).CombinedOutput(); err != nil {
+			msg := strings.TrimSpace(string(out))
+			if msg == "" {
+				msg = err.Error()
+			}
+			return actionDoneMsg{notice: "tmux failed: " + msg}
+		}
+		return actionDoneMsg{notice: "Opened " + file.Path + " in " + name + " in a tmux pane"}
+	}
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// toastDuration is how long a toast stays in the footer
+const toastDuration = 5 * time.Second
+
+// toast is a transient error shown in the footer. Unlike a notice it
+// survives key presses, and goes away on its own.
+type toast struct {
+	text string
+	id   int // Identifies the toast its expiry message is for
+}
+
+// toastExpiredMsg is sent when the toast with id has been shown long enough
+type toastExpiredMsg struct {
+	id int
+}
+
+// showToast shows text in the footer, replacing any earlier toast, and
+// returns the command that dismisses it
+func (m *Model) showToast(text string) tea.Cmd {
+	m.toast.id++
+	m.toast.text = text
+	id := m.toast.id
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpiredMsg{id: id}
+	})
+}
+
+// expireToast dismisses the toast if it's still the one msg is for
+func (m *Model) expireToast(msg toastExpiredMsg) {
+	if msg.id == m.toast.id {
+		m.toast.text = ""
+	}
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui/picker"
+)
+
+// pickTodo identifies the TODO summary in picker messages
+const pickTodo = "todo"
+
+// todoMarker matches the markers of notes left for later
+var todoMarker = regexp.MustCompile(`\b(TODO|FIXME|HACK)\b`)
+
+// todo is an added line carrying a TODO, FIXME or HACK marker
+type todo struct {
+	file   git.ChangedFile
+	line   git.DiffLine
+	marker string
+}
+
+// todosFoundMsg is sent when the added lines of every diff have been scanned
+type todosFoundMsg struct {
+	todos []todo
+}
+
+// findTodos scans the added lines of every changed file in the background
+func (m Model) findTodos() tea.Cmd {
+	files, source := m.files, m.source
+	return func() tea.Msg {
+		return todosFoundMsg{todos: scanTodos(files, source)}
+	}
+}
+
+// scanTodos returns the marked added lines of files, in file order
+func scanTodos(files []git.ChangedFile, source Source) []todo {
+	var todos []todo
+	for _, f := range files {
+		diff, err := source.FileDiff(f)
+		if err != nil {
+			continue
+		}
+		for _, hunk := range diff.Hunks {
+			for _, line := range hunk.Lines {
+				if line.Type != git.DiffLineAddition {
+					continue
+				}
+				if marker := todoMarker.FindString(line.Content); marker != "" {
+					todos = append(todos, todo{file: f, line: line, marker: marker})
+				}
+			}
+		}
+	}
+	return todos
+}
+
+// openTodoPicker lists the markers found, in file order
+func (m *Model) openTodoPicker(todos []todo) {
+	if len(todos) == 0 {
+		m.notice = "No TODO, FIXME or HACK in the added lines"
+		return
+	}
+	m.todos = todos
+	items := make([]picker.Item, len(todos))
+	for i, t := range todos {
+		items[i] = picker.Item{
+			Label:  fmt.Sprintf("%-5s %s:%d", t.marker, t.file.Path, t.line.NewLineNum),
+			Detail: strings.TrimSpace(t.line.Content),
+			Value:  strconv.Itoa(i),
+		}
+	}
+	m.picker.Open(pickTodo, fmt.Sprintf("TODO/FIXME/HACK (%d)", len(todos)), items, 0)
+}
+
+// jumpToTodo shows the file of a listed marker with the cursor on its line
+func (m *Model) jumpToTodo(value string) tea.Cmd {
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 || i >= len(m.todos) {
+		return nil
+	}
+	t := m.todos[i]
+	m.setFocus(PaneDiffView)
+	if t.file.Path == m.diffView.FilePath() {
+		m.diffView.JumpToDiffLine(t.line)
+		return nil
+	}
+	m.pendingJump = &pendingJump{filePath: t.file.Path, line: t.line}
+	return m.startDiffLoad(t.file)
+}
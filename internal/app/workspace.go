@@ -0,0 +1,61 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/ui/picker"
+)
+
+// pickRepo identifies the workspace repository list in picker messages
+const pickRepo = "repo"
+
+// openRepoPicker shows the workspace repositories with the current one
+// selected
+func (m *Model) openRepoPicker() {
+	switcher, ok := m.source.(RepoSwitcher)
+	if !ok || len(switcher.Repos()) == 0 {
+		m.notice = "Not in a workspace: use --workspace or [workspace] in the config file"
+		return
+	}
+
+	cwd, _ := os.Getwd()
+	var items []picker.Item
+	current := 0
+	for _, repo := range switcher.Repos() {
+		label := repo
+		if rel, err := filepath.Rel(cwd, repo); err == nil && filepath.IsLocal(rel) {
+			label = rel
+		}
+		detail := ""
+		if m.repo != nil && sameDir(repo, m.repo.Root()) {
+			current = len(items)
+			detail = "(current)"
+		}
+		items = append(items, picker.Item{Label: label, Detail: detail, Value: repo})
+	}
+	m.picker.Open(pickRepo, "Repositories", items, current)
+}
+
+// switchRepo reloads the changeset from the workspace repository at path
+func (m *Model) switchRepo(path string) tea.Cmd {
+	switcher, ok := m.source.(RepoSwitcher)
+	if !ok || (m.repo != nil && sameDir(path, m.repo.Root())) {
+		return nil
+	}
+	switcher.SetRepo(path)
+	m.diffView.Clear()
+	return m.startRepoLoad()
+}
+
+// sameDir reports whether two paths name the same directory, following
+// symlinks such as a /tmp that points elsewhere
+func sameDir(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui/picker"
+)
+
+// pickWorktree identifies the worktree list in picker messages
+const pickWorktree = "worktree"
+
+// worktreesLoadedMsg is sent when the worktree list has been read
+type worktreesLoadedMsg struct {
+	worktrees []git.Worktree
+	err       error
+}
+
+// loadWorktrees lists the worktrees of the repository for the picker
+func (m Model) loadWorktrees() tea.Cmd {
+	switcher, ok := m.source.(WorktreeSwitcher)
+	if !ok {
+		return func() tea.Msg {
+			return worktreesLoadedMsg{err: errors.New("worktrees are not available in this view")}
+		}
+	}
+	return func() tea.Msg {
+		worktrees, err := switcher.Worktrees()
+		return worktreesLoadedMsg{worktrees: worktrees, err: err}
+	}
+}
+
+// openWorktreePicker shows the worktrees with the current one selected
+func (m *Model) openWorktreePicker(worktrees []git.Worktree) {
+	var items []picker.Item
+	current := 0
+	for _, wt := range worktrees {
+		if wt.Bare || wt.Prunable {
+			continue
+		}
+		detail := wt.Branch
+		if detail == "" {
+			detail = "detached at " + shortSHA(wt.Head)
+		}
+		if m.repo != nil && sameDir(wt.Path, m.repo.Root()) {
+			current = len(items)
+			detail += " (current)"
+		}
+		items = append(items, picker.Item{Label: wt.Path, Detail: detail, Value: wt.Path})
+	}
+	m.picker.Open(pickWorktree, "Worktrees", items, current)
+}
+
+// switchWorktree reloads the changeset from the worktree at path
+func (m *Model) switchWorktree(path string) tea.Cmd {
+	if m.repo != nil && sameDir(path, m.repo.Root()) {
+		return nil
+	}
+	switcher, ok := m.source.(WorktreeSwitcher)
+	if !ok {
+		return nil
+	}
+	switcher.SetWorktree(path)
+	m.diffView.Clear()
+	return m.startRepoLoad()
+}
+
+// shortSHA abbreviates a commit hash for display
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,276 @@
+// Package config loads the user configuration file.
+//
+// The file uses a small subset of TOML: comments, [sections], and
+// key = value pairs where a value is a quoted string, an integer, a boolean
+// or an array of strings.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// colorModes are the accepted values of the color setting
+var colorModes = []string{"auto", "truecolor", "256", "16", "none"}
+
+// granularities are the accepted values of the [intraline] section
+var granularities = []string{"off", "char", "word", "token"}
+
+// editors are the accepted values of the editor setting
+var editors = []string{"vscode", "jetbrains"}
+
+// Config holds the user settings
+type Config struct {
+	// Diffs with more lines than this are only rendered once confirmed.
+	// Zero disables the guard.
+	MaxDiffLines int
+	// Columns per tab stop when expanding tabs in diff content
+	TabWidth int
+	// Runs of more unchanged lines than this fold into one row in the diff
+	// view. Zero shows every line.
+	ElideUnchanged int
+	// Show tabs as → and trailing spaces as · in the diff view
+	ShowWhitespace bool
+	// Show the line number column of the diff view
+	LineNumbers bool
+	// Number diff rows by their distance from the cursor
+	RelativeLineNumbers bool
+	// Color blocks of lines moved within a file apart from other changes
+	ColorMoved bool
+	// Show Nerd Font file and folder icons in the file list
+	Icons bool
+	// Show each file's number in the file list, for jumping to it with :
+	FileNumbers bool
+	// Show each file's size class (XS-XL) in the file list, not only while
+	// ordering the files by size
+	SizeBadges bool
+	// Open on the summary of the changeset rather than the file list
+	StartSummary bool
+	// Syntax highlighting style: a built-in chroma style name, or a chroma
+	// XML style file or TOML file of token style rules, relative to the
+	// config file
+	SyntaxStyle string
+	// Style resolved from SyntaxStyle, nil for the default
+	Style *chroma.Style
+	// Color support: "auto" detects it from the terminal and $NO_COLOR,
+	// otherwise one of "truecolor", "256", "16" or "none"
+	Color string
+	// External diff tool command, with {old} and {new} replaced by files
+	// holding both versions and {path} by the file's path
+	Difftool string
+	// GUI editor files open in with O: vscode or jetbrains. Empty uses
+	// $VISUAL or $EDITOR in the terminal.
+	Editor string
+	// Launcher of the editor when not the usual one (code, idea), e.g.
+	// cursor or goland
+	EditorCommand string
+	// Linter run on the changed files with L, {files} replaced by their
+	// paths. Its diagnostics mark the lines they're about.
+	Lint string
+	// Commands run by key, from the [commands] section, with {path},
+	// {line} and other placeholders filled in
+	Commands map[string]string
+	// Granularity of the highlighting within changed lines by language,
+	// from the [intraline] section: off, char, word or token. The
+	// "default" key applies to languages not listed.
+	Intraline map[string]string
+	// Globs of files hidden from the file list, like the lines of a
+	// .gitdiffsignore file
+	Exclude []string
+	// The user's handles and teams as the repository's CODEOWNERS names
+	// them, e.g. "@alice" or "@acme/payments", for listing only their files
+	OwnedBy []string
+	// Make file names and line numbers terminal hyperlinks (OSC 8)
+	Hyperlinks bool
+	// URL the links open, with {path}, {line} and {sha} (the head commit)
+	// replaced; empty links to the files on disk
+	LinkURL string
+
+	// Fetch the base branch's remote before computing the diff
+	Fetch bool
+
+	// Disable everything that changes the repository: reverting, fetching,
+	// custom commands and the merge check
+	ReadOnly bool
+
+	// Repositories to switch between, "~" is expanded
+	WorkspaceRepos []string
+	// Also add the repositories found below the current directory
+	WorkspaceDiscover bool
+}
+
+// Default returns the settings used when there is no config file
+func Default() Config {
+	return Config{
+		MaxDiffLines:   10000,
+		TabWidth:       4,
+		ElideUnchanged: 20,
+		LineNumbers:    true,
+		ColorMoved:     true,
+		Color:          "auto",
+	}
+}
+
+// DefaultPath returns the config file location, normally
+// ~/.config/git-diffs/config.toml
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "git-diffs", "config.toml")
+}
+
+// Load reads the config file at path, or the default location when path is
+// empty. A missing file at the default location is not an error.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	explicit := path != ""
+	if !explicit {
+		path = DefaultPath()
+		if path == "" {
+			return cfg, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+
+	values, err := parse(string(data))
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.apply(values); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if cfg.SyntaxStyle != "" {
+		if cfg.Style, err = loadStyle(cfg.SyntaxStyle, filepath.Dir(path)); err != nil {
+			return cfg, fmt.Errorf("%s: syntax_style: %w", path, err)
+		}
+	}
+	return cfg, nil
+}
+
+// apply copies parsed values into the config
+func (c *Config) apply(values map[string]value) error {
+	for key, v := range values {
+		var err error
+		switch key {
+		case "max_diff_lines":
+			c.MaxDiffLines, err = v.int()
+		case "tab_width":
+			c.TabWidth, err = v.int()
+			if err == nil && c.TabWidth < 1 {
+				err = errors.New("must be at least 1")
+			}
+		case "elide_unchanged":
+			c.ElideUnchanged, err = v.int()
+			if err == nil && c.ElideUnchanged < 0 {
+				err = errors.New("must not be negative")
+			}
+		case "show_whitespace":
+			c.ShowWhitespace, err = v.bool()
+		case "line_numbers":
+			c.LineNumbers, err = v.bool()
+		case "relative_line_numbers":
+			c.RelativeLineNumbers, err = v.bool()
+		case "color_moved":
+			c.ColorMoved, err = v.bool()
+		case "fetch":
+			c.Fetch, err = v.bool()
+		case "read_only":
+			c.ReadOnly, err = v.bool()
+		case "workspace.repos":
+			c.WorkspaceRepos, err = v.strings()
+			for i, repo := range c.WorkspaceRepos {
+				c.WorkspaceRepos[i] = expandHome(repo)
+			}
+		case "workspace.discover":
+			c.WorkspaceDiscover, err = v.bool()
+		case "icons":
+			c.Icons, err = v.bool()
+		case "file_numbers":
+			c.FileNumbers, err = v.bool()
+		case "size_badges":
+			c.SizeBadges, err = v.bool()
+		case "start_summary":
+			c.StartSummary, err = v.bool()
+		case "difftool":
+			c.Difftool, err = v.string()
+		case "editor":
+			c.Editor, err = v.string()
+			if err == nil && !slices.Contains(editors, c.Editor) {
+				err = fmt.Errorf("must be one of %s", strings.Join(editors, ", "))
+			}
+		case "editor_command":
+			c.EditorCommand, err = v.string()
+		case "lint":
+			c.Lint, err = v.string()
+		case "exclude":
+			c.Exclude, err = v.strings()
+		case "owned_by":
+			c.OwnedBy, err = v.strings()
+		case "hyperlinks":
+			c.Hyperlinks, err = v.bool()
+		case "link_url":
+			c.LinkURL, err = v.string()
+		case "syntax_style":
+			c.SyntaxStyle, err = v.string()
+		case "color":
+			c.Color, err = v.string()
+			if err == nil && !slices.Contains(colorModes, c.Color) {
+				err = fmt.Errorf("must be one of %s", strings.Join(colorModes, ", "))
+			}
+		default:
+			if name, ok := strings.CutPrefix(key, "commands."); ok {
+				if c.Commands == nil {
+					c.Commands = make(map[string]string)
+				}
+				c.Commands[name], err = v.string()
+				break
+			}
+			if lang, ok := strings.CutPrefix(key, "intraline."); ok {
+				if c.Intraline == nil {
+					c.Intraline = make(map[string]string)
+				}
+				var mode string
+				mode, err = v.string()
+				if err == nil && !slices.Contains(granularities, mode) {
+					err = fmt.Errorf("must be one of %s", strings.Join(granularities, ", "))
+				}
+				c.Intraline[strings.ToLower(lang)] = mode
+				break
+			}
+			err = errors.New("unknown setting")
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %s: %w", v.line, key, err)
+		}
+	}
+	return nil
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
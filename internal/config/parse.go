@@ -0,0 +1,202 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// value is a parsed setting. Exactly one of the typed fields is set,
+// according to kind.
+type value struct {
+	kind string // "string", "int", "bool" or "array"
+	str  string
+	num  int
+	flag bool
+	list []string
+	line int
+}
+
+func (v value) string() (string, error) {
+	if v.kind != "string" {
+		return "", fmt.Errorf("expected a string, got %s", v.kind)
+	}
+	return v.str, nil
+}
+
+func (v value) int() (int, error) {
+	if v.kind != "int" {
+		return 0, fmt.Errorf("expected an integer, got %s", v.kind)
+	}
+	return v.num, nil
+}
+
+func (v value) bool() (bool, error) {
+	if v.kind != "bool" {
+		return false, fmt.Errorf("expected true or false, got %s", v.kind)
+	}
+	return v.flag, nil
+}
+
+func (v value) strings() ([]string, error) {
+	if v.kind != "array" {
+		return nil, fmt.Errorf("expected an array of strings, got %s", v.kind)
+	}
+	return v.list, nil
+}
+
+// parse reads the TOML subset into a map keyed by "section.key" (or just
+// "key" before the first section)
+func parse(text string) (map[string]value, error) {
+	values := make(map[string]value)
+	section := ""
+
+	for i, raw := range strings.Split(text, "\n") {
+		lineNum := i + 1
+		line := strings.TrimSpace(stripComment(raw))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", lineNum)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == "" {
+				return nil, fmt.Errorf("line %d: empty section name", lineNum)
+			}
+			continue
+		}
+
+		key, rest, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNum)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", lineNum)
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+
+		v, err := parseValue(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", lineNum, key, err)
+		}
+		v.line = lineNum
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("line %d: %s is set twice", lineNum, key)
+		}
+		values[key] = v
+	}
+
+	return values, nil
+}
+
+// parseValue parses the right-hand side of an assignment
+func parseValue(s string) (value, error) {
+	switch {
+	case s == "":
+		return value{}, errors.New("missing value")
+	case s == "true" || s == "false":
+		return value{kind: "bool", flag: s == "true"}, nil
+	case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'"):
+		str, err := parseString(s)
+		return value{kind: "string", str: str}, err
+	case strings.HasPrefix(s, "["):
+		list, err := parseArray(s)
+		return value{kind: "array", list: list}, err
+	}
+
+	n, err := strconv.Atoi(strings.ReplaceAll(s, "_", ""))
+	if err != nil {
+		return value{}, fmt.Errorf("invalid value %q", s)
+	}
+	return value{kind: "int", num: n}, nil
+}
+
+// parseString parses a basic ("...") or literal ('...') string
+func parseString(s string) (string, error) {
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return "", errors.New("unterminated string")
+	}
+	if s[0] == '\'' {
+		return s[1 : len(s)-1], nil
+	}
+	str, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+	return str, nil
+}
+
+// parseArray parses a single-line array of strings
+func parseArray(s string) ([]string, error) {
+	if !strings.HasSuffix(s, "]") {
+		return nil, errors.New("unterminated array")
+	}
+	body := strings.TrimSpace(s[1 : len(s)-1])
+
+	var list []string
+	for body != "" {
+		if body[0] != '"' && body[0] != '\'' {
+			return nil, errors.New("arrays may only contain strings")
+		}
+		end := closingQuote(body)
+		if end < 0 {
+			return nil, errors.New("unterminated string")
+		}
+		str, err := parseString(body[:end+1])
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, str)
+
+		body = strings.TrimSpace(body[end+1:])
+		if body == "" {
+			break
+		}
+		if body[0] != ',' {
+			return nil, errors.New("expected , between array items")
+		}
+		body = strings.TrimSpace(body[1:])
+	}
+	return list, nil
+}
+
+// closingQuote returns the index of the quote ending the string at the
+// start of s, skipping escaped quotes in basic strings
+func closingQuote(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// stripComment removes a trailing # comment that isn't inside a string
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0 && c == '\\' && quote == '"':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
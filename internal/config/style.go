@@ -0,0 +1,78 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// loadStyle resolves the syntax_style setting: the name of a built-in chroma
+// style, or the path of a style file, relative to dir. Files ending in .xml
+// are chroma XML styles; other files use the TOML subset, mapping token
+// types to style rules:
+//
+//	name = "corporate"
+//	Background = "bg:#1e1e1e #d4d4d4"
+//	Keyword = "bold #c586c0"
+//	LiteralString = "#ce9178"
+func loadStyle(setting, dir string) (*chroma.Style, error) {
+	ext := strings.ToLower(filepath.Ext(setting))
+	if ext != ".xml" && ext != ".toml" && !strings.ContainsRune(setting, filepath.Separator) && !strings.Contains(setting, "/") {
+		style, ok := styles.Registry[strings.ToLower(setting)]
+		if !ok {
+			return nil, fmt.Errorf("unknown style %q; name a chroma style or a .xml or .toml file", setting)
+		}
+		return style, nil
+	}
+
+	path := expandHome(setting)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ext == ".xml" {
+		style, err := chroma.NewXMLStyle(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return style, nil
+	}
+
+	values, err := parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	entries := make(chroma.StyleEntries)
+	for key, v := range values {
+		rule, err := v.string()
+		if err == nil && key == "name" {
+			name = rule
+			continue
+		}
+		if err == nil {
+			var token chroma.TokenType
+			if token, err = chroma.TokenTypeString(key); err == nil {
+				entries[token] = rule
+			} else {
+				err = fmt.Errorf("unknown token type; use chroma's names, e.g. Keyword or LiteralString")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: %s: %w", path, v.line, key, err)
+		}
+	}
+	style, err := chroma.NewStyle(name, entries)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return style, nil
+}
@@ -0,0 +1,233 @@
+package export
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/matthewmyrick/git-diffs/internal/app"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui/diffview"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
+)
+
+// HTMLOptions configures HTML output
+type HTMLOptions struct {
+	Layout   Layout
+	TabWidth int
+	// LayoutLinks adds links switching the layout through a ?layout= query,
+	// for pages that are served rather than saved
+	LayoutLinks bool
+}
+
+// htmlPage is the data of the page template
+type htmlPage struct {
+	Title       string
+	Files       []htmlFile
+	SideBySide  bool
+	LayoutLinks bool
+}
+
+type htmlFile struct {
+	ID        string
+	Path      string
+	Status    string
+	Additions int
+	Deletions int
+	Binary    bool
+	Error     string
+	Rows      []htmlRow
+}
+
+// htmlRow is a table row: a hunk header, or a line of each side. In the
+// unified layout only the Old fields are used.
+type htmlRow struct {
+	Header   string
+	OldNum   int
+	Old      string
+	OldClass string
+	NewNum   int
+	New      string
+	NewClass string
+}
+
+// HTML writes a standalone page with the diff of every file of cs to w
+func HTML(w io.Writer, source app.Source, cs *app.Changeset, opts HTMLOptions) error {
+	if opts.TabWidth <= 0 {
+		opts.TabWidth = 4
+	}
+	page := htmlPage{
+		Title:       Title(cs),
+		SideBySide:  opts.Layout == SideBySide,
+		LayoutLinks: opts.LayoutLinks,
+	}
+	for i, file := range cs.Files {
+		f := htmlFile{
+			ID:        fmt.Sprintf("file-%d", i+1),
+			Path:      file.Path,
+			Status:    file.Status.String(),
+			Additions: file.Additions,
+			Deletions: file.Deletions,
+			Binary:    file.Binary,
+		}
+		if file.OldPath != "" && file.OldPath != file.Path {
+			f.Path = file.OldPath + " → " + file.Path
+		}
+		if !file.Binary {
+			diff, err := source.FileDiff(file)
+			if err != nil {
+				f.Error = err.Error()
+			} else if page.SideBySide {
+				f.Rows = sideBySideRows(diff, opts.TabWidth)
+			} else {
+				f.Rows = unifiedRows(diff, opts.TabWidth)
+			}
+		}
+		page.Files = append(page.Files, f)
+	}
+	return pageTemplate.Execute(w, page)
+}
+
+// Title describes the changeset like the TUI's header
+func Title(cs *app.Changeset) string {
+	title := cs.Title
+	if title == "" {
+		title = fmt.Sprintf("%s → %s", cs.CurrentBranch, cs.BaseBranch)
+	}
+	if cs.Location != "" {
+		title = cs.Location + ": " + title
+	}
+	return title
+}
+
+func unifiedRows(diff *git.FileDiff, tabWidth int) []htmlRow {
+	var rows []htmlRow
+	for _, hunk := range diff.Hunks {
+		for _, line := range hunk.Lines {
+			content := text.ExpandTabs(strings.TrimSuffix(line.Content, "\r"), tabWidth)
+			if line.Type == git.DiffLineHeader {
+				rows = append(rows, htmlRow{Header: content})
+				continue
+			}
+			rows = append(rows, htmlRow{
+				OldNum:   line.OldLineNum,
+				NewNum:   line.NewLineNum,
+				Old:      content,
+				OldClass: lineClass(line.Type),
+			})
+		}
+	}
+	return rows
+}
+
+func sideBySideRows(diff *git.FileDiff, tabWidth int) []htmlRow {
+	var rows []htmlRow
+	for _, line := range diffview.Rows(diff) {
+		if line.OldType == git.DiffLineHeader {
+			rows = append(rows, htmlRow{Header: line.OldContent})
+			continue
+		}
+		row := htmlRow{OldNum: line.OldLineNum, NewNum: line.NewLineNum, OldClass: "empty", NewClass: "empty"}
+		if line.OldLineNum > 0 {
+			row.Old = text.ExpandTabs(strings.TrimSuffix(line.OldContent, "\r"), tabWidth)
+			row.OldClass = lineClass(line.OldType)
+		}
+		if line.NewLineNum > 0 {
+			row.New = text.ExpandTabs(strings.TrimSuffix(line.NewContent, "\r"), tabWidth)
+			row.NewClass = lineClass(line.NewType)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func lineClass(lineType git.DiffLineType) string {
+	switch lineType {
+	case git.DiffLineAddition:
+		return "add"
+	case git.DiffLineDeletion:
+		return "del"
+	}
+	return "ctx"
+}
+
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"num": func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return fmt.Sprint(n)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} · git-diffs</title>
+<style>
+:root { color-scheme: dark; --bg: #111827; --surface: #1f2937; --text: #f9fafb; --muted: #9ca3af; --primary: #7c3aed; --add: #0a2a12; --del: #2a0a0a; --addfg: #88cc88; --delfg: #cc8888; }
+* { box-sizing: border-box; }
+body { margin: 0; background: var(--bg); color: var(--text); font: 14px/1.4 system-ui, sans-serif; display: flex; }
+nav { position: sticky; top: 0; height: 100vh; overflow: auto; width: 280px; flex: none; background: var(--surface); padding: 12px; }
+nav h1 { font-size: 15px; margin: 0 0 8px; }
+nav a { display: block; color: var(--text); text-decoration: none; padding: 2px 4px; border-radius: 4px; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+nav a:hover { background: var(--primary); }
+nav .layout { margin: 0 0 12px; color: var(--muted); }
+nav .layout a { display: inline; color: var(--muted); text-decoration: underline; }
+main { flex: 1; min-width: 0; padding: 12px; }
+details { margin-bottom: 12px; border: 1px solid #374151; border-radius: 6px; overflow: hidden; }
+summary { cursor: pointer; padding: 6px 10px; background: var(--surface); font-weight: bold; }
+.status { color: var(--muted); font-weight: normal; margin-right: 6px; }
+.counts { color: var(--muted); font-weight: normal; margin-left: 6px; }
+.plus { color: var(--addfg); } .minus { color: var(--delfg); }
+table { width: 100%; border-collapse: collapse; font: 12px/1.5 ui-monospace, SFMono-Regular, Menlo, monospace; table-layout: fixed; }
+td { padding: 0 6px; white-space: pre-wrap; word-break: break-all; vertical-align: top; }
+td.num { width: 4.5em; color: var(--muted); text-align: right; user-select: none; }
+td.hunk { color: #818cf8; background: #161e2e; }
+td.add { background: var(--add); } td.del { background: var(--del); }
+td.empty { background: #0d1320; }
+.note { padding: 6px 10px; color: var(--muted); }
+</style>
+</head>
+<body>
+<nav>
+<h1>{{.Title}}</h1>
+{{- if .LayoutLinks}}
+<p class="layout">{{if .SideBySide}}<a href="?layout=unified">unified</a> · side by side{{else}}unified · <a href="?layout=side-by-side">side by side</a>{{end}}</p>
+{{- end}}
+{{- range .Files}}
+<a href="#{{.ID}}" title="{{.Path}}">{{.Path}}</a>
+{{- end}}
+</nav>
+<main>
+{{- if not .Files}}
+<p class="note">No changes.</p>
+{{- end}}
+{{- $sbs := .SideBySide}}
+{{- range .Files}}
+<details id="{{.ID}}" open>
+<summary><span class="status">{{.Status}}</span>{{.Path}}{{if not .Binary}}<span class="counts"><span class="plus">+{{.Additions}}</span> <span class="minus">-{{.Deletions}}</span></span>{{end}}</summary>
+{{- if .Error}}
+<p class="note">{{.Error}}</p>
+{{- else if .Binary}}
+<p class="note">Binary file</p>
+{{- else}}
+<table>
+{{- range .Rows}}
+{{- if .Header}}
+<tr><td class="hunk" colspan="{{if $sbs}}4{{else}}3{{end}}">{{.Header}}</td></tr>
+{{- else if $sbs}}
+<tr><td class="num">{{num .OldNum}}</td><td class="{{.OldClass}}">{{.Old}}</td><td class="num">{{num .NewNum}}</td><td class="{{.NewClass}}">{{.New}}</td></tr>
+{{- else}}
+<tr><td class="num">{{num .OldNum}}</td><td class="num">{{num .NewNum}}</td><td class="{{.OldClass}}">{{.Old}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+{{- end}}
+</details>
+{{- end}}
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,168 @@
+// Package export renders a whole changeset outside of the TUI, for pagers
+// and other programs.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matthewmyrick/git-diffs/internal/app"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/internal/ui/diffview"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
+)
+
+// Layout selects how text output arranges the two sides of a diff
+type Layout int
+
+const (
+	Unified    Layout = iota // One column with - and + lines, like git diff
+	SideBySide               // Old and new side by side, like the Both view
+)
+
+// ParseLayout parses a layout name: unified or side-by-side
+func ParseLayout(s string) (Layout, error) {
+	switch s {
+	case "unified":
+		return Unified, nil
+	case "side-by-side":
+		return SideBySide, nil
+	}
+	return Unified, fmt.Errorf("unknown layout %q: want unified or side-by-side", s)
+}
+
+// TextOptions configures text output
+type TextOptions struct {
+	Layout   Layout
+	Width    int // Columns the side-by-side layout fills
+	TabWidth int
+}
+
+var (
+	fileStyle     = lipgloss.NewStyle().Bold(true).Foreground(ui.ColorText)
+	hunkStyle     = ui.DiffHeaderStyle
+	additionStyle = lipgloss.NewStyle().Foreground(ui.ColorAdditionFg)
+	deletionStyle = lipgloss.NewStyle().Foreground(ui.ColorDeletionFg)
+	mutedStyle    = lipgloss.NewStyle().Foreground(ui.ColorMuted)
+)
+
+// Text writes the diff of every file of cs to w. Files whose diff can't be
+// loaded show the error in their place.
+func Text(w io.Writer, source app.Source, cs *app.Changeset, opts TextOptions) error {
+	if opts.TabWidth <= 0 {
+		opts.TabWidth = 4
+	}
+	if opts.Width <= 0 {
+		opts.Width = 120
+	}
+
+	var b strings.Builder
+	for i, file := range cs.Files {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fileStyle.Render(fileHeader(file)) + "\n")
+
+		diff, err := source.FileDiff(file)
+		switch {
+		case err != nil:
+			b.WriteString(deletionStyle.Render("error: "+err.Error()) + "\n")
+		case file.Binary:
+			b.WriteString(mutedStyle.Render("Binary file") + "\n")
+		case opts.Layout == SideBySide:
+			writeSideBySide(&b, diff, opts)
+		default:
+			writeUnified(&b, diff, opts)
+		}
+
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+		b.Reset()
+	}
+	return nil
+}
+
+// fileHeader describes a file like the file list does: status, path and
+// line counts
+func fileHeader(file git.ChangedFile) string {
+	path := file.Path
+	if file.OldPath != "" && file.OldPath != file.Path {
+		path = file.OldPath + " → " + file.Path
+	}
+	header := fmt.Sprintf("%s %s", file.Status, path)
+	if !file.Binary {
+		header += fmt.Sprintf(" (+%d -%d)", file.Additions, file.Deletions)
+	}
+	return header
+}
+
+func writeUnified(b *strings.Builder, diff *git.FileDiff, opts TextOptions) {
+	for _, hunk := range diff.Hunks {
+		for _, line := range hunk.Lines {
+			content := text.ExpandTabs(strings.TrimSuffix(line.Content, "\r"), opts.TabWidth)
+			switch line.Type {
+			case git.DiffLineHeader:
+				b.WriteString(hunkStyle.Render(content))
+			case git.DiffLineAddition:
+				b.WriteString(additionStyle.Render("+" + content))
+			case git.DiffLineDeletion:
+				b.WriteString(deletionStyle.Render("-" + content))
+			default:
+				b.WriteString(" " + content)
+			}
+			b.WriteString("\n")
+		}
+	}
+}
+
+func writeSideBySide(b *strings.Builder, diff *git.FileDiff, opts TextOptions) {
+	rows := diffview.Rows(diff)
+	maxNum := 0
+	for _, row := range rows {
+		maxNum = max(maxNum, row.OldLineNum, row.NewLineNum)
+	}
+	numWidth := max(len(strconv.Itoa(maxNum)), 3)
+	// Each side: number, space, marker, content; " │ " in between
+	sideWidth := max((opts.Width-3)/2, numWidth+4)
+	contentWidth := sideWidth - numWidth - 2
+
+	side := func(num int, content string, lineType git.DiffLineType) string {
+		if num == 0 {
+			return strings.Repeat(" ", sideWidth)
+		}
+		content = text.Fit(text.ExpandTabs(strings.TrimSuffix(content, "\r"), opts.TabWidth), contentWidth)
+		s := fmt.Sprintf("%*d %s%s", numWidth, num, diffMarker(lineType), content)
+		switch lineType {
+		case git.DiffLineAddition:
+			return additionStyle.Render(s)
+		case git.DiffLineDeletion:
+			return deletionStyle.Render(s)
+		}
+		return s
+	}
+
+	sep := mutedStyle.Render(" │ ")
+	for _, row := range rows {
+		if row.OldType == git.DiffLineHeader {
+			b.WriteString(hunkStyle.Render(text.Truncate(row.OldContent, opts.Width, "…")) + "\n")
+			continue
+		}
+		line := side(row.OldLineNum, row.OldContent, row.OldType) + sep + side(row.NewLineNum, row.NewContent, row.NewType)
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+}
+
+func diffMarker(lineType git.DiffLineType) string {
+	switch lineType {
+	case git.DiffLineAddition:
+		return "+"
+	case git.DiffLineDeletion:
+		return "-"
+	}
+	return " "
+}
@@ -0,0 +1,73 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// codeownersFiles are where GitHub looks for the CODEOWNERS file, in order
+var codeownersFiles = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Codeowners maps paths to their owners following a CODEOWNERS file: each
+// line is a .gitignore-style pattern followed by the owners' @handles,
+// @org/teams or emails, and the last matching line decides. A pattern
+// without owners leaves its paths unowned.
+type Codeowners struct {
+	rules []ownerRule
+}
+
+type ownerRule struct {
+	match  *Excluder
+	owners []string
+}
+
+// Codeowners reads the CODEOWNERS file of the repository, or returns nil
+// when it has none
+func (r *Repo) Codeowners() (*Codeowners, error) {
+	for _, name := range codeownersFiles {
+		f, err := os.Open(filepath.Join(r.root, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return parseCodeowners(f)
+	}
+	return nil, nil
+}
+
+func parseCodeowners(f *os.File) (*Codeowners, error) {
+	c := &Codeowners{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		c.rules = append(c.rules, ownerRule{
+			match:  NewExcluder(fields[:1]),
+			owners: fields[1:],
+		})
+	}
+	return c, scanner.Err()
+}
+
+// Owners returns the owners of path, relative to the root
+func (c *Codeowners) Owners(path string) []string {
+	if c == nil {
+		return nil
+	}
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].match.Excluded(path) {
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
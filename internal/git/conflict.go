@@ -0,0 +1,76 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// conflictStart matches the line opening a conflict region
+const conflictStart = "^<{7}( |$)"
+
+// conflictChunk bounds the pathspecs passed to a single git grep so long
+// file lists stay under the command line limit
+const conflictChunk = 500
+
+// IsConflictMarker reports whether line is one of the <<<<<<<, |||||||,
+// ======= or >>>>>>> lines git writes around a conflict region
+func IsConflictMarker(line string) bool {
+	if line == "=======" {
+		return true
+	}
+	for _, marker := range []string{"<<<<<<<", "|||||||", ">>>>>>>"} {
+		if rest, ok := strings.CutPrefix(line, marker); ok && (rest == "" || rest[0] == ' ') {
+			return true
+		}
+	}
+	return false
+}
+
+// ConflictCounts counts the conflict regions left in paths at rev, which
+// may be WorkTree or Index, keyed by path. Files without conflict markers
+// are omitted.
+func (r *Repo) ConflictCounts(rev string, paths []string) (map[string]int, error) {
+	// git grep searches the working tree without a revision
+	var revArgs []string
+	prefix := ""
+	switch rev {
+	case WorkTree:
+	case Index:
+		revArgs = []string{"--cached"}
+	default:
+		revArgs, prefix = []string{rev}, rev+":"
+	}
+
+	counts := make(map[string]int)
+	for len(paths) > 0 {
+		chunk := paths[:min(len(paths), conflictChunk)]
+		paths = paths[len(chunk):]
+
+		args := append([]string{"-C", r.path, "--literal-pathspecs", "grep", "-c", "-z", "-E", conflictStart}, revArgs...)
+		args = append(append(args, "--"), chunk...)
+		out, err := exec.Command("git", args...).Output()
+		if err != nil {
+			// git grep exits 1 when nothing matches
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("failed to search for conflict markers: %w", commandError(err))
+		}
+
+		// Lines are "rev:path\x00count", or "path\x00count" without a rev
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			path, count, ok := strings.Cut(strings.TrimPrefix(line, prefix), "\x00")
+			if !ok {
+				continue
+			}
+			if n, err := strconv.Atoi(count); err == nil {
+				counts[path] = n
+			}
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,20 @@
+package git
+
+import (
+	"fmt"
+	"math"
+)
+
+// SetFullContext makes file diffs show every unchanged line of the file
+// rather than three around each change
+func (r *Repo) SetFullContext(full bool) {
+	r.fullContext = full
+}
+
+// contextFlags returns the flags selecting how much context file diffs show
+func (r *Repo) contextFlags() []string {
+	if r.fullContext {
+		return []string{fmt.Sprintf("--unified=%d", math.MaxInt32)}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// shaPattern matches an abbreviated or full commit hash
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// IsSHA reports whether ref looks like a commit hash rather than a name
+func IsSHA(ref string) bool {
+	return shaPattern.MatchString(ref)
+}
+
+// describePattern splits git describe --long output into the tag, the
+// commits since it and the abbreviated hash
+var describePattern = regexp.MustCompile(`^(.+)-(\d+)-g[0-9a-f]+$`)
+
+// Describe names a commit for the header, since a bare hash or HEAD says
+// little: its short hash followed by the nearest tag and how many commits
+// past it, e.g. "1a2b3c4 (v1.2.0+3)", or just the hash when no tag is
+// reachable. It returns rev itself when it doesn't name a commit.
+func (r *Repo) Describe(rev string) string {
+	out, err := exec.Command("git", "-C", r.path, "rev-parse", "--short", rev+"^{commit}").Output()
+	if err != nil {
+		return rev
+	}
+	short := strings.TrimSpace(string(out))
+
+	out, err = exec.Command("git", "-C", r.path, "describe", "--tags", "--long", rev).Output()
+	if err != nil {
+		return short
+	}
+	m := describePattern.FindStringSubmatch(strings.TrimSpace(string(out)))
+	switch {
+	case m == nil:
+		return short
+	case m[2] == "0":
+		return fmt.Sprintf("%s (%s)", short, m[1])
+	}
+	return fmt.Sprintf("%s (%s+%s)", short, m[1], m[2])
+}
+
+// BranchLabel returns the checked out branch, or when HEAD is detached the
+// commit it is at, described
+func (r *Repo) BranchLabel() (string, error) {
+	branch, err := r.GetCurrentBranch()
+	if err != nil || branch != "HEAD" {
+		return branch, err
+	}
+	return r.Describe("HEAD"), nil
+}
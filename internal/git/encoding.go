@@ -0,0 +1,143 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/matthewmyrick/git-diffs/pkg/gitdiff"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Encodings content is converted from, as shown in the diff header
+const (
+	EncodingUTF16LE = "UTF-16LE"
+	EncodingUTF16BE = "UTF-16BE"
+	EncodingLatin1  = "ISO-8859-1"
+)
+
+// DetectEncoding guesses the encoding of a file's content: UTF-16 when it
+// starts with a byte order mark, Latin-1 when it isn't valid UTF-8, and ""
+// for UTF-8
+func DetectEncoding(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xff, 0xfe}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(content, []byte{0xfe, 0xff}):
+		return EncodingUTF16BE
+	case !utf8.Valid(content):
+		return EncodingLatin1
+	}
+	return ""
+}
+
+// decoder returns the decoder for an encoding DetectEncoding reports
+func decoder(enc string) *encoding.Decoder {
+	switch enc {
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder()
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder()
+	case EncodingLatin1:
+		return charmap.ISO8859_1.NewDecoder()
+	}
+	return nil
+}
+
+// decodeDiff converts the lines of a diff of a file that isn't UTF-8. Lines
+// of a Latin-1 file that aren't valid UTF-8 are converted in place. UTF-16
+// files, which git finds binary, are rediffed from both versions converted
+// to UTF-8. The diff's Encoding names what it was converted from.
+func (r *Repo) decodeDiff(ctx context.Context, diff *gitdiff.FileDiff, base, head string, mode CompareMode, file ChangedFile) (*gitdiff.FileDiff, error) {
+	if !diff.Binary {
+		latin1 := charmap.ISO8859_1.NewDecoder()
+		for h := range diff.Hunks {
+			lines := diff.Hunks[h].Lines
+			for i := range lines {
+				if lines[i].Type == gitdiff.DiffLineHeader || utf8.ValidString(lines[i].Content) {
+					continue
+				}
+				if content, err := latin1.String(lines[i].Content); err == nil {
+					lines[i].Content = content
+					diff.Encoding = EncodingLatin1
+				}
+			}
+		}
+		return diff, nil
+	}
+
+	before, after, err := r.FileVersions(base, head, mode, file)
+	if err != nil {
+		// Left as a binary file rather than failing the diff
+		return diff, nil
+	}
+	enc := DetectEncoding(after)
+	if file.Status == StatusDeleted {
+		enc = DetectEncoding(before)
+	}
+	if enc != EncodingUTF16LE && enc != EncodingUTF16BE {
+		return diff, nil
+	}
+	if before, err = decodeVersion(before); err != nil {
+		return diff, nil
+	}
+	if after, err = decodeVersion(after); err != nil {
+		return diff, nil
+	}
+
+	decoded, err := r.diffContent(ctx, before, after)
+	if err != nil {
+		return nil, err
+	}
+	decoded.OldPath, decoded.NewPath = diff.OldPath, diff.NewPath
+	decoded.Similarity = diff.Similarity
+	decoded.Encoding = enc
+	return decoded, nil
+}
+
+// decodeVersion converts one version of a UTF-16 file to UTF-8. An empty
+// version, such as the old side of an added file, stays empty.
+func decodeVersion(content []byte) ([]byte, error) {
+	enc := DetectEncoding(content)
+	if len(content) == 0 || enc == "" {
+		return content, nil
+	}
+	return decoder(enc).Bytes(content)
+}
+
+// diffContent diffs two versions of a file held in memory, with the context
+// file diffs use
+func (r *Repo) diffContent(ctx context.Context, before, after []byte) (*gitdiff.FileDiff, error) {
+	dir, err := os.MkdirTemp("", "git-diffs-decoded-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	oldFile, newFile := filepath.Join(dir, "old"), filepath.Join(dir, "new")
+	if err := os.WriteFile(oldFile, before, 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(newFile, after, 0o600); err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"diff", "--no-index"}, r.diffFlags()...)
+	args = append(append(args, r.contextFlags()...), "--", oldFile, newFile)
+	out, err := exec.CommandContext(ctx, "git", args...).Output()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	// --no-index exits with 1 when the files differ
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return nil, fmt.Errorf("failed to diff the decoded file: %w", commandError(err))
+	}
+	return gitdiff.Parse(string(out))
+}
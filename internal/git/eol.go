@@ -0,0 +1,7 @@
+package git
+
+// SetIgnoreLineEndings makes diffs ignore carriage returns at the end of
+// lines, so a file converted between CRLF and LF shows no changes
+func (r *Repo) SetIgnoreLineEndings(ignore bool) {
+	r.ignoreEOL = ignore
+}
@@ -0,0 +1,124 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ExcludeFile is the name of the file in the repository root listing paths
+// to hide from the file list
+const ExcludeFile = ".gitdiffsignore"
+
+// ExcludePatterns returns the patterns of the exclude file in the
+// repository root: one per line, with blank lines and # comments skipped.
+// A missing file has none.
+func (r *Repo) ExcludePatterns() ([]string, error) {
+	f, err := os.Open(filepath.Join(r.root, ExcludeFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// Excluder matches paths against exclude patterns, which follow
+// .gitignore: a pattern without a slash matches a file or folder name at
+// any depth, one with a slash is relative to the root, a trailing slash
+// only matches folders, "**" spans folders and "!" re-includes a path
+// excluded by an earlier pattern
+type Excluder struct {
+	rules []excludeRule
+}
+
+type excludeRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// NewExcluder compiles patterns
+func NewExcluder(patterns []string) *Excluder {
+	e := &Excluder{}
+	for _, p := range patterns {
+		var rule excludeRule
+		p, rule.negate = strings.CutPrefix(p, "!")
+		p, rule.dirOnly = strings.CutSuffix(p, "/")
+		if p == "" {
+			continue
+		}
+		anchored := strings.Contains(p, "/")
+		p = strings.TrimPrefix(p, "/")
+		expr := globRegexp(p)
+		if !anchored {
+			expr = "(.*/)?" + expr
+		}
+		rule.re = regexp.MustCompile("^" + expr + "$")
+		e.rules = append(e.rules, rule)
+	}
+	return e
+}
+
+// Excluded reports whether path, relative to the root, is hidden. A path is
+// hidden when it or one of its folders matches; the last matching pattern
+// decides.
+func (e *Excluder) Excluded(path string) bool {
+	if e == nil || len(e.rules) == 0 {
+		return false
+	}
+	excluded := false
+	parts := strings.Split(path, "/")
+	for _, rule := range e.rules {
+		for i := range parts {
+			isDir := i < len(parts)-1
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.re.MatchString(strings.Join(parts[:i+1], "/")) {
+				excluded = !rule.negate
+				break
+			}
+		}
+	}
+	return excluded
+}
+
+// globRegexp translates a glob into a regular expression: "*" and "?" stay
+// within a folder, "**" crosses them
+func globRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
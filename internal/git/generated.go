@@ -0,0 +1,100 @@
+package git
+
+import (
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// generatedPatterns match the base names of files that are commonly
+// generated: lock files, checksums, protobuf and other codegen output, and
+// minified assets
+var generatedPatterns = []string{
+	"go.sum",
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"bun.lockb",
+	"Cargo.lock",
+	"Gemfile.lock",
+	"composer.lock",
+	"poetry.lock",
+	"Pipfile.lock",
+	"uv.lock",
+	"flake.lock",
+	"*.pb.go",
+	"*.pb.gw.go",
+	"*_pb2.py",
+	"*_pb2_grpc.py",
+	"*.pb.cc",
+	"*.pb.h",
+	"*_generated.go",
+	"zz_generated.*.go",
+	"*.gen.go",
+	"*.min.js",
+	"*.min.css",
+	"*.js.map",
+	"*.css.map",
+}
+
+// IsGeneratedPath reports whether the file name matches a common generated
+// file pattern
+func IsGeneratedPath(p string) bool {
+	base := path.Base(p)
+	for _, pattern := range generatedPatterns {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// GeneratedFiles reports which of paths are generated. The
+// linguist-generated attribute decides when it is set or unset in
+// .gitattributes; otherwise the file name is matched against common
+// patterns.
+func (r *Repo) GeneratedFiles(paths []string) map[string]bool {
+	generated := make(map[string]bool)
+	attrs := r.generatedAttrs(paths)
+	for _, p := range paths {
+		if value, ok := attrs[p]; ok {
+			if value {
+				generated[p] = true
+			}
+			continue
+		}
+		if IsGeneratedPath(p) {
+			generated[p] = true
+		}
+	}
+	return generated
+}
+
+// generatedAttrs returns the linguist-generated attribute of the paths that
+// set or unset it. Failures are ignored so the patterns still apply.
+func (r *Repo) generatedAttrs(paths []string) map[string]bool {
+	attrs := make(map[string]bool)
+	if len(paths) == 0 {
+		return attrs
+	}
+
+	cmd := exec.Command("git", "-C", r.root, "check-attr", "-z", "--stdin", "linguist-generated")
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	out, err := cmd.Output()
+	if err != nil {
+		return attrs
+	}
+
+	// Records are "path\x00attribute\x00value\x00"
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		switch fields[i+2] {
+		case "set", "true":
+			attrs[fields[i]] = true
+		case "unset", "false":
+			attrs[fields[i]] = false
+		}
+	}
+	return attrs
+}
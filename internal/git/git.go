@@ -1,73 +1,50 @@
 package git
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
-)
-
-// FileStatus represents the type of change for a file
-type FileStatus string
 
-const (
-	StatusAdded    FileStatus = "A"
-	StatusModified FileStatus = "M"
-	StatusDeleted  FileStatus = "D"
-	StatusRenamed  FileStatus = "R"
-	StatusCopied   FileStatus = "C"
-	StatusUnknown  FileStatus = "?"
+	"github.com/matthewmyrick/git-diffs/pkg/gitdiff"
 )
 
-// ChangedFile represents a file that has changed between branches
-type ChangedFile struct {
-	Status   FileStatus
-	Path     string
-	OldPath  string // Used for renames
-	Additions int
-	Deletions int
-}
-
-// DiffLine represents a single line in a diff
-type DiffLine struct {
-	Type       DiffLineType
-	Content    string
-	OldLineNum int
-	NewLineNum int
-}
-
-// DiffLineType represents the type of diff line
-type DiffLineType int
+// The diff model and parser live in pkg/gitdiff, so other tools can use
+// them; these aliases keep the short names used throughout the app.
+type (
+	FileStatus   = gitdiff.FileStatus
+	ChangedFile  = gitdiff.ChangedFile
+	DiffLine     = gitdiff.DiffLine
+	DiffLineType = gitdiff.DiffLineType
+	DiffHunk     = gitdiff.DiffHunk
+	FileDiff     = gitdiff.FileDiff
+)
 
 const (
-	DiffLineContext DiffLineType = iota
-	DiffLineAddition
-	DiffLineDeletion
-	DiffLineHeader
+	StatusAdded     = gitdiff.StatusAdded
+	StatusModified  = gitdiff.StatusModified
+	StatusDeleted   = gitdiff.StatusDeleted
+	StatusRenamed   = gitdiff.StatusRenamed
+	StatusCopied    = gitdiff.StatusCopied
+	StatusUnchanged = gitdiff.StatusUnchanged
+	StatusConflict  = gitdiff.StatusConflict
+	StatusUnknown   = gitdiff.StatusUnknown
+
+	DiffLineContext  = gitdiff.DiffLineContext
+	DiffLineAddition = gitdiff.DiffLineAddition
+	DiffLineDeletion = gitdiff.DiffLineDeletion
+	DiffLineHeader   = gitdiff.DiffLineHeader
 )
 
-// DiffHunk represents a hunk in a diff
-type DiffHunk struct {
-	OldStart int
-	OldCount int
-	NewStart int
-	NewCount int
-	Lines    []DiffLine
-}
-
-// FileDiff represents the diff for a single file
-type FileDiff struct {
-	OldPath string
-	NewPath string
-	Hunks   []DiffHunk
-}
-
 // Repo represents a git repository
 type Repo struct {
-	path string
+	path        string
+	root        string // Top level of the working tree
+	ignoreEOL   bool   // Diff with --ignore-cr-at-eol
+	fullContext bool   // File diffs show the whole file
 }
 
 // NewRepo creates a new Repo instance for the given path
@@ -78,12 +55,27 @@ func NewRepo(path string) (*Repo, error) {
 	}
 
 	// Check if this is a git repository
-	cmd := exec.Command("git", "-C", absPath, "rev-parse", "--git-dir")
-	if err := cmd.Run(); err != nil {
+	cmd := exec.Command("git", "-C", absPath, "rev-parse", "--show-toplevel")
+	out, err := cmd.Output()
+	if err != nil {
 		return nil, errors.New("not a git repository")
 	}
 
-	return &Repo{path: absPath}, nil
+	return &Repo{path: absPath, root: strings.TrimSpace(string(out))}, nil
+}
+
+// Root returns the top level directory of the working tree
+func (r *Repo) Root() string {
+	return r.root
+}
+
+// GitDir returns the absolute path of the repository's .git directory
+func (r *Repo) GitDir() (string, error) {
+	out, err := exec.Command("git", "-C", r.path, "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the git directory: %w", commandError(err))
+	}
+	return strings.TrimSpace(string(out)), nil
 }
 
 // GetCurrentBranch returns the name of the current branch
@@ -96,6 +88,16 @@ func (r *Repo) GetCurrentBranch() (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// Refs returns the short names of the local branches and tags
+func (r *Repo) Refs() ([]string, error) {
+	cmd := exec.Command("git", "-C", r.path, "for-each-ref", "--format=%(refname:short)", "refs/heads", "refs/tags")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs: %w", commandError(err))
+	}
+	return strings.Fields(string(out)), nil
+}
+
 // GetDefaultBranch returns the default branch (main or master)
 func (r *Repo) GetDefaultBranch() (string, error) {
 	// Try main first
@@ -124,94 +126,360 @@ func (r *Repo) GetDefaultBranch() (string, error) {
 	return "", errors.New("could not determine default branch")
 }
 
-// GetChangedFiles returns a list of files that have changed between base and head
-func (r *Repo) GetChangedFiles(base, head string) ([]ChangedFile, error) {
-	// Get file list with status
-	cmd := exec.Command("git", "-C", r.path, "diff", "--name-status", base+"..."+head)
-	out, err := cmd.Output()
-	if err != nil {
-		// Try without the three-dot notation (for uncommitted changes)
-		cmd = exec.Command("git", "-C", r.path, "diff", "--name-status", base)
-		out, err = cmd.Output()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get changed files: %w", err)
+// DetectBase picks the branch to compare against when none is given: the
+// target of the current branch's open pull request, then its upstream, then
+// the remote's default branch (origin/HEAD), then main or master. It also
+// returns which of these was used.
+func (r *Repo) DetectBase() (base, strategy string, err error) {
+	if pr := r.PullRequest(); pr != nil {
+		if base, ok := r.pullRequestBase(pr); ok {
+			return base, fmt.Sprintf("PR #%d", pr.Number), nil
 		}
 	}
 
-	var files []ChangedFile
-	scanner := bufio.NewScanner(bytes.NewReader(out))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
+	cmd := exec.Command("git", "-C", r.path, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	if out, err := cmd.Output(); err == nil {
+		return strings.TrimSpace(string(out)), "upstream", nil
+	}
+
+	cmd = exec.Command("git", "-C", r.path, "rev-parse", "--abbrev-ref", "origin/HEAD")
+	if out, err := cmd.Output(); err == nil {
+		if ref := strings.TrimSpace(string(out)); ref != "origin/HEAD" {
+			return ref, "origin/HEAD", nil
 		}
+	}
 
-		parts := strings.Fields(line)
-		if len(parts) < 2 {
-			continue
+	base, err = r.GetDefaultBranch()
+	if err != nil {
+		return "", "", err
+	}
+	return base, "default branch", nil
+}
+
+// RemoteOf returns the remote a remote-tracking ref such as origin/main
+// belongs to, or "" for local refs
+func (r *Repo) RemoteOf(ref string) string {
+	for _, remote := range r.remotes() {
+		if strings.HasPrefix(ref, remote+"/") {
+			return remote
+		}
+	}
+	return ""
+}
+
+// remotes returns the names of the repository's remotes
+func (r *Repo) remotes() []string {
+	cmd := exec.Command("git", "-C", r.path, "remote")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+// Fetch updates the remote-tracking branches of remote
+func (r *Repo) Fetch(remote string) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
+	cmd := exec.Command("git", "-C", r.path, "fetch", "--quiet", remote)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n"); msg != "" {
+			return fmt.Errorf("git fetch %s: %s", remote, msg)
 		}
+		return fmt.Errorf("git fetch %s: %w", remote, err)
+	}
+	return nil
+}
 
-		status := FileStatus(parts[0][0:1])
-		file := ChangedFile{
-			Status: status,
-			Path:   parts[len(parts)-1],
+// FetchMissing fetches a remote-tracking ref such as origin/feature, or a
+// full commit hash from origin, when it doesn't exist locally, so branches
+// and commits only on the remote can be compared without checking them out.
+// Shallow clones fetch just the tip. Local refs, and refs already there, are
+// left alone.
+func (r *Repo) FetchMissing(ref string) error {
+	if ref == "" || r.resolves(ref) {
+		return nil
+	}
+	remote, refspec := r.RemoteOf(ref), ""
+	switch {
+	case remote != "":
+		branch := strings.TrimPrefix(ref, remote+"/")
+		refspec = fmt.Sprintf("+refs/heads/%s:refs/remotes/%s", branch, ref)
+	case len(ref) == 40 && IsSHA(ref) && slices.Contains(r.remotes(), "origin"):
+		// Servers only hand out commits that no ref points at by their full hash
+		remote, refspec = "origin", ref
+	default:
+		return nil
+	}
+	if ReadOnly() {
+		return fmt.Errorf("%s isn't fetched: %w", ref, ErrReadOnly)
+	}
+	args := []string{"-C", r.path, "fetch", "--quiet"}
+	if r.shallow() {
+		args = append(args, "--depth=1")
+	}
+	args = append(args, remote, refspec)
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		if msg, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n"); msg != "" {
+			return fmt.Errorf("git fetch %s %s: %s", remote, ref, msg)
 		}
+		return fmt.Errorf("git fetch %s %s: %w", remote, ref, err)
+	}
+	return nil
+}
+
+// resolves reports whether ref names a commit
+func (r *Repo) resolves(ref string) bool {
+	return exec.Command("git", "-C", r.path, "rev-parse", "--verify", "--quiet", ref+"^{commit}").Run() == nil
+}
 
-		// Handle renames (R100 old new)
-		if status == StatusRenamed && len(parts) >= 3 {
-			file.OldPath = parts[1]
-			file.Path = parts[2]
+// HasMergeBase reports whether base and head, which may be WorkTree or
+// Index, share history. Unrelated histories have no merge base; any other
+// failure of git merge-base is returned.
+func (r *Repo) HasMergeBase(base, head string) (bool, error) {
+	// Uncommitted changes sit on top of HEAD, so that's where they branch
+	if head == WorkTree || head == Index {
+		head = "HEAD"
+	}
+	// Output captures stderr, which tells "no merge base", where git says
+	// nothing, from other failures
+	_, err := exec.Command("git", "-C", r.path, "merge-base", base, head).Output()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && len(exitErr.Stderr) == 0:
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to find merge base of %s and %s: %w", base, head, commandError(err))
+}
+
+// shallow reports whether the repository is a shallow clone
+func (r *Repo) shallow() bool {
+	out, err := exec.Command("git", "-C", r.path, "rev-parse", "--is-shallow-repository").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// PathspecArgs converts include/exclude globs into git pathspec arguments.
+// Globs starting with "!" exclude matching paths; "**" matches across
+// directories.
+func PathspecArgs(globs []string) []string {
+	if len(globs) == 0 {
+		return nil
+	}
+	args := []string{"--"}
+	for _, glob := range globs {
+		if exclude, ok := strings.CutPrefix(glob, "!"); ok {
+			args = append(args, ":(exclude,glob)"+exclude)
+		} else {
+			args = append(args, ":(glob)"+glob)
 		}
+	}
+	return args
+}
+
+// CompareMode selects how head is compared with base
+type CompareMode int
 
-		files = append(files, file)
+const (
+	// CompareMergeBase diffs head against its merge base with base
+	// (base...head), showing only the changes made on head
+	CompareMergeBase CompareMode = iota
+	// CompareDirect diffs the two trees (base..head), which also shows
+	// changes made on base since head branched off, reversed
+	CompareDirect
+)
+
+// String returns the name used for the mode in flags and the header
+func (c CompareMode) String() string {
+	if c == CompareDirect {
+		return "direct"
 	}
+	return "merge-base"
+}
 
-	// Get stats for additions/deletions
-	cmd = exec.Command("git", "-C", r.path, "diff", "--numstat", base+"..."+head)
-	out, err = cmd.Output()
+// Range returns the revision range git diff takes for the mode
+func (c CompareMode) Range(base, head string) string {
+	if c == CompareDirect {
+		return base + ".." + head
+	}
+	return base + "..." + head
+}
+
+// Pseudo-revisions a diff can end at besides a commit. Ref names can't
+// contain colons, so they never shadow a branch.
+const (
+	WorkTree = ":worktree" // Uncommitted changes in the working tree
+	Index    = ":index"    // Changes staged in the index
+)
+
+// Args returns the revision arguments git diff takes to compare base with
+// head, which may be WorkTree or Index. Comparing the index with the
+// working tree takes none.
+func (c CompareMode) Args(base, head string) []string {
+	if base == Index && head == WorkTree {
+		return nil
+	}
+	if head != WorkTree && head != Index {
+		return []string{c.Range(base, head)}
+	}
+	var args []string
+	if head == Index {
+		args = append(args, "--cached")
+	}
+	if c == CompareMergeBase {
+		args = append(args, "--merge-base")
+	}
+	return append(args, base)
+}
+
+// HeadLabel returns how head is named in the header
+func HeadLabel(head string) string {
+	switch head {
+	case WorkTree:
+		return "working tree"
+	case Index:
+		return "index"
+	}
+	return head
+}
+
+// ParseCompareMode parses the name of a compare mode
+func ParseCompareMode(s string) (CompareMode, error) {
+	switch s {
+	case "merge-base":
+		return CompareMergeBase, nil
+	case "direct":
+		return CompareDirect, nil
+	}
+	return CompareMergeBase, fmt.Errorf("unknown compare mode %q: want merge-base or direct", s)
+}
+
+// GetChangedFiles returns a list of files that have changed between base and
+// head, optionally restricted by include/exclude globs
+func (r *Repo) GetChangedFiles(base, head string, mode CompareMode, globs ...string) ([]ChangedFile, error) {
+	pathspec := PathspecArgs(globs)
+
+	// Statuses and line counts in a single pass, NUL separated so paths
+	// with spaces or newlines survive
+	args := append([]string{"-C", r.path, "diff", "--raw", "--numstat", "-z"}, r.diffFlags()...)
+	cmd := exec.Command("git", append(append(args, mode.Args(base, head)...), pathspec...)...)
+	out, err := cmd.Output()
 	if err != nil {
-		cmd = exec.Command("git", "-C", r.path, "diff", "--numstat", base)
-		out, _ = cmd.Output()
-	}
-
-	statsMap := make(map[string][2]int)
-	scanner = bufio.NewScanner(bytes.NewReader(out))
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Fields(line)
-		if len(parts) >= 3 {
-			adds := 0
-			dels := 0
-			fmt.Sscanf(parts[0], "%d", &adds)
-			fmt.Sscanf(parts[1], "%d", &dels)
-			statsMap[parts[2]] = [2]int{adds, dels}
+		return nil, fmt.Errorf("failed to get changed files: %w", commandError(err))
+	}
+
+	files := parseRawNumstat(out)
+	r.countTextconv(base, head, mode, files)
+	if r.ignoreEOL {
+		// The raw records still list files whose only changes were ignored
+		kept := files[:0]
+		for _, f := range files {
+			if f.Status != StatusModified || f.Additions > 0 || f.Deletions > 0 || f.Binary || f.ModeChanged {
+				kept = append(kept, f)
+			}
 		}
+		files = kept
 	}
+	return files, nil
+}
 
-	for i := range files {
-		if stats, ok := statsMap[files[i].Path]; ok {
-			files[i].Additions = stats[0]
-			files[i].Deletions = stats[1]
+// commandError includes git's message in the error of a failed command
+func commandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if msg, _, _ := strings.Cut(strings.TrimSpace(string(exitErr.Stderr)), "\n"); msg != "" {
+			return errors.New(msg)
 		}
 	}
+	return err
+}
 
-	return files, nil
+// parseRawNumstat parses `git diff --raw --numstat -z` output: a raw record
+// per file followed by a numstat record per file
+func parseRawNumstat(out []byte) []ChangedFile {
+	fields := strings.Split(string(out), "\x00")
+
+	var files []ChangedFile
+	index := make(map[string]int)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if field == "" {
+			continue
+		}
+
+		// Raw record: ":old-mode new-mode old-sha new-sha status" then the
+		// path, or the old and new paths for renames and copies
+		if strings.HasPrefix(field, ":") {
+			meta := strings.Fields(field)
+			if len(meta) < 5 || i+1 >= len(fields) {
+				break
+			}
+			file := ChangedFile{Status: FileStatus(meta[4][0:1]), ModeChanged: meta[0][1:] != meta[1]}
+			i++
+			file.Path = fields[i]
+			if (file.Status == StatusRenamed || file.Status == StatusCopied) && i+1 < len(fields) {
+				file.OldPath = file.Path
+				i++
+				file.Path = fields[i]
+			}
+			index[file.Path] = len(files)
+			files = append(files, file)
+			continue
+		}
+
+		// Numstat record: "adds\tdels\tpath", or "adds\tdels\t" followed by
+		// the old and new paths for renames. Binary files show "-" counts.
+		parts := strings.SplitN(field, "\t", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		path := parts[2]
+		if path == "" && i+2 < len(fields) {
+			path = fields[i+2]
+			i += 2
+		}
+		if idx, ok := index[path]; ok {
+			files[idx].Binary = parts[0] == "-"
+			fmt.Sscanf(parts[0], "%d", &files[idx].Additions)
+			fmt.Sscanf(parts[1], "%d", &files[idx].Deletions)
+		}
+	}
+
+	return files
 }
 
-// GetFileDiff returns the diff for a specific file
-func (r *Repo) GetFileDiff(base, head, filePath string) (*FileDiff, error) {
-	cmd := exec.Command("git", "-C", r.path, "diff", base+"..."+head, "--", filePath)
+// GetFileDiff returns the diff for a specific file. Renamed files are
+// diffed against their old path, so the diff shows what changed rather than
+// the whole file as added.
+func (r *Repo) GetFileDiff(base, head string, mode CompareMode, file ChangedFile) (*FileDiff, error) {
+	return r.GetFileDiffContext(context.Background(), base, head, mode, file)
+}
+
+// GetFileDiffContext is GetFileDiff, killing git when ctx is done
+func (r *Repo) GetFileDiffContext(ctx context.Context, base, head string, mode CompareMode, file ChangedFile) (*FileDiff, error) {
+	paths := []string{file.Path}
+	if file.Status == StatusRenamed && file.OldPath != "" {
+		paths = append(paths, file.OldPath)
+	}
+	args := append([]string{"-C", r.path, "diff", "--textconv"}, r.diffFlags()...)
+	args = append(append(args, r.contextFlags()...), mode.Args(base, head)...)
+	args = append(args, "--")
+	cmd := exec.CommandContext(ctx, "git", append(args, paths...)...)
 	out, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	if err != nil {
-		// Try without three-dot notation
-		cmd = exec.Command("git", "-C", r.path, "diff", base, "--", filePath)
-		out, err = cmd.Output()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get diff for %s: %w", filePath, err)
-		}
+		return nil, fmt.Errorf("failed to get diff for %s: %w", file.Path, commandError(err))
 	}
 
-	return parseDiff(string(out))
+	diff, err := gitdiff.Parse(string(out))
+	if err != nil {
+		return nil, err
+	}
+	return r.decodeDiff(ctx, diff, base, head, mode, file)
 }
 
 // GetFileContent returns the content of a file at a specific ref
@@ -234,127 +502,23 @@ func (r *Repo) HasUncommittedChanges() (bool, error) {
 	return len(strings.TrimSpace(string(out))) > 0, nil
 }
 
-// parseDiff parses unified diff output into a FileDiff struct
-func parseDiff(diffText string) (*FileDiff, error) {
-	diff := &FileDiff{}
-	lines := strings.Split(diffText, "\n")
-
-	var currentHunk *DiffHunk
-	oldLineNum := 0
-	newLineNum := 0
-
-	for _, line := range lines {
-		if strings.HasPrefix(line, "---") {
-			parts := strings.SplitN(line, " ", 2)
-			if len(parts) > 1 {
-				diff.OldPath = strings.TrimPrefix(parts[1], "a/")
-			}
-			continue
-		}
-		if strings.HasPrefix(line, "+++") {
-			parts := strings.SplitN(line, " ", 2)
-			if len(parts) > 1 {
-				diff.NewPath = strings.TrimPrefix(parts[1], "b/")
-			}
-			continue
-		}
-		if strings.HasPrefix(line, "@@") {
-			// Parse hunk header: @@ -old,count +new,count @@
-			if currentHunk != nil {
-				diff.Hunks = append(diff.Hunks, *currentHunk)
-			}
-			currentHunk = &DiffHunk{}
-
-			// Parse the line numbers
-			var oldStart, oldCount, newStart, newCount int
-			fmt.Sscanf(line, "@@ -%d,%d +%d,%d @@", &oldStart, &oldCount, &newStart, &newCount)
-			if oldCount == 0 {
-				fmt.Sscanf(line, "@@ -%d +%d,%d @@", &oldStart, &newStart, &newCount)
-				oldCount = 1
-			}
-			if newCount == 0 {
-				fmt.Sscanf(line, "@@ -%d,%d +%d @@", &oldStart, &oldCount, &newStart)
-				newCount = 1
-			}
-
-			currentHunk.OldStart = oldStart
-			currentHunk.OldCount = oldCount
-			currentHunk.NewStart = newStart
-			currentHunk.NewCount = newCount
-
-			oldLineNum = oldStart
-			newLineNum = newStart
-
-			currentHunk.Lines = append(currentHunk.Lines, DiffLine{
-				Type:    DiffLineHeader,
-				Content: line,
-			})
-			continue
-		}
-		if currentHunk == nil {
-			continue
-		}
-
-		if len(line) == 0 {
-			// Empty context line
-			currentHunk.Lines = append(currentHunk.Lines, DiffLine{
-				Type:       DiffLineContext,
-				Content:    "",
-				OldLineNum: oldLineNum,
-				NewLineNum: newLineNum,
-			})
-			oldLineNum++
-			newLineNum++
-		} else if line[0] == '+' {
-			currentHunk.Lines = append(currentHunk.Lines, DiffLine{
-				Type:       DiffLineAddition,
-				Content:    line[1:],
-				NewLineNum: newLineNum,
-			})
-			newLineNum++
-		} else if line[0] == '-' {
-			currentHunk.Lines = append(currentHunk.Lines, DiffLine{
-				Type:       DiffLineDeletion,
-				Content:    line[1:],
-				OldLineNum: oldLineNum,
-			})
-			oldLineNum++
-		} else if line[0] == ' ' {
-			currentHunk.Lines = append(currentHunk.Lines, DiffLine{
-				Type:       DiffLineContext,
-				Content:    line[1:],
-				OldLineNum: oldLineNum,
-				NewLineNum: newLineNum,
-			})
-			oldLineNum++
-			newLineNum++
-		} else if line[0] == '\\' {
-			// "\ No newline at end of file" - skip
-			continue
-		}
-	}
-
-	if currentHunk != nil {
-		diff.Hunks = append(diff.Hunks, *currentHunk)
+// StagedCount returns the number of files with changes staged in the index
+func (r *Repo) StagedCount() (int, error) {
+	cmd := exec.Command("git", "-C", r.path, "diff", "--cached", "--name-only", "-z")
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, err
 	}
-
-	return diff, nil
+	return strings.Count(string(out), "\x00"), nil
 }
 
-// StatusString returns a human-readable status string
-func (s FileStatus) String() string {
-	switch s {
-	case StatusAdded:
-		return "added"
-	case StatusModified:
-		return "modified"
-	case StatusDeleted:
-		return "deleted"
-	case StatusRenamed:
-		return "renamed"
-	case StatusCopied:
-		return "copied"
-	default:
-		return "unknown"
+// UnstagedCount returns the number of tracked files whose working tree
+// changes aren't staged
+func (r *Repo) UnstagedCount() (int, error) {
+	cmd := exec.Command("git", "-C", r.path, "diff", "--name-only", "-z")
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, err
 	}
+	return strings.Count(string(out), "\x00"), nil
 }
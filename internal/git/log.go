@@ -0,0 +1,64 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// emptyTree is the hash of git's empty tree, the "parent" of a root commit
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// Commit is a commit in the range being reviewed
+type Commit struct {
+	SHA     string
+	Short   string
+	Subject string
+	Author  string
+	Date    string // Relative, e.g. "2 days ago"
+}
+
+// Commits lists the commits reachable from head but not from base, newest
+// first
+func (r *Repo) Commits(base, head string) ([]Commit, error) {
+	cmd := exec.Command("git", "-C", r.path, "log", "-z", "--format=%H%x1f%h%x1f%s%x1f%an%x1f%ar", base+".."+head)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", commandError(err))
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(out), "\x00") {
+		fields := strings.Split(strings.TrimPrefix(record, "\n"), "\x1f")
+		if len(fields) < 5 {
+			continue
+		}
+		commits = append(commits, Commit{
+			SHA:     fields[0],
+			Short:   fields[1],
+			Subject: fields[2],
+			Author:  fields[3],
+			Date:    fields[4],
+		})
+	}
+	return commits, nil
+}
+
+// Parent returns the first parent of commit, or the empty tree for a root
+// commit, so that Parent(c)..c is the change the commit made
+func (r *Repo) Parent(commit string) string {
+	cmd := exec.Command("git", "-C", r.path, "rev-parse", "--verify", "--quiet", commit+"^")
+	if err := cmd.Run(); err != nil {
+		return emptyTree
+	}
+	return commit + "^"
+}
+
+// CommitSHA returns the full hash of the commit rev names
+func (r *Repo) CommitSHA(rev string) (string, error) {
+	out, err := exec.Command("git", "-C", r.path, "rev-parse", "--verify", "--quiet", rev+"^{commit}").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not a commit", rev)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,35 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// MergeConflicts returns the files that would conflict if head were merged
+// into base. The merge is computed in memory with git merge-tree (git 2.38
+// or later), leaving the working tree and index alone. merge-tree still
+// writes the merged objects into the repository, so the check fails with
+// ErrReadOnly in read-only mode.
+func (r *Repo) MergeConflicts(base, head string) ([]string, error) {
+	if ReadOnly() {
+		return nil, ErrReadOnly
+	}
+	cmd := exec.Command("git", "-C", r.path, "merge-tree", "--write-tree", "--name-only", "--no-messages", "-z", base, head)
+	out, err := cmd.Output()
+	if err != nil {
+		// Exit status 1 means the merge has conflicts
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return nil, fmt.Errorf("failed to test merging %s into %s: %w", head, base, commandError(err))
+		}
+	}
+
+	// The merged tree's id, then the conflicted paths, each NUL terminated
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	if len(fields) < 2 {
+		return nil, nil
+	}
+	return fields[1:], nil
+}
@@ -0,0 +1,84 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/matthewmyrick/git-diffs/pkg/gitdiff"
+)
+
+// DiffDirs compares two directories outside of any repository using
+// `git diff --no-index`. It returns the changed files with paths relative to
+// the compared directories, and their parsed diffs keyed by that path.
+func DiffDirs(oldDir, newDir string) ([]ChangedFile, map[string]*FileDiff, error) {
+	oldAbs, err := filepath.Abs(oldDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	newAbs, err := filepath.Abs(newDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cmd := exec.Command("git", "diff", "--no-index", "--no-color", oldAbs, newAbs)
+	out, err := cmd.Output()
+	if err != nil {
+		// Exit status 1 just means the directories differ
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return nil, nil, fmt.Errorf("failed to compare %s and %s: %w", oldDir, newDir, err)
+		}
+	}
+
+	// git prints absolute paths without their leading slash after the a/ b/ prefixes
+	oldPrefix := "a/" + strings.TrimPrefix(filepath.ToSlash(oldAbs), "/") + "/"
+	newPrefix := "b/" + strings.TrimPrefix(filepath.ToSlash(newAbs), "/") + "/"
+
+	var files []ChangedFile
+	diffs := make(map[string]*FileDiff)
+	for _, chunk := range gitdiff.Split(string(out)) {
+		header, _, _ := strings.Cut(chunk, "\n")
+
+		file := ChangedFile{Status: StatusModified}
+		switch {
+		case strings.Contains(chunk, "\nnew file mode"):
+			file.Status = StatusAdded
+		case strings.Contains(chunk, "\ndeleted file mode"):
+			file.Status = StatusDeleted
+		}
+
+		if i := strings.Index(header, " "+newPrefix); i >= 0 && file.Status != StatusDeleted {
+			file.Path = header[i+1+len(newPrefix):]
+		} else if i := strings.Index(header, " "+oldPrefix); i >= 0 {
+			file.Path, _, _ = strings.Cut(header[i+1+len(oldPrefix):], " b/")
+		}
+		if file.Path == "" {
+			continue
+		}
+
+		diff, err := gitdiff.Parse(chunk)
+		if err != nil {
+			return nil, nil, err
+		}
+		diff.OldPath = file.Path
+		diff.NewPath = file.Path
+		for _, hunk := range diff.Hunks {
+			for _, line := range hunk.Lines {
+				switch line.Type {
+				case DiffLineAddition:
+					file.Additions++
+				case DiffLineDeletion:
+					file.Deletions++
+				}
+			}
+		}
+
+		files = append(files, file)
+		diffs[file.Path] = diff
+	}
+
+	return files, diffs, nil
+}
@@ -0,0 +1,25 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ApplyPatch applies patch to the working tree, or its reverse when reverse
+// is set. Nothing is applied unless every hunk applies.
+func (r *Repo) ApplyPatch(patch string, reverse bool) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
+	args := []string{"-C", r.root, "apply", "--recount"}
+	if reverse {
+		args = append(args, "--reverse")
+	}
+	cmd := exec.Command("git", append(args, "-")...)
+	cmd.Stdin = strings.NewReader(patch)
+	if _, err := cmd.Output(); err != nil {
+		return fmt.Errorf("failed to apply patch: %w", commandError(err))
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package git
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// WebURL returns the web address of the repository remote points at, e.g.
+// https://github.com/org/repo for git@github.com:org/repo.git
+func (r *Repo) WebURL(remote string) (string, error) {
+	out, err := exec.Command("git", "-C", r.path, "remote", "get-url", remote).Output()
+	if err != nil {
+		return "", fmt.Errorf("no remote named %s", remote)
+	}
+	return webURL(strings.TrimSpace(string(out)))
+}
+
+// webURL converts a clone URL in any of the https, ssh or scp-like forms to
+// the repository's https address
+func webURL(remote string) (string, error) {
+	host, path := "", ""
+	if u, err := url.Parse(remote); err == nil && u.Host != "" {
+		// https://host/org/repo.git, ssh://git@host:22/org/repo.git
+		host, path = u.Hostname(), u.Path
+	} else if at, rest, ok := strings.Cut(remote, ":"); ok && !strings.Contains(at, "/") {
+		// git@host:org/repo.git
+		_, host, _ = strings.Cut(at, "@")
+		if host == "" {
+			host = at
+		}
+		path = rest
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || path == "" {
+		return "", fmt.Errorf("%s is not a hosted repository", remote)
+	}
+	return "https://" + host + "/" + path, nil
+}
+
+// Permalink returns the address of line of the file at path in commit sha
+// on the forge at web. GitLab hosts use its /-/blob/ layout, others
+// GitHub's.
+func Permalink(web, sha, path string, line int) string {
+	blob := "/blob/"
+	if u, err := url.Parse(web); err == nil && strings.Contains(u.Hostname(), "gitlab") {
+		blob = "/-/blob/"
+	}
+	escaped := (&url.URL{Path: path}).EscapedPath()
+	return web + blob + sha + "/" + escaped + "#L" + strconv.Itoa(line)
+}
@@ -0,0 +1,90 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ShowCommit resolves rev to a commit
+func (r *Repo) ShowCommit(rev string) (Commit, error) {
+	cmd := exec.Command("git", "-C", r.path, "log", "-1", "--format=%H%x1f%h%x1f%s%x1f%an%x1f%ar", rev, "--")
+	out, err := cmd.Output()
+	if err != nil {
+		return Commit{}, fmt.Errorf("unknown commit %s: %w", rev, commandError(err))
+	}
+	fields := strings.Split(strings.TrimSpace(string(out)), "\x1f")
+	if len(fields) < 5 {
+		return Commit{}, fmt.Errorf("unknown commit %s", rev)
+	}
+	return Commit{SHA: fields[0], Short: fields[1], Subject: fields[2], Author: fields[3], Date: fields[4]}, nil
+}
+
+// CheckPick predicts which files would conflict if commit were
+// cherry-picked onto HEAD, mapped to git apply's reason. The check runs
+// against a scratch index built from HEAD, so neither the real index nor the
+// working tree is touched. It doesn't attempt a three-way merge, so a
+// flagged file may still merge cleanly.
+func (r *Repo) CheckPick(commit string) (map[string]string, error) {
+	patch, err := exec.Command("git", "-C", r.path, "diff", "--binary", r.Parent(commit), commit).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get patch for %s: %w", commit, commandError(err))
+	}
+
+	dir, err := os.MkdirTemp("", "git-diffs-pick-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	env := append(os.Environ(), "GIT_INDEX_FILE="+filepath.Join(dir, "index"))
+
+	readTree := exec.Command("git", "-C", r.path, "read-tree", "HEAD")
+	readTree.Env = env
+	if err := readTree.Run(); err != nil {
+		return nil, fmt.Errorf("failed to read HEAD: %w", commandError(err))
+	}
+
+	apply := exec.Command("git", "-C", r.path, "apply", "--check", "--cached", "-")
+	apply.Env = env
+	apply.Stdin = bytes.NewReader(patch)
+	out, err := apply.CombinedOutput()
+	if err == nil {
+		return nil, nil
+	}
+	if _, ok := err.(*exec.ExitError); !ok {
+		return nil, err
+	}
+	return parseApplyErrors(string(out)), nil
+}
+
+// parseApplyErrors maps the files in git apply's error output to the first
+// reason given for each, e.g. "patch failed at line 12" or "already exists"
+func parseApplyErrors(out string) map[string]string {
+	conflicts := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		msg, ok := strings.CutPrefix(line, "error: ")
+		if !ok {
+			continue
+		}
+
+		var path, reason string
+		if loc, ok := strings.CutPrefix(msg, "patch failed: "); ok {
+			i := strings.LastIndex(loc, ":")
+			if i < 0 {
+				continue
+			}
+			path, reason = loc[:i], "patch failed at line "+loc[i+1:]
+		} else if path, reason, ok = strings.Cut(msg, ": "); !ok {
+			continue
+		}
+		reason = strings.TrimSuffix(reason, " in index")
+
+		if _, seen := conflicts[path]; !seen {
+			conflicts[path] = reason
+		}
+	}
+	return conflicts
+}
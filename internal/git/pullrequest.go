@@ -0,0 +1,81 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// prTimeout bounds how long gh may take to look up a pull request, since it
+// asks the forge over the network
+const prTimeout = 5 * time.Second
+
+// PullRequest is the open pull request of a branch, as gh reports it
+type PullRequest struct {
+	Number  int    `json:"number"`
+	BaseRef string `json:"baseRefName"`
+}
+
+// pullRequests caches the lookups by worktree root and branch, so reloads
+// don't ask gh again
+var pullRequests = struct {
+	sync.Mutex
+	byBranch map[string]*PullRequest
+}{byBranch: make(map[string]*PullRequest)}
+
+// PullRequest returns the open pull request of the current branch, looked
+// up once per branch with gh pr view. It returns nil without gh, outside a
+// branch, or when the branch has no open pull request.
+func (r *Repo) PullRequest() *PullRequest {
+	branch, err := r.GetCurrentBranch()
+	if err != nil || branch == "HEAD" {
+		return nil
+	}
+	key := r.root + "\x00" + branch
+
+	pullRequests.Lock()
+	defer pullRequests.Unlock()
+	if pr, ok := pullRequests.byBranch[key]; ok {
+		return pr
+	}
+	pr := r.lookupPullRequest()
+	pullRequests.byBranch[key] = pr
+	return pr
+}
+
+func (r *Repo) lookupPullRequest() *PullRequest {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), prTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "gh", "pr", "view", "--json", "number,baseRefName,state")
+	cmd.Dir = r.path
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	var pr struct {
+		PullRequest
+		State string `json:"state"`
+	}
+	if err := json.Unmarshal(out, &pr); err != nil || pr.BaseRef == "" || !strings.EqualFold(pr.State, "open") {
+		return nil
+	}
+	return &pr.PullRequest
+}
+
+// pullRequestBase returns the ref to compare the current branch's pull
+// request against: its target branch as last fetched from origin, or the
+// local branch of that name
+func (r *Repo) pullRequestBase(pr *PullRequest) (string, bool) {
+	for _, ref := range []string{"origin/" + pr.BaseRef, pr.BaseRef} {
+		if r.resolves(ref) {
+			return ref, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,107 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// RangeDiffPair pairs the old and new version of a commit in a range-diff
+type RangeDiffPair struct {
+	OldIndex string // "-" when the commit only exists in the new range
+	OldSHA   string
+	Relation byte   // '=' unchanged, '!' changed, '<' dropped, '>' added
+	NewIndex string // "-" when the commit only exists in the old range
+	NewSHA   string
+	Subject  string
+	Diff     *FileDiff // Diff between the two versions of the patch
+}
+
+// rangeDiffHeader matches pair lines such as "1:  a1b2c3d ! 1:  d4e5f6a Subject"
+var rangeDiffHeader = regexp.MustCompile(`^\s*(-|\d+):\s+(-+|[0-9a-f]+) ([=!<>]) \s*(-|\d+):\s+(-+|[0-9a-f]+) ?(.*)$`)
+
+// RangeDiff runs git range-diff between two commit ranges
+func (r *Repo) RangeDiff(oldRange, newRange string) ([]RangeDiffPair, error) {
+	cmd := exec.Command("git", "-C", r.path, "range-diff", "--no-color", oldRange, newRange)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run range-diff: %w", err)
+	}
+	return parseRangeDiff(string(out)), nil
+}
+
+// parseRangeDiff parses range-diff output. The diff-of-diffs under each pair
+// is indented by four spaces and is turned into a FileDiff whose hunks are the
+// "@@" sections of the inner diff.
+func parseRangeDiff(text string) []RangeDiffPair {
+	var pairs []RangeDiffPair
+	var current *RangeDiffPair
+	var hunk *DiffHunk
+	oldLineNum, newLineNum := 0, 0
+
+	flushHunk := func() {
+		if current != nil && hunk != nil {
+			current.Diff.Hunks = append(current.Diff.Hunks, *hunk)
+		}
+		hunk = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if match := rangeDiffHeader.FindStringSubmatch(line); match != nil {
+			flushHunk()
+			pairs = append(pairs, RangeDiffPair{
+				OldIndex: match[1],
+				OldSHA:   match[2],
+				Relation: match[3][0],
+				NewIndex: match[4],
+				NewSHA:   match[5],
+				Subject:  match[6],
+				Diff:     &FileDiff{},
+			})
+			current = &pairs[len(pairs)-1]
+			oldLineNum, newLineNum = 1, 1
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		inner, ok := strings.CutPrefix(line, "    ")
+		if !ok || inner == "" {
+			continue
+		}
+
+		// Section headers ("@@ Metadata", "## path ##") start a new hunk
+		if strings.HasPrefix(inner, "@@") || strings.HasPrefix(inner, "##") {
+			flushHunk()
+			hunk = &DiffHunk{OldStart: oldLineNum, NewStart: newLineNum}
+			hunk.Lines = append(hunk.Lines, DiffLine{Type: DiffLineHeader, Content: inner})
+			continue
+		}
+		if hunk == nil {
+			hunk = &DiffHunk{OldStart: oldLineNum, NewStart: newLineNum}
+		}
+
+		content := inner[1:]
+		switch inner[0] {
+		case '+':
+			hunk.Lines = append(hunk.Lines, DiffLine{Type: DiffLineAddition, Content: content, NewLineNum: newLineNum})
+			hunk.NewCount++
+			newLineNum++
+		case '-':
+			hunk.Lines = append(hunk.Lines, DiffLine{Type: DiffLineDeletion, Content: content, OldLineNum: oldLineNum})
+			hunk.OldCount++
+			oldLineNum++
+		default:
+			hunk.Lines = append(hunk.Lines, DiffLine{Type: DiffLineContext, Content: content, OldLineNum: oldLineNum, NewLineNum: newLineNum})
+			hunk.OldCount++
+			hunk.NewCount++
+			oldLineNum++
+			newLineNum++
+		}
+	}
+	flushHunk()
+
+	return pairs
+}
@@ -0,0 +1,23 @@
+package git
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrReadOnly is returned by operations that would change the repository
+// while read-only mode is on
+var ErrReadOnly = errors.New("read-only mode: the repository can't be changed")
+
+var readOnly atomic.Bool
+
+// SetReadOnly turns read-only mode on or off. While it is on, fetching,
+// applying patches and test merges fail with ErrReadOnly.
+func SetReadOnly(on bool) {
+	readOnly.Store(on)
+}
+
+// ReadOnly reports whether read-only mode is on
+func ReadOnly() bool {
+	return readOnly.Load()
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// secretPatterns match lines that look like they contain a credential
+var secretPatterns = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{"private key", regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret key", regexp.MustCompile(`(?i)aws.{0,20}(secret|key).{0,20}['"][0-9a-zA-Z/+]{40}['"]`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"Stripe key", regexp.MustCompile(`\b[sr]k_live_[0-9a-zA-Z]{20,}\b`)},
+	{"token", regexp.MustCompile(`(?i)\b(api[_-]?key|secret|token|passw(or)?d)\b['"]?\s*[:=]\s*['"][^'"\s]{8,}['"]`)},
+}
+
+// SecretKind returns the kind of credential line looks like it contains,
+// or "" if none
+func SecretKind(line string) string {
+	for _, p := range secretPatterns {
+		if p.re.MatchString(line) {
+			return p.kind
+		}
+	}
+	return ""
+}
+
+// CountSecrets counts the added lines of paths between base and head that
+// look like credentials, keyed by path. Files without any are omitted.
+func (r *Repo) CountSecrets(base, head string, mode CompareMode, paths []string) (map[string]int, error) {
+	counts := make(map[string]int)
+	for len(paths) > 0 {
+		// Same bound as the conflict search
+		chunk := paths[:min(len(paths), conflictChunk)]
+		paths = paths[len(chunk):]
+
+		// Fixed prefixes, whatever diff.noprefix or diff.mnemonicPrefix say
+		args := append([]string{"-C", r.path, "diff", "-U0", "--src-prefix=a/", "--dst-prefix=b/"}, r.diffFlags()...)
+		args = append(append(args, mode.Args(base, head)...), "--")
+		cmd := exec.Command("git", append(args, chunk...)...)
+		out, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, fmt.Errorf("failed to scan for secrets: %w", err)
+		}
+		scanAddedLines(out, func(path, line string) {
+			if SecretKind(line) != "" {
+				counts[path]++
+			}
+		})
+		if err := cmd.Wait(); err != nil {
+			return nil, fmt.Errorf("failed to scan for secrets: %w", commandError(err))
+		}
+	}
+	return counts, nil
+}
+
+// scanAddedLines calls fn with the path and content of every added line of
+// a patch
+func scanAddedLines(patch io.Reader, fn func(path, line string)) {
+	scanner := bufio.NewScanner(patch)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	path, inHeader := "", false
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			path, inHeader = "", true
+		case inHeader && strings.HasPrefix(line, "+++ "):
+			path = headerPath(strings.TrimPrefix(line, "+++ "))
+		case strings.HasPrefix(line, "@@"):
+			inHeader = false
+		case !inHeader && path != "" && strings.HasPrefix(line, "+"):
+			fn(path, line[1:])
+		}
+	}
+}
+
+// headerPath returns the path of a +++ header, which git quotes when it has
+// unusual characters, without its b/ prefix
+func headerPath(header string) string {
+	if unquoted, err := strconv.Unquote(header); err == nil {
+		header = unquoted
+	}
+	if header == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(header, "b/")
+}
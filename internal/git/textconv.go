@@ -0,0 +1,82 @@
+package git
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// diffFlags returns the options every diff of the repo is run with. External
+// diff tools and color are turned off so the output can be parsed; textconv
+// drivers from .gitattributes still apply.
+func (r *Repo) diffFlags() []string {
+	flags := []string{"--no-ext-diff", "--no-color"}
+	if r.ignoreEOL {
+		flags = append(flags, "--ignore-cr-at-eol")
+	}
+	return flags
+}
+
+// countTextconv fills in the line counts of binary files that a textconv
+// driver turns into text. numstat counts them as binary, while their diffs
+// show the converted text.
+func (r *Repo) countTextconv(base, head string, mode CompareMode, files []ChangedFile) {
+	var paths []string
+	for _, f := range files {
+		if f.Binary {
+			paths = append(paths, f.Path)
+		}
+	}
+	converted := r.textconvFiles(paths)
+	if len(converted) == 0 {
+		return
+	}
+
+	for i := range files {
+		if !files[i].Binary || !converted[files[i].Path] {
+			continue
+		}
+		diff, err := r.GetFileDiff(base, head, mode, files[i])
+		if err != nil || diff.Binary {
+			continue
+		}
+		files[i].Binary = false
+		files[i].Additions, files[i].Deletions = diff.LineCounts()
+	}
+}
+
+// textconvFiles reports which of paths have a diff driver with a textconv
+// command configured
+func (r *Repo) textconvFiles(paths []string) map[string]bool {
+	converted := make(map[string]bool)
+	if len(paths) == 0 {
+		return converted
+	}
+
+	cmd := exec.Command("git", "-C", r.root, "check-attr", "-z", "--stdin", "diff")
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	out, err := cmd.Output()
+	if err != nil {
+		return converted
+	}
+
+	// Records are "path\x00attribute\x00value\x00"; a driver name is any
+	// value other than the set, unset and unspecified states
+	drivers := make(map[string]bool)
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		driver := fields[i+2]
+		switch driver {
+		case "set", "unset", "unspecified":
+			continue
+		}
+		ok, seen := drivers[driver]
+		if !seen {
+			ok = exec.Command("git", "-C", r.root, "config", "--get", "diff."+driver+".textconv").Run() == nil
+			drivers[driver] = ok
+		}
+		if ok {
+			converted[fields[i]] = true
+		}
+	}
+	return converted
+}
@@ -0,0 +1,67 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// UntrackedFiles lists the files of the working tree git doesn't track and
+// doesn't ignore, as untracked ChangedFiles counting their lines as added
+func (r *Repo) UntrackedFiles(globs ...string) ([]ChangedFile, error) {
+	args := []string{"-C", r.root, "ls-files", "--others", "--exclude-standard", "-z"}
+	out, err := exec.Command("git", append(args, PathspecArgs(globs)...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", commandError(err))
+	}
+	var files []ChangedFile
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path == "" {
+			continue
+		}
+		file := ChangedFile{Status: StatusUnknown, Path: path}
+		if content, err := os.ReadFile(filepath.Join(r.root, path)); err == nil {
+			file.Binary = bytes.IndexByte(content, 0) >= 0
+			if !file.Binary && len(content) > 0 {
+				file.Additions = bytes.Count(content, []byte("\n"))
+				if content[len(content)-1] != '\n' {
+					file.Additions++
+				}
+			}
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// UntrackedDiff returns the diff adding an untracked file in full
+func (r *Repo) UntrackedDiff(ctx context.Context, path string) (*FileDiff, error) {
+	content, err := os.ReadFile(filepath.Join(r.root, path))
+	if err != nil {
+		return nil, err
+	}
+	diff, err := r.diffContent(ctx, nil, content)
+	if err != nil {
+		return nil, err
+	}
+	diff.OldPath, diff.NewPath = "/dev/null", path
+	return diff, nil
+}
+
+// IntentToAdd records paths in the index without their content, like git
+// add -N, so their lines show as unstaged additions that can be staged or
+// reverted like any other change
+func (r *Repo) IntentToAdd(paths ...string) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
+	args := append([]string{"-C", r.root, "add", "--intent-to-add", "--"}, paths...)
+	if _, err := exec.Command("git", args...).Output(); err != nil {
+		return fmt.Errorf("git add -N: %w", commandError(err))
+	}
+	return nil
+}
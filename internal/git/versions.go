@@ -0,0 +1,62 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// FileVersions returns the contents of a changed file at both ends of the
+// compared range, following renames. A side where the file doesn't exist is
+// nil.
+func (r *Repo) FileVersions(base, head string, mode CompareMode, file ChangedFile) (before, after []byte, err error) {
+	from := base
+	if mode == CompareMergeBase && base != Index {
+		// Uncommitted changes sit on top of HEAD, so that's where they branch
+		tip := head
+		if head == WorkTree || head == Index {
+			tip = "HEAD"
+		}
+		out, err := exec.Command("git", "-C", r.path, "merge-base", base, tip).Output()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find merge base of %s and %s: %w", base, tip, commandError(err))
+		}
+		from = strings.TrimSpace(string(out))
+	}
+
+	oldPath := file.Path
+	if file.OldPath != "" {
+		oldPath = file.OldPath
+	}
+	if from == Index {
+		from = "" // "git show :path" reads the index
+	}
+	if file.Status != StatusAdded {
+		content, err := r.GetFileContent(from, oldPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		before = []byte(content)
+	}
+	switch {
+	case file.Status == StatusDeleted:
+	case head == WorkTree:
+		if after, err = os.ReadFile(filepath.Join(r.Root(), file.Path)); err != nil {
+			return nil, nil, err
+		}
+	default:
+		// "git show :path" reads the index
+		rev := head
+		if head == Index {
+			rev = ""
+		}
+		content, err := r.GetFileContent(rev, file.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		after = []byte(content)
+	}
+	return before, after, nil
+}
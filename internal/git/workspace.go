@@ -0,0 +1,64 @@
+package git
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// discoverDepth is how many directory levels DiscoverRepos descends
+const discoverDepth = 4
+
+// skipDirs are never searched for repositories
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// DiscoverRepos finds the git repositories at or below root, including
+// submodules and other nested repositories, which are marked by a .git
+// directory or file. Hidden directories are skipped.
+func DiscoverRepos(root string) ([]string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped rather than failing the walk
+			if d != nil && d.IsDir() && path != root {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		if path != root {
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || skipDirs[name] {
+				return fs.SkipDir
+			}
+			rel, _ := filepath.Rel(root, path)
+			if strings.Count(rel, string(filepath.Separator)) >= discoverDepth {
+				return fs.SkipDir
+			}
+		}
+
+		if _, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+			repos = append(repos, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(repos)
+	return repos, nil
+}
@@ -0,0 +1,60 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Worktree is a working tree attached to the repository
+type Worktree struct {
+	Path     string
+	Head     string // Commit checked out
+	Branch   string // Empty when HEAD is detached
+	Bare     bool
+	Current  bool // The worktree the repo was opened in
+	Prunable bool // The directory no longer exists
+}
+
+// Worktrees lists the main working tree and any linked ones. Linked
+// worktrees have a .git file pointing into the common git directory, which
+// git resolves for us.
+func (r *Repo) Worktrees() ([]Worktree, error) {
+	cmd := exec.Command("git", "-C", r.path, "worktree", "list", "--porcelain")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	return parseWorktrees(string(out), r.root), nil
+}
+
+// parseWorktrees parses `git worktree list --porcelain`: one block of
+// "key value" lines per worktree, separated by blank lines
+func parseWorktrees(out, current string) []Worktree {
+	var worktrees []Worktree
+	for _, block := range strings.Split(out, "\n\n") {
+		var wt Worktree
+		for _, line := range strings.Split(block, "\n") {
+			key, value, _ := strings.Cut(line, " ")
+			switch key {
+			case "worktree":
+				wt.Path = value
+			case "HEAD":
+				wt.Head = value
+			case "branch":
+				wt.Branch = strings.TrimPrefix(value, "refs/heads/")
+			case "bare":
+				wt.Bare = true
+			case "prunable":
+				wt.Prunable = true
+			}
+		}
+		if wt.Path == "" {
+			continue
+		}
+		wt.Current = filepath.Clean(wt.Path) == filepath.Clean(current)
+		worktrees = append(worktrees, wt)
+	}
+	return worktrees
+}
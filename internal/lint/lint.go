@@ -0,0 +1,157 @@
+// Package lint runs a configured linter over the changed files and maps its
+// diagnostics to file lines.
+//
+// Two output formats are understood: golangci-lint's JSON report and the
+// "path:line[:column]: message" lines most compilers and linters print.
+package lint
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Diagnostics holds linter messages by path, relative to the repository
+// root, and line
+type Diagnostics map[string]map[int][]string
+
+// Count returns the number of messages
+func (d Diagnostics) Count() int {
+	n := 0
+	for _, lines := range d {
+		for _, msgs := range lines {
+			n += len(msgs)
+		}
+	}
+	return n
+}
+
+// add records msg for line of path, given as the linter printed it
+func (d Diagnostics) add(root, path string, line int, msg string) {
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return
+		}
+		path = rel
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+	if d[path] == nil {
+		d[path] = make(map[int][]string)
+	}
+	d[path][line] = append(d[path][line], msg)
+}
+
+// Run runs command in root, with {files} replaced by the paths of files,
+// and collects its diagnostics. Linters exit non-zero when they report
+// problems, so that only counts as failure when nothing could be parsed.
+func Run(command, root string, files []string) (Diagnostics, error) {
+	args := commandArgs(command, files)
+	if len(args) == 0 {
+		return nil, errors.New("no lint command configured")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+
+	diags := parse(root, out)
+	if err != nil && len(diags) == 0 {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, err
+		}
+		// Some linters report on stderr
+		if diags = parse(root, stderr.Bytes()); len(diags) == 0 && exitErr.ExitCode() != 1 {
+			msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n")
+			return nil, fmt.Errorf("%s: %s", args[0], msg)
+		}
+	}
+	return diags, nil
+}
+
+// commandArgs splits command into arguments, replacing a {files} argument
+// with files
+func commandArgs(command string, files []string) []string {
+	var args []string
+	for _, arg := range strings.Fields(command) {
+		if arg == "{files}" {
+			args = append(args, files...)
+		} else {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// parse reads linter output in either understood format
+func parse(root string, out []byte) Diagnostics {
+	if diags, ok := parseGolangci(root, out); ok {
+		return diags
+	}
+	return parseLines(root, out)
+}
+
+// golangciReport is the part of golangci-lint's JSON output we use
+type golangciReport struct {
+	Issues []struct {
+		FromLinter string
+		Text       string
+		Pos        struct {
+			Filename string
+			Line     int
+		}
+	}
+}
+
+// parseGolangci parses golangci-lint's JSON report, which may be followed
+// by a text summary
+func parseGolangci(root string, out []byte) (Diagnostics, bool) {
+	trimmed := bytes.TrimSpace(out)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, false
+	}
+	var report golangciReport
+	if err := json.NewDecoder(bytes.NewReader(trimmed)).Decode(&report); err != nil {
+		return nil, false
+	}
+	diags := make(Diagnostics)
+	for _, issue := range report.Issues {
+		msg := issue.Text
+		if issue.FromLinter != "" {
+			msg += " (" + issue.FromLinter + ")"
+		}
+		diags.add(root, issue.Pos.Filename, issue.Pos.Line, msg)
+	}
+	return diags, true
+}
+
+// diagnosticLine matches "path:line: message" and "path:line:column:
+// message"
+var diagnosticLine = regexp.MustCompile(`^(.+?):(\d+)(?::\d+)?:\s*(.+)$`)
+
+// parseLines parses one diagnostic per line, skipping other output
+func parseLines(root string, out []byte) Diagnostics {
+	diags := make(Diagnostics)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		m := diagnosticLine.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		line, err := strconv.Atoi(m[2])
+		if err != nil || line < 1 {
+			continue
+		}
+		diags.add(root, m[1], line, m[3])
+	}
+	return diags
+}
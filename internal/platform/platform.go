@@ -0,0 +1,81 @@
+// Package platform wraps the desktop integrations that differ between
+// operating systems: the clipboard and the file manager.
+package platform
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// clipboardCommands returns the commands that can write stdin to the system
+// clipboard, in order of preference
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	}
+
+	var cmds [][]string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append(cmds, []string{"wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		cmds = append(cmds,
+			[]string{"xclip", "-selection", "clipboard"},
+			[]string{"xsel", "--clipboard", "--input"},
+		)
+	}
+	return cmds
+}
+
+// CopyToClipboard puts text on the clipboard. Over ssh, or when no clipboard
+// tool is available, it falls back to the OSC 52 escape sequence, which
+// most terminals forward to the local clipboard.
+func CopyToClipboard(text string) error {
+	if os.Getenv("SSH_TTY") == "" && os.Getenv("SSH_CONNECTION") == "" {
+		for _, args := range clipboardCommands() {
+			if _, err := exec.LookPath(args[0]); err != nil {
+				continue
+			}
+			cmd := exec.Command(args[0], args[1:]...)
+			cmd.Stdin = strings.NewReader(text)
+			if err := cmd.Run(); err == nil {
+				return nil
+			}
+		}
+	}
+
+	termenv.Copy(text)
+	return nil
+}
+
+// Open opens path with the platform's default application, which for a
+// directory is the file manager
+func Open(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("explorer", path)
+	default:
+		if _, err := exec.LookPath("xdg-open"); err != nil {
+			return errors.New("xdg-open not found")
+		}
+		cmd = exec.Command("xdg-open", path)
+	}
+
+	// Don't wait: some openers only return once the application exits
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,187 @@
+// Package script drives the TUI with a scripted sequence of key events,
+// capturing the rendered frames without attaching to a terminal.
+package script
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Step is a single script instruction: either a key press or a pause
+type Step struct {
+	Line int
+	Key  tea.KeyMsg
+	Wait time.Duration
+	Text string // The step as written in the script
+}
+
+// Options configures a script run
+type Options struct {
+	Width  int
+	Height int
+	// Startup is how long to wait for the initial load before the first key
+	Startup time.Duration
+	// Settle is how long to wait after each key for async commands to finish
+	Settle time.Duration
+	// ANSI keeps the escape codes of colors and styles in frames, which are
+	// plain text otherwise
+	ANSI bool
+	// OnFrame, if set, is called with the frame rendered after each key step
+	OnFrame func(step int, s Step, frame string) error
+}
+
+// DefaultOptions returns options suitable for most scripts
+func DefaultOptions() Options {
+	return Options{
+		Width:   120,
+		Height:  40,
+		Startup: 500 * time.Millisecond,
+		Settle:  100 * time.Millisecond,
+	}
+}
+
+// keyTypes maps key names (as reported by tea.KeyMsg.String) to key types
+var keyTypes = func() map[string]tea.KeyType {
+	names := make(map[string]tea.KeyType)
+	for k := tea.KeyF20; k <= tea.KeyBackspace; k++ {
+		if name := k.String(); name != "" && k != tea.KeyRunes && k != tea.KeySpace {
+			names[name] = k
+		}
+	}
+	names["space"] = tea.KeySpace
+	return names
+}()
+
+// ParseFile reads a script from a file
+func ParseFile(path string) ([]Step, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads a script: one key per line, named as bubbletea reports them
+// (e.g. "j", "enter", "ctrl+d", "alt+x", "space"). Blank lines and lines
+// starting with # are ignored, and "wait <duration>" pauses the script.
+func Parse(r io.Reader) ([]Step, error) {
+	var steps []Step
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(text, "wait "); ok {
+			d, err := time.ParseDuration(strings.TrimSpace(rest))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid wait duration: %w", lineNum, err)
+			}
+			steps = append(steps, Step{Line: lineNum, Wait: d, Text: text})
+			continue
+		}
+
+		k, err := ParseKey(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		steps = append(steps, Step{Line: lineNum, Key: k, Text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// ParseKey converts a key name into a key message
+func ParseKey(name string) (tea.KeyMsg, error) {
+	alt := false
+	if rest, ok := strings.CutPrefix(name, "alt+"); ok && rest != "" {
+		alt = true
+		name = rest
+	}
+
+	if t, ok := keyTypes[name]; ok {
+		return tea.KeyMsg{Type: t, Alt: alt}, nil
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name), Alt: alt}, nil
+	}
+	return tea.KeyMsg{}, fmt.Errorf("unknown key %q", name)
+}
+
+// snapshotMsg asks the runner to capture the current frame
+type snapshotMsg struct {
+	step int
+}
+
+// Run feeds the steps to the model and returns the final rendered frame
+func Run(model tea.Model, steps []Step, opts Options) (string, error) {
+	var frameErr error
+
+	filter := func(m tea.Model, msg tea.Msg) tea.Msg {
+		snap, ok := msg.(snapshotMsg)
+		if !ok {
+			return msg
+		}
+		if opts.OnFrame != nil && frameErr == nil {
+			frameErr = opts.OnFrame(snap.step, steps[snap.step], opts.frame(m))
+		}
+		return nil
+	}
+
+	// An empty reader rather than no input, so commands that hand the
+	// terminal to another program can restore it afterwards
+	p := tea.NewProgram(model,
+		tea.WithInput(strings.NewReader("")),
+		tea.WithOutput(io.Discard),
+		tea.WithoutRenderer(),
+		tea.WithoutSignalHandler(),
+		tea.WithFilter(filter),
+	)
+
+	go func() {
+		p.Send(tea.WindowSizeMsg{Width: opts.Width, Height: opts.Height})
+		time.Sleep(opts.Startup)
+
+		for i, step := range steps {
+			if step.Wait > 0 {
+				time.Sleep(step.Wait)
+				continue
+			}
+			p.Send(step.Key)
+			time.Sleep(opts.Settle)
+			p.Send(snapshotMsg{step: i})
+		}
+		p.Quit()
+	}()
+
+	final, err := p.Run()
+	if err != nil {
+		return "", err
+	}
+	if frameErr != nil {
+		return "", frameErr
+	}
+	return opts.frame(final), nil
+}
+
+// frame renders m, without escape codes unless ANSI is set
+func (opts Options) frame(m tea.Model) string {
+	if opts.ANSI {
+		return m.View()
+	}
+	return ansi.Strip(m.View())
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// profile is the color profile styles are rendered with
+var profile = termenv.TrueColor
+
+// SetColorMode selects the color profile. "auto" detects it from the
+// terminal and honors $NO_COLOR. Without color, selections are shown in
+// reverse video instead of with a background.
+func SetColorMode(mode string) error {
+	p, err := ParseColorMode(mode)
+	if err != nil {
+		return err
+	}
+	setProfile(p)
+	return nil
+}
+
+// ParseColorMode returns the color profile a mode names, detecting it for
+// "auto" like SetColorMode
+func ParseColorMode(mode string) (termenv.Profile, error) {
+	switch mode {
+	case "", "auto":
+		return lipgloss.ColorProfile(), nil
+	case "truecolor":
+		return termenv.TrueColor, nil
+	case "256":
+		return termenv.ANSI256, nil
+	case "16":
+		return termenv.ANSI, nil
+	case "none":
+		return termenv.Ascii, nil
+	}
+	return termenv.Ascii, fmt.Errorf("unknown color mode %q", mode)
+}
+
+// WithColorProfile renders with p while f runs and then restores the
+// previous profile, so a component mounted in another program can have
+// its own colors without changing the host's
+func WithColorProfile(p termenv.Profile, f func()) {
+	prev, prevLipgloss := profile, lipgloss.ColorProfile()
+	setProfile(p)
+	defer func() {
+		setProfile(prev)
+		lipgloss.SetColorProfile(prevLipgloss)
+	}()
+	f()
+}
+
+func setProfile(p termenv.Profile) {
+	profile = p
+	lipgloss.SetColorProfile(p)
+
+	reverse := p == termenv.Ascii
+	for _, style := range []*lipgloss.Style{
+		&FileItemSelectedStyle, &SearchResultSelectedStyle, &SelectedLineStyle, &PreviewFocusStyle,
+		&SecretBadgeStyle, &SecretBannerStyle, &ToastStyle,
+	} {
+		*style = style.Reverse(reverse)
+	}
+}
+
+// HasTints reports whether the terminal can show the subtle background
+// tints that mark added and deleted lines. Otherwise views fall back to
+// +/- markers.
+func HasTints() bool {
+	return profile == termenv.TrueColor || profile == termenv.ANSI256
+}
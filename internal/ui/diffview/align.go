@@ -0,0 +1,145 @@
+package diffview
+
+import (
+	"strings"
+
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// maxAlignCells bounds the similarity table built for a change block.
+// Larger blocks pair their lines by position.
+const maxAlignCells = 10000
+
+// maxAlignBytes bounds the content of a change block that is aligned, since
+// every line is compared with every line of the other side, so a few very
+// long lines such as minified code cost as much as many short ones
+const maxAlignBytes = 64 << 10
+
+// minSimilarity is how alike two lines must be to be shown side by side
+const minSimilarity = 0.5
+
+// align pairs the deleted and added lines of a change block by similarity,
+// keeping their order. Each returned row holds the indexes of a deletion and
+// an addition, or -1 for a gap on that side. It returns nil when the lines
+// should simply be paired by position: when one side is empty, the block is
+// too large, or no lines are alike.
+func align(dels, adds []git.DiffLine) [][2]int {
+	if len(dels) == 0 || len(adds) == 0 || len(dels)*len(adds) > maxAlignCells {
+		return nil
+	}
+	size := 0
+	for _, lines := range [][]git.DiffLine{dels, adds} {
+		for _, line := range lines {
+			size += len(line.Content)
+		}
+	}
+	if size > maxAlignBytes {
+		return nil
+	}
+
+	delGrams := make([]map[string]int, len(dels))
+	for i, line := range dels {
+		delGrams[i] = bigrams(line.Content)
+	}
+	addGrams := make([]map[string]int, len(adds))
+	for j, line := range adds {
+		addGrams[j] = bigrams(line.Content)
+	}
+
+	// score[i][j] is the best total similarity pairing dels[i:] with adds[j:]
+	score := make([][]float64, len(dels)+1)
+	for i := range score {
+		score[i] = make([]float64, len(adds)+1)
+	}
+	sim := make([][]float64, len(dels))
+	for i := len(dels) - 1; i >= 0; i-- {
+		sim[i] = make([]float64, len(adds))
+		for j := len(adds) - 1; j >= 0; j-- {
+			sim[i][j] = similarity(dels[i].Content, adds[j].Content, delGrams[i], addGrams[j])
+			best := max(score[i+1][j], score[i][j+1])
+			if sim[i][j] >= minSimilarity {
+				best = max(best, score[i+1][j+1]+sim[i][j])
+			}
+			score[i][j] = best
+		}
+	}
+	if score[0][0] == 0 {
+		return nil
+	}
+
+	var rows [][2]int
+	i, j := 0, 0
+	for i < len(dels) || j < len(adds) {
+		switch {
+		case i == len(dels):
+			rows = append(rows, [2]int{-1, j})
+			j++
+		case j == len(adds):
+			rows = append(rows, [2]int{i, -1})
+			i++
+		case sim[i][j] >= minSimilarity && score[i][j] == score[i+1][j+1]+sim[i][j]:
+			rows = append(rows, [2]int{i, j})
+			i++
+			j++
+		case score[i][j] == score[i+1][j]:
+			rows = append(rows, [2]int{i, -1})
+			i++
+		default:
+			rows = append(rows, [2]int{-1, j})
+			j++
+		}
+	}
+	return rows
+}
+
+// alignedRows is align with the pairing by position filled in
+func alignedRows(dels, adds []git.DiffLine) [][2]int {
+	if rows := align(dels, adds); rows != nil {
+		return rows
+	}
+	rows := make([][2]int, max(len(dels), len(adds)))
+	for k := range rows {
+		rows[k] = [2]int{-1, -1}
+		if k < len(dels) {
+			rows[k][0] = k
+		}
+		if k < len(adds) {
+			rows[k][1] = k
+		}
+	}
+	return rows
+}
+
+// bigrams counts the pairs of adjacent characters in a line, ignoring its
+// indentation
+func bigrams(s string) map[string]int {
+	r := []rune(strings.TrimSpace(s))
+	grams := make(map[string]int, len(r))
+	for i := 0; i+1 < len(r); i++ {
+		grams[string(r[i:i+2])]++
+	}
+	return grams
+}
+
+// similarity is the Dice coefficient of two lines' bigrams, from 0 for
+// nothing in common to 1 for lines equal but for indentation
+func similarity(a, b string, ga, gb map[string]int) float64 {
+	if strings.TrimSpace(a) == strings.TrimSpace(b) {
+		return 1
+	}
+	total := 0
+	for _, n := range ga {
+		total += n
+	}
+	for _, n := range gb {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	common := 0
+	for g, n := range ga {
+		common += min(n, gb[g])
+	}
+	return 2 * float64(common) / float64(total)
+}
@@ -2,8 +2,12 @@ package diffview
 
 import (
 	"fmt"
+	"maps"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/lexers"
@@ -13,6 +17,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/matthewmyrick/git-diffs/internal/git"
 	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
 )
 
 // ViewMode represents the diff view mode
@@ -34,11 +39,16 @@ type SideBySideLine struct {
 	NewType    git.DiffLineType
 }
 
+// defaultStyle resolves the chroma style on first use rather than at startup
+var defaultStyle = sync.OnceValue(func() *chroma.Style {
+	return styles.Get("monokai")
+})
+
 // Model represents the diff view component
 type Model struct {
 	diff     *git.FileDiff
 	filePath string
-	lines    []SideBySideLine
+	rows     *rowIndex
 	offset   int
 	cursor   int
 	width    int
@@ -47,23 +57,70 @@ type Model struct {
 	lexer    chroma.Lexer
 	style    *chroma.Style
 	viewMode ViewMode
+	rendered renderCache
+	loading  string          // Placeholder shown while a diff loads
+	maxLines int             // Larger diffs need confirmation before rendering
+	guarded  bool            // Current diff is too large and not yet confirmed
+	allowed  map[string]bool // Large diffs the user chose to render
+	elide    int             // Unchanged runs longer than this fold into one row
+	expanded map[int]bool    // Folds opened with Enter, by their first new line
+	threads  []Thread        // Comment threads on the diff's hunks
+
+	openThreads map[int]bool // Threads showing their comments, by ID
+
+	tabWidth       int    // Columns per tab stop
+	showWhitespace bool   // Show tabs as → and trailing spaces as ·
+	eolOnly        bool   // Every change only converts line endings
+	secrets        string // Banner warning of added credentials, if any
+	colorMoved     bool   // Color moved blocks apart from other changes
+	moved          map[movedKey]int
+	trivial        map[movedKey]bool // Blank and whitespace-only changed lines
+	trivialCount   int
+	intraline      map[string]string   // Configured granularity by language
+	granularity    Granularity         // Granularity for the current file
+	partners       map[movedKey]string // Content of the line paired with each changed line
+
+	lineNumbers     bool                        // Show the line number column
+	relativeNumbers bool                        // Number rows by their distance from the cursor
+	link            ui.LinkFunc                 // Hyperlinks the file name and new line numbers when set
+	diagnostics     map[string]map[int][]string // Linter messages by path and new line
+	title           string                      // Shown before the file name instead of DIFF
 }
 
 // New creates a new diff view model
 func New() Model {
 	return Model{
-		style:    styles.Get("monokai"),
-		viewMode: ViewBoth,
-		cursor:   0,
+		viewMode:    ViewBoth,
+		cursor:      0,
+		allowed:     make(map[string]bool),
+		openThreads: make(map[int]bool),
+		lineNumbers: true,
 	}
 }
 
 // SetDiff sets the diff to display
 func (m *Model) SetDiff(diff *git.FileDiff, filePath string) {
+	if filePath != m.filePath || m.expanded == nil {
+		m.expanded = make(map[int]bool)
+		m.threads = nil
+	}
 	m.diff = diff
 	m.filePath = filePath
 	m.offset = 0
 	m.cursor = 0
+	m.rendered = make(renderCache)
+	m.eolOnly = diff != nil && diff.LineEndingsOnly()
+	m.moved = nil
+	if m.colorMoved {
+		m.moved = findMoved(diff)
+	}
+	m.trivial, m.trivialCount = findTrivial(diff)
+	m.partners = findPartners(diff)
+	m.secrets = findSecrets(diff)
+
+	if m.style == nil {
+		m.style = defaultStyle()
+	}
 
 	// Set up lexer based on file extension
 	m.lexer = lexers.Match(filePath)
@@ -71,15 +128,168 @@ func (m *Model) SetDiff(diff *git.FileDiff, filePath string) {
 		m.lexer = lexers.Fallback
 	}
 	m.lexer = chroma.Coalesce(m.lexer)
+	m.granularity = m.granularityFor(m.lexer)
+
+	// Hold back diffs too large to render quickly until confirmed
+	m.guarded = m.maxLines > 0 && !m.allowed[filePath] && diffLines(diff) > m.maxLines
+	if m.guarded {
+		m.rows = nil
+		return
+	}
 
 	// Convert diff to side-by-side format
-	m.lines = m.convertToSideBySide()
+	m.rows = newRowIndex(diff, m.rowOptions())
+}
+
+// rowOptions returns the view state the rows are built with
+func (m Model) rowOptions() rowOptions {
+	return rowOptions{elide: m.elide, expanded: m.expanded, threads: m.threads, open: m.openThreads}
+}
+
+// rebuildRows regroups the rows after the folds or threads changed
+func (m *Model) rebuildRows() {
+	if m.guarded {
+		return
+	}
+	m.rows = newRowIndex(m.diff, m.rowOptions())
+	clear(m.rendered)
+}
+
+// SetMaxLines sets the number of diff lines above which rendering needs
+// confirmation. Zero renders every diff.
+func (m *Model) SetMaxLines(n int) {
+	m.maxLines = n
+}
+
+// diffLines counts the lines in a diff
+func diffLines(diff *git.FileDiff) int {
+	n := 0
+	if diff != nil {
+		for _, hunk := range diff.Hunks {
+			n += len(hunk.Lines)
+		}
+	}
+	return n
+}
+
+// renderGuard describes a diff held back for being too large
+func (m Model) renderGuard() []string {
+	adds, dels := 0, 0
+	for _, hunk := range m.diff.Hunks {
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case git.DiffLineAddition:
+				adds++
+			case git.DiffLineDeletion:
+				dels++
+			}
+		}
+	}
+
+	stats := lipgloss.NewStyle().Foreground(ui.ColorSuccess).Render(fmt.Sprintf("+%d", adds)) + " " +
+		lipgloss.NewStyle().Foreground(ui.ColorDanger).Render(fmt.Sprintf("-%d", dels))
+	return []string{
+		"",
+		fmt.Sprintf("Large diff: %d lines (limit %d)  ", diffLines(m.diff), m.maxLines) + stats,
+		"",
+		ui.EmptyStateStyle.Render("Press Enter to render it anyway"),
+	}
+}
+
+// SetElide sets the length above which runs of unchanged lines fold into a
+// row that Enter expands. Zero shows every line.
+func (m *Model) SetElide(n int) {
+	m.elide = n
+}
+
+// SetTabWidth sets the number of columns per tab stop
+func (m *Model) SetTabWidth(n int) {
+	m.tabWidth = n
+	clear(m.rendered)
+}
+
+// SetStyle sets the syntax highlighting style; nil keeps the default
+func (m *Model) SetStyle(style *chroma.Style) {
+	if style == nil {
+		return
+	}
+	m.style = style
+	clear(m.rendered)
+}
+
+// SetShowWhitespace sets whether tabs and trailing spaces are made visible
+func (m *Model) SetShowWhitespace(show bool) {
+	m.showWhitespace = show
+	clear(m.rendered)
+}
+
+// SetLineNumbers sets whether the line number column is shown. Hiding it
+// gives the content more room on narrow terminals.
+func (m *Model) SetLineNumbers(show bool) {
+	m.lineNumbers = show
+	clear(m.rendered)
+}
+
+// SetRelativeNumbers sets whether rows are numbered by their distance from
+// the cursor rather than by their line in the file
+func (m *Model) SetRelativeNumbers(relative bool) {
+	m.relativeNumbers = relative
+}
+
+// SetDiagnostics sets the linter messages to mark, by path and line of the
+// new version
+func (m *Model) SetDiagnostics(diagnostics map[string]map[int][]string) {
+	m.diagnostics = diagnostics
+}
+
+// CursorDiagnostics returns the linter messages for the new line of the
+// cursor row
+func (m Model) CursorDiagnostics() []string {
+	_, line := m.CursorLine()
+	if line == 0 {
+		return nil
+	}
+	return m.diagnostics[m.filePath][line]
+}
+
+// SetLinks makes the file name in the title and the line numbers of the new
+// side terminal hyperlinks to the URLs link returns, or plain text when link
+// is nil
+func (m *Model) SetLinks(link ui.LinkFunc) {
+	m.link = link
+}
+
+// SetColorMoved sets whether blocks of moved lines get their own colors.
+// It applies from the next diff set.
+func (m *Model) SetColorMoved(color bool) {
+	m.colorMoved = color
+}
+
+// SetLoading shows a placeholder instead of the diff while one loads
+func (m *Model) SetLoading(text string) {
+	m.loading = text
 }
 
 // SetSize sets the dimensions
 func (m *Model) SetSize(width, height int) {
+	if width != m.width {
+		clear(m.rendered)
+	}
+	before := m.visibleLines()
 	m.width = width
 	m.height = height
+	m.rescale(before)
+}
+
+// rescale keeps the cursor at the same height within the view, in
+// proportion, after the number of visible rows changed from before
+func (m *Model) rescale(before int) {
+	visible := m.visibleLines()
+	if visible == before || m.rows.count(ViewBoth) == 0 {
+		return
+	}
+	row := min(max((m.cursor-m.offset)*visible/before, 0), visible-1)
+	m.offset = max(min(m.cursor-row, m.rows.count(ViewBoth)-visible), 0)
 }
 
 // SetFocused sets whether this component is focused
@@ -96,6 +306,9 @@ func (m Model) IsFocused() bool {
 func (m Model) visibleLines() int {
 	// height - border(2) - title(1) - tabs(1) - column headers(2)
 	visible := m.height - 6
+	if m.secrets != "" {
+		visible--
+	}
 	if visible < 1 {
 		visible = 1
 	}
@@ -117,12 +330,37 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	case tea.KeyMsg:
 		keys := ui.DefaultKeyMap()
 		visibleHeight := m.visibleLines()
-		maxCursor := len(m.lines) - 1
+		maxCursor := m.rows.count(ViewBoth) - 1
 		if maxCursor < 0 {
 			maxCursor = 0
 		}
 
 		switch {
+		case m.guarded && key.Matches(msg, keys.Enter):
+			m.allowed[m.filePath] = true
+			m.guarded = false
+			m.rebuildRows()
+
+		case key.Matches(msg, keys.Enter):
+			// Unfold the unchanged lines under the cursor, or open or close
+			// the comment thread
+			if first, ok := m.rows.fold(m.cursor); ok {
+				m.expanded[first] = true
+				m.rebuildRows()
+			} else if thread, _, open := m.rows.thread(m.cursor); thread != nil {
+				m.openThreads[thread.ID] = !open
+				m.rebuildRows()
+			}
+
+		case key.Matches(msg, keys.Whitespace):
+			m.SetShowWhitespace(!m.showWhitespace)
+
+		case key.Matches(msg, keys.LineNumbers):
+			m.SetLineNumbers(!m.lineNumbers)
+
+		case key.Matches(msg, keys.RelativeNums):
+			m.SetRelativeNumbers(!m.relativeNumbers)
+
 		case key.Matches(msg, keys.BracketLeft):
 			// Previous view mode
 			if m.viewMode > 0 {
@@ -177,6 +415,34 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				m.offset = m.cursor - visibleHeight + 1
 			}
 
+		case key.Matches(msg, keys.HalfPageUp, keys.HalfPageDown):
+			// Scroll the view and the cursor by half a page, like vim
+			half := max(visibleHeight/2, 1)
+			if key.Matches(msg, keys.HalfPageUp) {
+				half = -half
+			}
+			maxOffset := max(maxCursor+1-visibleHeight, 0)
+			m.offset = min(max(m.offset+half, 0), maxOffset)
+			m.cursor = min(max(m.cursor+half, 0), maxCursor)
+
+		case key.Matches(msg, keys.ScrollUp):
+			// Scroll the view a line, moving the cursor only to keep it
+			// in view
+			if m.offset > 0 {
+				m.offset--
+				if m.cursor >= m.offset+visibleHeight {
+					m.cursor = m.offset + visibleHeight - 1
+				}
+			}
+
+		case key.Matches(msg, keys.ScrollDown):
+			if m.offset < maxCursor+1-visibleHeight {
+				m.offset++
+				if m.cursor < m.offset {
+					m.cursor = m.offset
+				}
+			}
+
 		case key.Matches(msg, keys.Home):
 			m.cursor = 0
 			m.offset = 0
@@ -205,26 +471,66 @@ func (m Model) View() string {
 
 	// Title
 	title := "DIFF"
-	if m.filePath != "" {
-		title = fmt.Sprintf("DIFF: %s", filepath.Base(m.filePath))
+	if m.title != "" {
+		title = m.title
+	}
+	if from, to, ok := m.renamed(); ok {
+		title = fmt.Sprintf("%s: %s → %s", title, from, m.linked(to, 0))
+		if m.diff.Similarity > 0 {
+			title += fmt.Sprintf(" (%d%%)", m.diff.Similarity)
+		}
+	} else if m.filePath != "" {
+		title = fmt.Sprintf("%s: %s", title, m.linked(filepath.Base(m.filePath), 0))
+	}
+	if m.eolOnly {
+		title += "  [line endings changed]"
+	} else if m.trivialCount == 1 {
+		title += "  [1 trivial change]"
+	} else if m.trivialCount > 1 {
+		title += fmt.Sprintf("  [%d trivial changes]", m.trivialCount)
+	}
+	if m.diff != nil && m.diff.Encoding != "" {
+		title += fmt.Sprintf("  [%s]", m.diff.Encoding)
 	}
 	lines = append(lines, ui.PaneTitleStyle.Render(title))
+	if m.secrets != "" {
+		lines = append(lines, ui.SecretBannerStyle.Render(text.Truncate(m.secrets, innerWidth-2, "…")))
+	}
 
 	// Tabs
 	lines = append(lines, m.renderTabs())
 
 	// No diff content
-	if m.diff == nil || len(m.lines) == 0 {
-		lines = append(lines, ui.EmptyStateStyle.Render("Select a file to view diff"))
+	if m.loading != "" {
+		lines = append(lines, ui.EmptyStateStyle.Render(m.loading))
+	} else if m.guarded {
+		lines = append(lines, m.renderGuard()...)
+	} else if m.diff == nil || m.rows.count(ViewBoth) == 0 {
+		empty := "Select a file to view diff"
+		if m.diff != nil {
+			// Renames and mode changes can leave the content as it was
+			empty = "No content changes"
+		}
+		lines = append(lines, ui.EmptyStateStyle.Render(empty))
 	} else {
+		// Keep styled rows for the viewport and a page either side
+		first := m.offset
+		if m.viewMode != ViewBoth && first < m.rows.count(m.viewMode) {
+			first = m.rows.orig(m.viewMode, first)
+		}
+		m.rendered.prune(first-visibleHeight, first+2*visibleHeight)
+
+		var view []string
 		switch m.viewMode {
 		case ViewBoth:
-			lines = append(lines, m.renderBothView(innerWidth, visibleHeight)...)
+			view = m.renderBothView(innerWidth, visibleHeight)
 		case ViewNew:
-			lines = append(lines, m.renderSingleView(innerWidth, visibleHeight, true)...)
+			view = m.renderSingleView(innerWidth, visibleHeight, true)
 		case ViewOld:
-			lines = append(lines, m.renderSingleView(innerWidth, visibleHeight, false)...)
+			view = m.renderSingleView(innerWidth, visibleHeight, false)
 		}
+		// The scrollbar sits in the last column, against the border
+		lines = append(lines, m.addScrollbar(view, m.width-3, visibleHeight)...)
 	}
 
 	// Pad to fill height
@@ -252,6 +558,20 @@ func (m Model) View() string {
 		Render(content)
 }
 
+// renamed returns the old and new paths of a renamed file, as base names
+// when it stayed in its folder
+func (m Model) renamed() (from, to string, ok bool) {
+	if m.diff == nil || m.diff.OldPath == "" || m.diff.NewPath == "" || m.diff.OldPath == m.diff.NewPath ||
+		m.diff.OldPath == "/dev/null" || m.diff.NewPath == "/dev/null" {
+		return "", "", false
+	}
+	from, to = m.diff.OldPath, m.diff.NewPath
+	if path.Dir(from) == path.Dir(to) {
+		from, to = path.Base(from), path.Base(to)
+	}
+	return from, to, true
+}
+
 func (m Model) renderTabs() string {
 	modes := []string{"Both", "New", "Old"}
 	var tabs []string
@@ -290,29 +610,39 @@ func (m Model) renderBothView(innerWidth, visibleHeight int) []string {
 
 	// Content lines
 	end := m.offset + visibleHeight
-	if end > len(m.lines) {
-		end = len(m.lines)
+	if end > m.rows.count(ViewBoth) {
+		end = m.rows.count(ViewBoth)
 	}
 
-	lineNumWidth := 4
+	lineNumWidth := 0
+	if m.lineNumbers {
+		lineNumWidth = 4
+	}
 
 	for i := m.offset; i < end; i++ {
-		line := m.lines[i]
 		isCursor := i == m.cursor && m.focused
-
 		cursor := "  "
 		if isCursor {
 			cursor = "> "
 		}
-		oldSide := m.renderSide(line.OldLineNum, line.OldContent, line.OldType, sideWidth, lineNumWidth, isCursor)
-		newSide := m.renderSide(line.NewLineNum, line.NewContent, line.NewType, sideWidth, lineNumWidth, isCursor)
-
-		lines = append(lines, cursor+oldSide+" | "+newSide)
+		if thread, k, open := m.rows.thread(i); thread != nil {
+			lines = append(lines, cursor+m.renderThread(thread, open, k, 2*sideWidth+1))
+			continue
+		}
+		line := m.rows.at(i)
+		oldSide := m.cached(renderKey{i, sideOld}, func() string {
+			return m.renderSide(line.OldLineNum, line.OldContent, line.OldType, sideWidth, lineNumWidth, isCursor)
+		})
+		newSide := m.cached(renderKey{i, sideNew}, func() string {
+			return m.renderSide(line.NewLineNum, line.NewContent, line.NewType, sideWidth, lineNumWidth, isCursor)
+		})
+
+		lines = append(lines, cursor+m.gutter(line.OldLineNum, i, lineNumWidth, false)+oldSide+" | "+m.gutter(line.NewLineNum, i, lineNumWidth, true)+newSide)
 	}
 
 	// Scroll indicator
-	if len(m.lines) > visibleHeight {
-		scrollInfo := fmt.Sprintf(" [%d-%d of %d] (line %d)", m.offset+1, end, len(m.lines), m.cursor+1)
+	if m.rows.count(ViewBoth) > visibleHeight {
+		scrollInfo := fmt.Sprintf(" [%d-%d of %d] (line %d)", m.offset+1, end, m.rows.count(ViewBoth), m.cursor+1)
 		lines = append(lines, "  "+ui.EmptyStateStyle.Render(scrollInfo))
 	}
 
@@ -343,116 +673,177 @@ func (m Model) renderSingleView(innerWidth, visibleHeight int, showNew bool) []s
 	lines = append(lines, "  "+strings.Repeat("-", fullWidth-2))
 
 	// Filter and display lines
-	lineNumWidth := 5
+	lineNumWidth := 0
+	if m.lineNumbers {
+		lineNumWidth = 5
+	}
 	contentWidth := fullWidth - lineNumWidth - 2
 
-	displayedCount := 0
-	skipped := 0
-
-	for origIdx, line := range m.lines {
-		var lineNum int
-		var content string
-		var lineType git.DiffLineType
+	mode := ViewOld
+	if showNew {
+		mode = ViewNew
+	}
+	end := min(m.offset+visibleHeight, m.rows.count(mode))
 
-		if showNew {
-			// Show additions and context
-			if line.NewType == git.DiffLineAddition || line.NewType == git.DiffLineContext || line.NewType == git.DiffLineHeader {
-				lineNum = line.NewLineNum
-				content = line.NewContent
-				lineType = line.NewType
-			} else if line.OldType == git.DiffLineContext || line.OldType == git.DiffLineHeader {
-				lineNum = line.NewLineNum
-				content = line.OldContent
-				lineType = line.OldType
-			} else {
-				continue // Skip deletions in new view
-			}
-		} else {
-			// Show deletions and context
-			if line.OldType == git.DiffLineDeletion || line.OldType == git.DiffLineContext || line.OldType == git.DiffLineHeader {
-				lineNum = line.OldLineNum
-				content = line.OldContent
-				lineType = line.OldType
-			} else if line.NewType == git.DiffLineContext || line.NewType == git.DiffLineHeader {
-				lineNum = line.OldLineNum
-				content = line.NewContent
-				lineType = line.NewType
-			} else {
-				continue // Skip additions in old view
-			}
+	for row := m.offset; row < end; row++ {
+		origIdx := m.rows.orig(mode, row)
+		isCursor := origIdx == m.cursor && m.focused
+		cursor := "  "
+		if isCursor {
+			cursor = "> "
 		}
-
-		// Handle offset
-		if skipped < m.offset {
-			skipped++
+		if thread, k, open := m.rows.thread(origIdx); thread != nil {
+			lines = append(lines, cursor+m.renderThread(thread, open, k, lineNumWidth+contentWidth+1))
 			continue
 		}
+		lineNum, content, lineType, _ := singleSide(m.rows.at(origIdx), showNew)
 
-		if displayedCount >= visibleHeight {
-			break
+		side := fullOld
+		if showNew {
+			side = fullNew
 		}
+		renderedLine := m.cached(renderKey{origIdx, side}, func() string {
+			return m.renderFullWidthLine(lineNum, content, lineType, contentWidth, lineNumWidth, isCursor)
+		})
+		lines = append(lines, cursor+m.gutter(lineNum, origIdx, lineNumWidth, showNew)+renderedLine)
+	}
 
-		isCursor := origIdx == m.cursor && m.focused
-		cursor := "  "
-		if isCursor {
-			cursor = "> "
+	return lines
+}
+
+// singleSide picks the side of a row shown in the New or Old view. Rows that
+// only exist on the other side are skipped.
+func singleSide(line SideBySideLine, showNew bool) (int, string, git.DiffLineType, bool) {
+	if showNew {
+		// Show additions and context
+		if line.NewType == git.DiffLineAddition || line.NewType == git.DiffLineContext || line.NewType == git.DiffLineHeader {
+			return line.NewLineNum, line.NewContent, line.NewType, true
+		} else if line.OldType == git.DiffLineContext || line.OldType == git.DiffLineHeader {
+			return line.NewLineNum, line.OldContent, line.OldType, true
 		}
+		return 0, "", 0, false // Skip deletions in new view
+	}
 
-		renderedLine := m.renderFullWidthLine(lineNum, content, lineType, contentWidth, lineNumWidth, isCursor)
-		lines = append(lines, cursor+renderedLine)
-		displayedCount++
+	// Show deletions and context
+	if line.OldType == git.DiffLineDeletion || line.OldType == git.DiffLineContext || line.OldType == git.DiffLineHeader {
+		return line.OldLineNum, line.OldContent, line.OldType, true
+	} else if line.NewType == git.DiffLineContext || line.NewType == git.DiffLineHeader {
+		return line.OldLineNum, line.NewContent, line.NewType, true
 	}
+	return 0, "", 0, false // Skip additions in old view
+}
 
-	return lines
+// diffMarker separates the line number from the content. Without background
+// tints it marks added and deleted lines with + and -.
+func diffMarker(lineType git.DiffLineType) string {
+	if ui.HasTints() {
+		return " "
+	}
+	switch lineType {
+	case git.DiffLineAddition:
+		return "+"
+	case git.DiffLineDeletion:
+		return "-"
+	}
+	return " "
 }
 
-func (m Model) renderFullWidthLine(lineNum int, content string, lineType git.DiffLineType, contentWidth, lineNumWidth int, isCursor bool) string {
-	// Line number
-	var lineNumStr string
-	if lineNum > 0 {
-		lineNumStr = fmt.Sprintf("%*d", lineNumWidth, lineNum)
-	} else {
-		lineNumStr = strings.Repeat(" ", lineNumWidth)
+// gutter renders the line number column of side-by-side row i. With
+// relative numbers, rows show their distance from the cursor row, which
+// keeps its own line number. Rendered apart from the row's content, so
+// moving the cursor doesn't invalidate cached rows. Numbers of the new side
+// are marked when the linter reported on the line, and link to it when links
+// are set.
+func (m Model) gutter(lineNum, i, width int, newSide bool) string {
+	if width == 0 {
+		return ""
+	}
+	num := ""
+	switch {
+	case lineNum == 0:
+	case m.relativeNumbers && i != m.cursor:
+		num = strconv.Itoa(abs(i - m.cursor))
+	default:
+		num = strconv.Itoa(lineNum)
+	}
+	style := ui.LineNumberStyle
+	if newSide && lineNum > 0 && len(m.diagnostics[m.filePath][lineNum]) > 0 {
+		style = style.Inherit(ui.LintMarkerStyle)
+	}
+	rendered := style.Width(width).Render(fmt.Sprintf("%*s", width, num))
+	if newSide && lineNum > 0 {
+		return m.linked(rendered, lineNum)
+	}
+	return rendered
+}
+
+// linked makes s a hyperlink to line of the displayed file, or to the file
+// when line is 0, if links are set
+func (m Model) linked(s string, line int) string {
+	if m.link == nil || m.filePath == "" {
+		return s
 	}
-	lineNumRendered := ui.LineNumberStyle.Render(lineNumStr)
+	return ui.Hyperlink(m.link(m.filePath, line), s)
+}
 
-	// Truncate content if needed
-	displayContent := content
-	if len(displayContent) > contentWidth {
-		displayContent = displayContent[:contentWidth-1] + "…"
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
 	}
+	return n
+}
+
+func (m Model) renderFullWidthLine(lineNum int, content string, lineType git.DiffLineType, contentWidth, lineNumWidth int, isCursor bool) string {
+	// Truncate content if needed
+	displayContent := text.Truncate(expandTabs(content, m.tabWidth, m.showWhitespace), contentWidth, "…")
 
 	// Determine background color based on diff type (subtle tints)
-	var bgColor lipgloss.Color
-	var defaultFg lipgloss.Color
+	var bgColor lipgloss.TerminalColor = lipgloss.NoColor{}
+	var defaultFg lipgloss.TerminalColor
 	switch lineType {
 	case git.DiffLineAddition:
-		bgColor = lipgloss.Color("#0a1a0a")  // Very subtle dark green
-		defaultFg = lipgloss.Color("#88cc88")
+		bgColor = ui.ColorAdditionBg // Very subtle dark green
+		defaultFg = ui.ColorAdditionFg
 	case git.DiffLineDeletion:
-		bgColor = lipgloss.Color("#1a0a0a")  // Very subtle dark red
-		defaultFg = lipgloss.Color("#cc8888")
+		bgColor = ui.ColorDeletionBg // Very subtle dark red
+		defaultFg = ui.ColorDeletionFg
 	case git.DiffLineHeader:
-		bgColor = lipgloss.Color("#0a0a1a")  // Very subtle dark blue
-		defaultFg = lipgloss.Color("#8888cc")
+		bgColor = ui.ColorHunkBg // Very subtle dark blue
+		defaultFg = ui.ColorHunkFg
 	default:
-		bgColor = lipgloss.Color("")
 		defaultFg = ui.ColorTextMuted
 	}
 
+	// Conflict markers stand out from the code around them
+	conflict := lineType != git.DiffLineHeader && git.IsConflictMarker(content)
+	if conflict {
+		bgColor = ui.ColorConflictBg
+		defaultFg = ui.ColorConflictFg
+	}
+	block, moved := m.moved[movedKey{lineType, lineNum}]
+	if moved && !conflict {
+		bgColor, defaultFg = movedColors(lineType, block)
+	}
+	// Whitespace-only changes are dimmed rather than highlighted
+	trivial := !conflict && !moved && m.trivial[movedKey{lineType, lineNum}]
+	if trivial {
+		defaultFg = ui.ColorDim
+	}
+	var spans []span
+	if !conflict && !moved && !trivial {
+		spans = m.changedSpans(lineType, lineNum, content)
+	}
+
 	// Apply syntax highlighting
 	var result strings.Builder
 	currentLen := 0
 
-	if m.lexer != nil && m.style != nil && lineType != git.DiffLineHeader {
+	if m.lexer != nil && m.style != nil && lineType != git.DiffLineHeader && !conflict && !trivial {
 		iterator, err := m.lexer.Tokenise(nil, displayContent)
 		if err == nil {
 			for token := iterator(); token != chroma.EOF; token = iterator() {
-				tokenText := token.Value
-
-				if currentLen+len(tokenText) > contentWidth {
-					tokenText = tokenText[:contentWidth-currentLen]
-				}
+				tokenText, tokenWidth := text.Take(token.Value, contentWidth-currentLen)
 				if len(tokenText) == 0 {
 					break
 				}
@@ -469,8 +860,8 @@ func (m Model) renderFullWidthLine(lineNum int, content string, lineType git.Dif
 					style = style.Bold(true)
 				}
 
-				result.WriteString(style.Render(tokenText))
-				currentLen += len(tokenText)
+				writeEmphasized(&result, tokenText, currentLen, spans, style, lineType)
+				currentLen += tokenWidth
 
 				if currentLen >= contentWidth {
 					break
@@ -480,9 +871,9 @@ func (m Model) renderFullWidthLine(lineNum int, content string, lineType git.Dif
 	}
 
 	if currentLen == 0 {
-		style := lipgloss.NewStyle().Background(bgColor).Foreground(defaultFg)
-		result.WriteString(style.Render(displayContent))
-		currentLen = len(displayContent)
+		style := lipgloss.NewStyle().Background(bgColor).Foreground(defaultFg).Bold(conflict)
+		writeEmphasized(&result, displayContent, 0, spans, style, lineType)
+		currentLen = text.Width(displayContent)
 	}
 
 	if currentLen < contentWidth {
@@ -490,19 +881,10 @@ func (m Model) renderFullWidthLine(lineNum int, content string, lineType git.Dif
 		result.WriteString(padStyle.Render(strings.Repeat(" ", contentWidth-currentLen)))
 	}
 
-	return lineNumRendered + " " + result.String()
+	return diffMarker(lineType) + result.String()
 }
 
 func (m Model) renderSide(lineNum int, content string, lineType git.DiffLineType, width, lineNumWidth int, isCursor bool) string {
-	// Line number
-	var lineNumStr string
-	if lineNum > 0 {
-		lineNumStr = fmt.Sprintf("%*d", lineNumWidth, lineNum)
-	} else {
-		lineNumStr = strings.Repeat(" ", lineNumWidth)
-	}
-	lineNumRendered := ui.LineNumberStyle.Render(lineNumStr)
-
 	// Content width
 	codeWidth := width - lineNumWidth - 2
 	if codeWidth < 1 {
@@ -510,43 +892,55 @@ func (m Model) renderSide(lineNum int, content string, lineType git.DiffLineType
 	}
 
 	// Truncate content if needed
-	displayContent := content
-	if len(displayContent) > codeWidth {
-		displayContent = displayContent[:codeWidth-1] + "…"
-	}
+	displayContent := text.Truncate(expandTabs(content, m.tabWidth, m.showWhitespace), codeWidth, "…")
 
 	// Determine background color based on diff type (subtle tints)
-	var bgColor lipgloss.Color
-	var defaultFg lipgloss.Color
+	var bgColor lipgloss.TerminalColor = lipgloss.NoColor{}
+	var defaultFg lipgloss.TerminalColor
 	switch lineType {
 	case git.DiffLineAddition:
-		bgColor = lipgloss.Color("#0a1a0a")  // Very subtle dark green
-		defaultFg = lipgloss.Color("#88cc88")
+		bgColor = ui.ColorAdditionBg // Very subtle dark green
+		defaultFg = ui.ColorAdditionFg
 	case git.DiffLineDeletion:
-		bgColor = lipgloss.Color("#1a0a0a")  // Very subtle dark red
-		defaultFg = lipgloss.Color("#cc8888")
+		bgColor = ui.ColorDeletionBg // Very subtle dark red
+		defaultFg = ui.ColorDeletionFg
 	case git.DiffLineHeader:
-		bgColor = lipgloss.Color("#0a0a1a")  // Very subtle dark blue
-		defaultFg = lipgloss.Color("#8888cc")
+		bgColor = ui.ColorHunkBg // Very subtle dark blue
+		defaultFg = ui.ColorHunkFg
 	default:
-		bgColor = lipgloss.Color("")
 		defaultFg = ui.ColorTextMuted
 	}
 
+	// Conflict markers stand out from the code around them
+	conflict := lineType != git.DiffLineHeader && git.IsConflictMarker(content)
+	if conflict {
+		bgColor = ui.ColorConflictBg
+		defaultFg = ui.ColorConflictFg
+	}
+	block, moved := m.moved[movedKey{lineType, lineNum}]
+	if moved && !conflict {
+		bgColor, defaultFg = movedColors(lineType, block)
+	}
+	// Whitespace-only changes are dimmed rather than highlighted
+	trivial := !conflict && !moved && m.trivial[movedKey{lineType, lineNum}]
+	if trivial {
+		defaultFg = ui.ColorDim
+	}
+	var spans []span
+	if !conflict && !moved && !trivial {
+		spans = m.changedSpans(lineType, lineNum, content)
+	}
+
 	// Apply syntax highlighting with diff background
 	var result strings.Builder
 	currentLen := 0
 
-	if m.lexer != nil && m.style != nil && lineType != git.DiffLineHeader {
+	if m.lexer != nil && m.style != nil && lineType != git.DiffLineHeader && !conflict && !trivial {
 		iterator, err := m.lexer.Tokenise(nil, displayContent)
 		if err == nil {
 			for token := iterator(); token != chroma.EOF; token = iterator() {
-				tokenText := token.Value
-
 				// Don't exceed codeWidth
-				if currentLen+len(tokenText) > codeWidth {
-					tokenText = tokenText[:codeWidth-currentLen]
-				}
+				tokenText, tokenWidth := text.Take(token.Value, codeWidth-currentLen)
 				if len(tokenText) == 0 {
 					break
 				}
@@ -567,8 +961,8 @@ func (m Model) renderSide(lineNum int, content string, lineType git.DiffLineType
 					style = style.Italic(true)
 				}
 
-				result.WriteString(style.Render(tokenText))
-				currentLen += len(tokenText)
+				writeEmphasized(&result, tokenText, currentLen, spans, style, lineType)
+				currentLen += tokenWidth
 
 				if currentLen >= codeWidth {
 					break
@@ -579,9 +973,9 @@ func (m Model) renderSide(lineNum int, content string, lineType git.DiffLineType
 
 	// If no syntax highlighting was applied, use default styling
 	if currentLen == 0 {
-		style := lipgloss.NewStyle().Background(bgColor).Foreground(defaultFg)
-		result.WriteString(style.Render(displayContent))
-		currentLen = len(displayContent)
+		style := lipgloss.NewStyle().Background(bgColor).Foreground(defaultFg).Bold(conflict)
+		writeEmphasized(&result, displayContent, 0, spans, style, lineType)
+		currentLen = text.Width(displayContent)
 	}
 
 	// Pad remaining space with background color
@@ -590,88 +984,7 @@ func (m Model) renderSide(lineNum int, content string, lineType git.DiffLineType
 		result.WriteString(padStyle.Render(strings.Repeat(" ", codeWidth-currentLen)))
 	}
 
-	return lineNumRendered + " " + result.String()
-}
-
-// convertToSideBySide converts the diff hunks to side-by-side format
-func (m *Model) convertToSideBySide() []SideBySideLine {
-	if m.diff == nil {
-		return nil
-	}
-
-	var lines []SideBySideLine
-
-	for _, hunk := range m.diff.Hunks {
-		var deletions []git.DiffLine
-		var additions []git.DiffLine
-
-		for _, line := range hunk.Lines {
-			switch line.Type {
-			case git.DiffLineHeader:
-				lines = append(lines, SideBySideLine{
-					OldContent: line.Content,
-					OldType:    git.DiffLineHeader,
-					NewContent: line.Content,
-					NewType:    git.DiffLineHeader,
-				})
-
-			case git.DiffLineContext:
-				// Flush pending changes
-				lines = append(lines, m.alignChanges(deletions, additions)...)
-				deletions = nil
-				additions = nil
-
-				lines = append(lines, SideBySideLine{
-					OldLineNum: line.OldLineNum,
-					OldContent: line.Content,
-					OldType:    git.DiffLineContext,
-					NewLineNum: line.NewLineNum,
-					NewContent: line.Content,
-					NewType:    git.DiffLineContext,
-				})
-
-			case git.DiffLineDeletion:
-				deletions = append(deletions, line)
-
-			case git.DiffLineAddition:
-				additions = append(additions, line)
-			}
-		}
-
-		// Flush remaining changes
-		lines = append(lines, m.alignChanges(deletions, additions)...)
-	}
-
-	return lines
-}
-
-func (m *Model) alignChanges(deletions, additions []git.DiffLine) []SideBySideLine {
-	var result []SideBySideLine
-
-	maxLen := len(deletions)
-	if len(additions) > maxLen {
-		maxLen = len(additions)
-	}
-
-	for i := 0; i < maxLen; i++ {
-		line := SideBySideLine{}
-
-		if i < len(deletions) {
-			line.OldLineNum = deletions[i].OldLineNum
-			line.OldContent = deletions[i].Content
-			line.OldType = git.DiffLineDeletion
-		}
-
-		if i < len(additions) {
-			line.NewLineNum = additions[i].NewLineNum
-			line.NewContent = additions[i].Content
-			line.NewType = git.DiffLineAddition
-		}
-
-		result = append(result, line)
-	}
-
-	return result
+	return diffMarker(lineType) + result.String()
 }
 
 // FilePath returns the current file path
@@ -683,7 +996,11 @@ func (m Model) FilePath() string {
 func (m *Model) Clear() {
 	m.diff = nil
 	m.filePath = ""
-	m.lines = nil
+	m.rows = nil
+	m.eolOnly = false
+	m.secrets = ""
+	m.moved = nil
+	m.trivial, m.trivialCount = nil, 0
 	m.offset = 0
 }
 
@@ -703,7 +1020,8 @@ func (m Model) GetViewMode() string {
 func (m Model) GetSearchableLines() []SearchableLine {
 	var result []SearchableLine
 
-	for i, line := range m.lines {
+	for i := range m.rows.count(ViewBoth) {
+		line := m.rows.at(i)
 		switch m.viewMode {
 		case ViewBoth:
 			// Include both sides
@@ -770,9 +1088,87 @@ func lineTypeToString(t git.DiffLineType) string {
 	}
 }
 
+// Diff returns the diff being displayed, or nil
+func (m Model) Diff() *git.FileDiff {
+	return m.diff
+}
+
+// CursorHunk returns the index of the hunk under the cursor, or -1 when no
+// diff rows are shown
+func (m Model) CursorHunk() int {
+	if m.cursor >= m.rows.count(ViewBoth) {
+		return -1
+	}
+	return m.rows.hunk(m.cursor)
+}
+
+// CursorLine returns the old and new line numbers of the cursor row, zero
+// for a side the row has no line on
+func (m Model) CursorLine() (old, new int) {
+	if m.cursor >= m.rows.count(ViewBoth) {
+		return 0, 0
+	}
+	line := m.rows.at(m.cursor)
+	return line.OldLineNum, line.NewLineNum
+}
+
+// CursorDiffLine returns the diff line under the cursor, taking the new
+// side of rows that have both
+func (m Model) CursorDiffLine() (git.DiffLine, bool) {
+	if m.cursor >= m.rows.count(ViewBoth) {
+		return git.DiffLine{}, false
+	}
+	row := m.rows.at(m.cursor)
+	if row.NewType == git.DiffLineAddition || row.NewType == git.DiffLineContext || row.NewType == git.DiffLineHeader {
+		return git.DiffLine{Type: row.NewType, Content: row.NewContent, OldLineNum: row.OldLineNum, NewLineNum: row.NewLineNum}, true
+	}
+	return git.DiffLine{Type: row.OldType, Content: row.OldContent, OldLineNum: row.OldLineNum}, true
+}
+
+// Pin returns a copy of the view to keep showing the current diff beside
+// this one, sharing none of the state that changes as either is used
+func (m Model) Pin() Model {
+	m.title = "PINNED"
+	m.rendered = make(renderCache)
+	m.expanded = maps.Clone(m.expanded)
+	m.openThreads = maps.Clone(m.openThreads)
+	m.allowed = maps.Clone(m.allowed)
+	return m
+}
+
+// SetTitle sets what the title shows before the file name, DIFF when empty
+func (m *Model) SetTitle(title string) {
+	m.title = title
+}
+
+// Position returns the scroll offset and cursor row
+func (m Model) Position() (offset, cursor int) {
+	return m.offset, m.cursor
+}
+
+// SetPosition restores a scroll offset and cursor row, clamped to the
+// current diff
+func (m *Model) SetPosition(offset, cursor int) {
+	if cursor >= m.rows.count(ViewBoth) {
+		cursor = m.rows.count(ViewBoth) - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	maxOffset := m.rows.count(ViewBoth) - m.visibleLines()
+	if offset > maxOffset {
+		offset = maxOffset
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	m.offset = offset
+	m.cursor = cursor
+}
+
 // JumpToLine jumps to a specific line index
 func (m *Model) JumpToLine(idx int) {
-	if idx < 0 || idx >= len(m.lines) {
+	if idx < 0 || idx >= m.rows.count(ViewBoth) {
 		return
 	}
 	m.cursor = idx
@@ -782,10 +1178,108 @@ func (m *Model) JumpToLine(idx int) {
 	if m.offset < 0 {
 		m.offset = 0
 	}
-	if m.offset > len(m.lines)-visibleHeight {
-		m.offset = len(m.lines) - visibleHeight
+	if m.offset > m.rows.count(ViewBoth)-visibleHeight {
+		m.offset = m.rows.count(ViewBoth) - visibleHeight
 		if m.offset < 0 {
 			m.offset = 0
 		}
 	}
 }
+
+// JumpToHunk moves the cursor to the header of hunk h and centers the
+// hunk in the view, or shows its start when it is taller than the view. It
+// reports whether the diff has hunk h.
+func (m *Model) JumpToHunk(h int) bool {
+	start, end := -1, 0
+	for i := range m.rows.count(ViewBoth) {
+		if m.rows.hunk(i) == h {
+			if start < 0 {
+				start = i
+			}
+			end = i + 1
+		}
+	}
+	if start < 0 {
+		return false
+	}
+	visible := m.visibleLines()
+	m.cursor = start
+	m.offset = start
+	if size := end - start; size < visible {
+		m.offset = start - (visible-size)/2
+	}
+	m.offset = max(min(m.offset, m.rows.count(ViewBoth)-visible), 0)
+	return true
+}
+
+// JumpToFileLine moves the cursor to the row showing line n of the new
+// version of the file, or of the old one when old is set, centering it.
+// Lines outside the hunks go to the nearest line shown. It reports whether
+// line n itself is shown.
+func (m *Model) JumpToFileLine(n int, old bool) bool {
+	if first, ok := m.rows.foldHolding(n, old); ok {
+		m.expanded[first] = true
+		m.rebuildRows()
+	}
+	best, distance := -1, 0
+	for i := range m.rows.count(ViewBoth) {
+		line := m.rows.at(i)
+		num := line.NewLineNum
+		if line.NewType != git.DiffLineAddition && line.NewType != git.DiffLineContext {
+			num = 0
+		}
+		if old {
+			num = line.OldLineNum
+			if line.OldType != git.DiffLineDeletion && line.OldType != git.DiffLineContext {
+				num = 0
+			}
+		}
+		if num == 0 {
+			continue
+		}
+		if d := max(num-n, n-num); best < 0 || d < distance {
+			best, distance = i, d
+		}
+	}
+	if best < 0 {
+		return false
+	}
+	m.JumpToLine(best)
+	return distance == 0
+}
+
+// JumpToDiffLine jumps to the row showing the given diff line, unfolding
+// the unchanged lines hiding it
+func (m *Model) JumpToDiffLine(target git.DiffLine) {
+	if target.Type == git.DiffLineContext {
+		if first, ok := m.rows.foldHolding(target.NewLineNum, false); ok {
+			m.expanded[first] = true
+			m.rebuildRows()
+		}
+	}
+	for i := range m.rows.count(ViewBoth) {
+		line := m.rows.at(i)
+		switch target.Type {
+		case git.DiffLineDeletion:
+			if line.OldType == git.DiffLineDeletion && line.OldLineNum == target.OldLineNum {
+				m.JumpToLine(i)
+				return
+			}
+		case git.DiffLineAddition:
+			if line.NewType == git.DiffLineAddition && line.NewLineNum == target.NewLineNum {
+				m.JumpToLine(i)
+				return
+			}
+		case git.DiffLineHeader:
+			if line.NewType == git.DiffLineHeader && line.NewContent == target.Content {
+				m.JumpToLine(i)
+				return
+			}
+		default:
+			if line.NewType == git.DiffLineContext && line.NewLineNum == target.NewLineNum {
+				m.JumpToLine(i)
+				return
+			}
+		}
+	}
+}
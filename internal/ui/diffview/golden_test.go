@@ -0,0 +1,176 @@
+package diffview
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/pkg/gitdiff"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	// Golden files hold the escape codes of one profile, whatever the
+	// terminal running the tests
+	if err := ui.SetColorMode("truecolor"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// goldenDiff has a changed line for the intraline highlights, a line too
+// long for the view, and a block whose lines only align by similarity
+const goldenDiff = `diff --git a/server.go b/server.go
+--- a/server.go
++++ b/server.go
+@@ -1,12 +1,13 @@
+ package server
+
+-func Start(addr string) error {
++func Start(addr string, timeout time.Duration) error {
+ 	log.Printf("starting the server on %s with the default handler and no middleware whatsoever", addr)
+-	mux := http.NewServeMux()
+-	mux.HandleFunc("/", index)
+-	mux.HandleFunc("/health", health)
++	mux := http.NewServeMux()
++	mux.HandleFunc("/metrics", metrics)
++	mux.HandleFunc("/", index)
++	mux.HandleFunc("/healthz", health)
+ 	return http.ListenAndServe(addr, mux)
+ }
+-
+-var version = "1.0"
++var version = "1.1"
+`
+
+// render shows goldenDiff in a diff view of the given size after the keys
+func render(t *testing.T, width, height int, setup func(*Model), keys ...string) string {
+	t.Helper()
+	diff, err := gitdiff.Parse(goldenDiff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New()
+	m.SetFocused(true)
+	if setup != nil {
+		setup(&m)
+	}
+	m.SetSize(width, height)
+	m.SetDiff(diff, "server.go")
+	for _, k := range keys {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+	return m.View()
+}
+
+// assertGolden compares got with testdata/name, or rewrites it with -update
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the golden file:\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		height int
+		setup  func(*Model)
+		keys   []string
+		ansi   bool // Keep the colors, which show the intraline highlights
+	}{
+		{name: "side_by_side", width: 120, height: 24},
+		{name: "side_by_side_colors", width: 120, height: 24, ansi: true},
+		{name: "side_by_side_narrow", width: 60, height: 24},
+		{name: "new_only", width: 80, height: 24, keys: []string{"]"}},
+		{name: "old_only", width: 80, height: 24, keys: []string{"]", "]"}},
+		{name: "new_only_colors", width: 80, height: 24, keys: []string{"]"}, ansi: true},
+		{name: "relative_numbers", width: 100, height: 24, keys: []string{"j", "j", "j"},
+			setup: func(m *Model) { m.SetRelativeNumbers(true) }},
+		{name: "no_line_numbers", width: 100, height: 24,
+			setup: func(m *Model) { m.SetLineNumbers(false) }},
+		{name: "whitespace", width: 100, height: 24,
+			setup: func(m *Model) { m.SetShowWhitespace(true) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := render(t, tt.width, tt.height, tt.setup, tt.keys...)
+			if !tt.ansi {
+				got = ansi.Strip(got)
+			}
+			assertGolden(t, tt.name+".golden", got)
+		})
+	}
+}
+
+// TestRenderCacheResize checks that rows rendered at one width aren't
+// reused at another
+func TestRenderCacheResize(t *testing.T) {
+	diff, err := gitdiff.Parse(goldenDiff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New()
+	m.SetSize(120, 24)
+	m.SetDiff(diff, "server.go")
+	m.View()
+	m.SetSize(70, 24)
+
+	fresh := New()
+	fresh.SetSize(70, 24)
+	fresh.SetDiff(diff, "server.go")
+	if got, want := m.View(), fresh.View(); got != want {
+		t.Errorf("view after resizing differs from a fresh render:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestAlign checks that changed lines pair up by similarity, leaving gaps
+// for the lines that have no counterpart
+func TestAlign(t *testing.T) {
+	lines := func(contents ...string) []gitdiff.DiffLine {
+		var out []gitdiff.DiffLine
+		for _, c := range contents {
+			out = append(out, gitdiff.DiffLine{Content: c})
+		}
+		return out
+	}
+	dels := lines(`mux := http.NewServeMux()`, `mux.HandleFunc("/", index)`, `mux.HandleFunc("/health", health)`)
+	adds := lines(`mux := http.NewServeMux()`, `mux.HandleFunc("/metrics", metrics)`, `mux.HandleFunc("/", index)`, `mux.HandleFunc("/healthz", health)`)
+	got := align(dels, adds)
+	want := [][2]int{{0, 0}, {-1, 1}, {1, 2}, {2, 3}}
+	if len(got) != len(want) {
+		t.Fatalf("align() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("align() = %v, want %v", got, want)
+		}
+	}
+
+	long := lines(strings.Repeat("x", maxAlignBytes))
+	if got := align(long, long); got != nil {
+		t.Errorf("align() of a block over maxAlignBytes = %v, want nil", got)
+	}
+}
@@ -0,0 +1,326 @@
+package diffview
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
+)
+
+// Granularity is the unit in which paired changed lines are compared to
+// highlight the parts that changed
+type Granularity string
+
+const (
+	GranularityOff   Granularity = "off"
+	GranularityChar  Granularity = "char"  // Each character
+	GranularityWord  Granularity = "word"  // Words, punctuation and spaces
+	GranularityToken Granularity = "token" // Tokens of the syntax highlighter
+)
+
+// proseLanguages compare by word unless configured otherwise; other
+// languages compare by token
+var proseLanguages = map[string]bool{
+	"plaintext":        true,
+	"markdown":         true,
+	"restructuredtext": true,
+	"org mode":         true,
+	"asciidoc":         true,
+	"tex":              true,
+}
+
+// maxIntralineUnits bounds the units compared on each side of a pair once
+// their common prefix and suffix are removed. Larger changes aren't
+// highlighted within the line.
+const maxIntralineUnits = 200
+
+// maxIntralineChange is the share of a line that may change before
+// highlighting the changed parts stops being useful
+const maxIntralineChange = 0.6
+
+// span is a changed part of a line, in cell columns of its tab expanded
+// content
+type span struct {
+	start, end int
+}
+
+// unit is a piece of a line compared as a whole
+type unit struct {
+	text       string
+	start, end int
+}
+
+// SetIntraline sets the granularity of the highlighting within changed lines
+// by lowercase language name or alias. The "default" key applies to
+// languages not listed; without it prose compares by word and code by token.
+func (m *Model) SetIntraline(granularity map[string]string) {
+	m.intraline = granularity
+	m.granularity = m.granularityFor(m.lexer)
+	clear(m.rendered)
+}
+
+// granularityFor picks the configured granularity for lexer's language
+func (m Model) granularityFor(lexer chroma.Lexer) Granularity {
+	name := "plaintext"
+	var aliases []string
+	if lexer != nil {
+		config := lexer.Config()
+		name = strings.ToLower(config.Name)
+		aliases = config.Aliases
+	}
+	for _, lang := range append([]string{name}, aliases...) {
+		if g, ok := m.intraline[strings.ToLower(lang)]; ok {
+			return Granularity(g)
+		}
+	}
+	if g, ok := m.intraline["default"]; ok {
+		return Granularity(g)
+	}
+	if proseLanguages[name] {
+		return GranularityWord
+	}
+	return GranularityToken
+}
+
+// findPartners pairs the deleted and added lines of each change block as
+// they're shown side by side. It returns the content of each line's
+// partner, keyed like moved lines.
+func findPartners(diff *git.FileDiff) map[movedKey]string {
+	if diff == nil {
+		return nil
+	}
+
+	partners := make(map[movedKey]string)
+	for _, hunk := range diff.Hunks {
+		var dels, adds []git.DiffLine
+		flush := func() {
+			for _, row := range alignedRows(dels, adds) {
+				d, a := row[0], row[1]
+				if d >= 0 && a >= 0 {
+					partners[movedKey{git.DiffLineDeletion, dels[d].OldLineNum}] = adds[a].Content
+					partners[movedKey{git.DiffLineAddition, adds[a].NewLineNum}] = dels[d].Content
+				}
+			}
+			dels, adds = nil, nil
+		}
+
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case git.DiffLineDeletion:
+				dels = append(dels, line)
+			case git.DiffLineAddition:
+				adds = append(adds, line)
+			default:
+				flush()
+			}
+		}
+		flush()
+	}
+	return partners
+}
+
+// changedSpans returns the parts of a changed line that differ from its
+// partner on the other side, or nil when it has none or too much changed
+func (m Model) changedSpans(lineType git.DiffLineType, lineNum int, content string) []span {
+	if m.granularity == GranularityOff || lineType == git.DiffLineContext || lineType == git.DiffLineHeader {
+		return nil
+	}
+	partner, ok := m.partners[movedKey{lineType, lineNum}]
+	if !ok {
+		return nil
+	}
+	line := m.units(content)
+	other := m.units(partner)
+
+	// Only the middle between the common prefix and suffix needs comparing
+	prefix := 0
+	for prefix < len(line) && prefix < len(other) && line[prefix].text == other[prefix].text {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(line)-prefix && suffix < len(other)-prefix &&
+		line[len(line)-1-suffix].text == other[len(other)-1-suffix].text {
+		suffix++
+	}
+	a := line[prefix : len(line)-suffix]
+	b := other[prefix : len(other)-suffix]
+	if len(a) == 0 || len(a) > maxIntralineUnits || len(b) > maxIntralineUnits {
+		return nil
+	}
+
+	changed := changedUnits(a, b)
+	var spans []span
+	width, total := 0, 0
+	for _, u := range line {
+		if strings.TrimSpace(u.text) != "" {
+			total += u.end - u.start
+		}
+	}
+	for i, u := range a {
+		if !changed[i] {
+			continue
+		}
+		width += u.end - u.start
+		// Spaces between two changed units join their spans
+		if n := len(spans); n > 0 && i > 0 && changed[i-1] {
+			spans[n-1].end = u.end
+			continue
+		}
+		if n := len(spans); n > 0 && i > 1 && changed[i-2] && strings.TrimSpace(a[i-1].text) == "" {
+			spans[n-1].end = u.end
+			continue
+		}
+		spans = append(spans, span{u.start, u.end})
+	}
+	if total == 0 || float64(width) > maxIntralineChange*float64(total) {
+		return nil
+	}
+	return spans
+}
+
+// changedUnits marks the units of a that aren't part of the longest common
+// subsequence of a and b
+func changedUnits(a, b []unit) []bool {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].text == b[j].text {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	changed := make([]bool, len(a))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i].text == b[j].text:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			changed[i] = true
+			i++
+		default:
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		changed[i] = true
+	}
+	return changed
+}
+
+// units splits a line's tab expanded content into the units compared at the
+// view's granularity
+func (m Model) units(content string) []unit {
+	content = expandTabs(strings.TrimSuffix(content, "\r"), m.tabWidth, false)
+	var units []unit
+	col := 0
+	add := func(s string) {
+		w := text.Width(s)
+		units = append(units, unit{s, col, col + w})
+		col += w
+	}
+
+	switch m.granularity {
+	case GranularityChar:
+		for _, r := range content {
+			add(string(r))
+		}
+	case GranularityToken:
+		if m.lexer == nil {
+			splitWords(content, add)
+			break
+		}
+		iterator, err := m.lexer.Tokenise(nil, content)
+		if err != nil {
+			splitWords(content, add)
+			break
+		}
+		for token := iterator(); token != chroma.EOF; token = iterator() {
+			// Comments and strings are prose within the code, and adjacent
+			// punctuation is coalesced into one token
+			if token.Type.InCategory(chroma.Comment) || token.Type.InSubCategory(chroma.LiteralString) ||
+				token.Type.InCategory(chroma.Text) || token.Type.InCategory(chroma.Punctuation) ||
+				strings.TrimSpace(token.Value) != token.Value {
+				splitWords(token.Value, add)
+			} else {
+				add(token.Value)
+			}
+		}
+	default:
+		splitWords(content, add)
+	}
+	return units
+}
+
+// splitWords passes the runs of letters and digits, runs of spaces, and
+// other characters of s one at a time to add
+func splitWords(s string, add func(string)) {
+	kind := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		}
+		return 0
+	}
+	start := 0
+	prev := -1
+	for i, r := range s {
+		k := kind(r)
+		if i > start && (k != prev || k == 0) {
+			add(s[start:i])
+			start = i
+		}
+		prev = k
+	}
+	if start < len(s) {
+		add(s[start:])
+	}
+}
+
+// writeEmphasized writes s, which starts at cell column col of its line,
+// in style, emphasizing the parts within spans
+func writeEmphasized(b *strings.Builder, s string, col int, spans []span, style lipgloss.Style, lineType git.DiffLineType) {
+	if len(spans) == 0 {
+		b.WriteString(style.Render(s))
+		return
+	}
+	emph := style.Underline(true)
+	if ui.HasTints() {
+		emph = style.Background(ui.ColorDeletionEmphBg)
+		if lineType == git.DiffLineAddition {
+			emph = style.Background(ui.ColorAdditionEmphBg)
+		}
+	}
+
+	end := col + text.Width(s)
+	pos := col
+	for _, sp := range spans {
+		if sp.end <= pos || sp.start >= end {
+			continue
+		}
+		if start := max(sp.start, pos); start > pos {
+			b.WriteString(style.Render(text.Columns(s, pos-col, start-col)))
+			pos = start
+		}
+		stop := min(sp.end, end)
+		b.WriteString(emph.Render(text.Columns(s, pos-col, stop-col)))
+		pos = stop
+	}
+	if pos < end {
+		b.WriteString(style.Render(text.Columns(s, pos-col, end-col)))
+	}
+}
@@ -0,0 +1,113 @@
+package diffview
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+)
+
+// minMovedLines is the shortest run of lines counted as moved; shorter runs
+// are mostly coincidences such as closing braces
+const minMovedLines = 3
+
+// maxMovedCandidates bounds the places a line is looked up in, so files full
+// of repeated lines stay quick to index
+const maxMovedCandidates = 64
+
+// movedKey identifies a deleted or added line by its side and line number
+type movedKey struct {
+	lineType git.DiffLineType
+	lineNum  int
+}
+
+// findMoved finds blocks of lines deleted in one place and added verbatim in
+// another, within or across hunks. Each moved line maps to the index of its
+// block so that adjacent blocks can alternate colors, like git's
+// --color-moved=zebra.
+func findMoved(diff *git.FileDiff) map[movedKey]int {
+	if diff == nil {
+		return nil
+	}
+
+	// Runs number the uninterrupted stretches of deletions and additions;
+	// a moved block can't span two of them
+	var dels, adds []git.DiffLine
+	var delRun, addRun []int
+	run := 0
+	for _, hunk := range diff.Hunks {
+		prev := git.DiffLineHeader
+		for _, line := range hunk.Lines {
+			if line.Type != prev {
+				run++
+				prev = line.Type
+			}
+			switch line.Type {
+			case git.DiffLineDeletion:
+				dels = append(dels, line)
+				delRun = append(delRun, run)
+			case git.DiffLineAddition:
+				adds = append(adds, line)
+				addRun = append(addRun, run)
+			}
+		}
+	}
+	if len(dels) < minMovedLines || len(adds) < minMovedLines {
+		return nil
+	}
+
+	// Blank lines can continue a block but not start one
+	index := make(map[string][]int)
+	for j, line := range dels {
+		if strings.TrimSpace(line.Content) != "" && len(index[line.Content]) < maxMovedCandidates {
+			index[line.Content] = append(index[line.Content], j)
+		}
+	}
+
+	moved := make(map[movedKey]int)
+	taken := make([]bool, len(dels))
+	block := 0
+	for i := 0; i < len(adds); {
+		best, bestStart := 0, 0
+		for _, j := range index[adds[i].Content] {
+			n := 0
+			for i+n < len(adds) && j+n < len(dels) &&
+				addRun[i+n] == addRun[i] && delRun[j+n] == delRun[j] &&
+				!taken[j+n] && adds[i+n].Content == dels[j+n].Content {
+				n++
+			}
+			if n > best {
+				best, bestStart = n, j
+			}
+		}
+		if best < minMovedLines {
+			i++
+			continue
+		}
+
+		for k := range best {
+			taken[bestStart+k] = true
+			moved[movedKey{git.DiffLineDeletion, dels[bestStart+k].OldLineNum}] = block
+			moved[movedKey{git.DiffLineAddition, adds[i+k].NewLineNum}] = block
+		}
+		block++
+		i += best
+	}
+	return moved
+}
+
+// movedColors returns the background and text colors of a line in a moved
+// block, alternating shades between adjacent blocks
+func movedColors(lineType git.DiffLineType, block int) (lipgloss.TerminalColor, lipgloss.TerminalColor) {
+	if lineType == git.DiffLineDeletion {
+		if block%2 == 1 {
+			return ui.ColorMovedDelAltBg, ui.ColorMovedDelFg
+		}
+		return ui.ColorMovedDelBg, ui.ColorMovedDelFg
+	}
+	if block%2 == 1 {
+		return ui.ColorMovedAddAltBg, ui.ColorMovedAddFg
+	}
+	return ui.ColorMovedAddBg, ui.ColorMovedAddFg
+}
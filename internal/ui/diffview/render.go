@@ -0,0 +1,52 @@
+package diffview
+
+// renderSide identifies which half of a row was rendered, and for which view
+type renderSide int
+
+const (
+	sideOld renderSide = iota // Left half of the side-by-side view
+	sideNew                   // Right half of the side-by-side view
+	fullOld                   // Row of the Old view
+	fullNew                   // Row of the New view
+)
+
+// renderKey identifies a rendered row
+type renderKey struct {
+	row  int
+	side renderSide
+}
+
+// renderCache memoizes syntax highlighted rows. A row's rendering depends
+// only on the diff, the pane width and the style, so it is cleared when any
+// of those change.
+type renderCache map[renderKey]string
+
+// maxRendered is the number of rendered rows kept before rows far from the
+// viewport are dropped
+const maxRendered = 1000
+
+// prune drops rendered rows outside [start, end) once the cache is full, so
+// only rows around the viewport stay styled
+func (c renderCache) prune(start, end int) {
+	if len(c) <= maxRendered {
+		return
+	}
+	for key := range c {
+		if key.row < start || key.row >= end {
+			delete(c, key)
+		}
+	}
+}
+
+// cached returns the rendered row for key, rendering it on first use
+func (m Model) cached(key renderKey, render func() string) string {
+	if m.rendered == nil {
+		return render()
+	}
+	if s, ok := m.rendered[key]; ok {
+		return s
+	}
+	s := render()
+	m.rendered[key] = s
+	return s
+}
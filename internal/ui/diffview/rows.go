@@ -0,0 +1,377 @@
+package diffview
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// segment is a run of rows built from consecutive lines of a hunk: a header
+// line, a context line, or a block of deletions and additions that are
+// aligned side by side
+type segment struct {
+	line   git.DiffLine   // Header and context segments
+	dels   []git.DiffLine // Change blocks
+	adds   []git.DiffLine // Change blocks
+	elided []git.DiffLine // Context lines folded into one row
+	thread *Thread        // Comment thread shown below its hunk
+	open   bool           // The thread shows its comments, not just a summary
+	block  bool
+	pairs  [][2]int // Side-by-side rows of a change block; nil pairs by position
+	hunk   int      // Index of the hunk the segment belongs to
+	start  [3]int   // First row of the segment in each view mode
+}
+
+// elideKeep is the number of unchanged lines kept around a change when the
+// run of unchanged lines they belong to is folded
+const elideKeep = 3
+
+// rows returns the number of rows the segment occupies in a view mode
+func (s segment) rows(mode ViewMode) int {
+	if s.thread != nil && s.open {
+		return 1 + len(s.thread.Comments)
+	}
+	if !s.block {
+		return 1
+	}
+	switch mode {
+	case ViewNew:
+		return len(s.adds)
+	case ViewOld:
+		return len(s.dels)
+	default:
+		if s.pairs != nil {
+			return len(s.pairs)
+		}
+		return max(len(s.dels), len(s.adds))
+	}
+}
+
+// pair returns the deletion and addition shown on side-by-side row k of a
+// change block, or -1 where the row has none
+func (s segment) pair(k int) (del, add int) {
+	if s.pairs != nil {
+		return s.pairs[k][0], s.pairs[k][1]
+	}
+	del, add = k, k
+	if del >= len(s.dels) {
+		del = -1
+	}
+	if add >= len(s.adds) {
+		add = -1
+	}
+	return del, add
+}
+
+// rowIndex maps view rows onto the parsed diff lines. Rows are built on
+// demand so huge diffs cost one segment per change block rather than a
+// SideBySideLine per line.
+type rowIndex struct {
+	segs  []segment
+	total [3]int // Number of rows in each view mode
+}
+
+// rowOptions are the view state that shapes the rows of a diff
+type rowOptions struct {
+	// Runs of more than elide unchanged lines fold into one row, except
+	// those whose first folded line's new line number is in expanded; zero
+	// folds none
+	elide    int
+	expanded map[int]bool
+	// Threads follow their hunk, showing their comments when their ID is in
+	// open
+	threads []Thread
+	open    map[int]bool
+}
+
+// newRowIndex groups the hunk lines of diff into segments
+func newRowIndex(diff *git.FileDiff, opts rowOptions) *rowIndex {
+	x := &rowIndex{}
+	if diff == nil {
+		return x
+	}
+
+	for h, hunk := range diff.Hunks {
+		block := segment{hunk: h}
+		flush := func() {
+			if len(block.dels) > 0 || len(block.adds) > 0 {
+				block.block = true
+				block.pairs = align(block.dels, block.adds)
+				x.add(block)
+			}
+			block = segment{hunk: h}
+		}
+		// Unchanged lines [ctx, i) are added once the run ends
+		ctx := -1
+		flushContext := func(i int) {
+			if ctx < 0 {
+				return
+			}
+			start := ctx
+			run := hunk.Lines[start:i]
+			ctx = -1
+			// Keep the lines next to a change, not to the file's edges
+			lead, trail := 0, 0
+			if start > 0 && isChange(hunk.Lines[start-1]) {
+				lead = elideKeep
+			}
+			if i < len(hunk.Lines) && isChange(hunk.Lines[i]) {
+				trail = elideKeep
+			}
+			if opts.elide > 0 && len(run) > opts.elide && len(run)-lead-trail > 1 &&
+				!opts.expanded[run[lead].NewLineNum] {
+				for _, line := range run[:lead] {
+					x.add(segment{line: line, hunk: h})
+				}
+				x.add(segment{elided: run[lead : len(run)-trail], hunk: h})
+				run = run[len(run)-trail:]
+			}
+			for _, line := range run {
+				x.add(segment{line: line, hunk: h})
+			}
+		}
+
+		for i, line := range hunk.Lines {
+			switch line.Type {
+			case git.DiffLineHeader:
+				flush()
+				flushContext(i)
+				x.add(segment{line: line, hunk: h})
+			case git.DiffLineContext:
+				flush()
+				if ctx < 0 {
+					ctx = i
+				}
+			case git.DiffLineDeletion:
+				flushContext(i)
+				block.dels = appendLine(block.dels, hunk.Lines, i)
+			case git.DiffLineAddition:
+				flushContext(i)
+				block.adds = appendLine(block.adds, hunk.Lines, i)
+			}
+		}
+		flush()
+		flushContext(len(hunk.Lines))
+
+		for t := range opts.threads {
+			if thread := &opts.threads[t]; thread.Hunk == h {
+				x.add(segment{thread: thread, open: opts.open[thread.ID], hunk: h})
+			}
+		}
+	}
+	return x
+}
+
+// isChange reports whether line is an addition or deletion
+func isChange(line git.DiffLine) bool {
+	return line.Type == git.DiffLineAddition || line.Type == git.DiffLineDeletion
+}
+
+// appendLine appends lines[i] to run, sharing the backing array with lines
+// while run is a contiguous slice of it
+func appendLine(run, lines []git.DiffLine, i int) []git.DiffLine {
+	if len(run) == 0 {
+		return lines[i : i+1 : i+1]
+	}
+	if start := i - len(run); start >= 0 && &lines[start] == &run[0] {
+		return lines[start : i+1 : i+1]
+	}
+	return append(run, lines[i])
+}
+
+func (x *rowIndex) add(s segment) {
+	for mode := range x.total {
+		s.start[mode] = x.total[mode]
+		x.total[mode] += s.rows(ViewMode(mode))
+	}
+	x.segs = append(x.segs, s)
+}
+
+// count returns the number of rows in a view mode
+func (x *rowIndex) count(mode ViewMode) int {
+	if x == nil {
+		return 0
+	}
+	return x.total[mode]
+}
+
+// find returns the index of the segment holding a row of a view mode
+func (x *rowIndex) find(mode ViewMode, row int) int {
+	return sort.Search(len(x.segs), func(i int) bool {
+		s := x.segs[i]
+		return s.start[mode]+s.rows(mode) > row
+	})
+}
+
+// orig converts a row of the New or Old view to its side-by-side row
+func (x *rowIndex) orig(mode ViewMode, row int) int {
+	s := x.segs[x.find(mode, row)]
+	k := row - s.start[mode]
+	if s.pairs != nil {
+		side := 0
+		if mode == ViewNew {
+			side = 1
+		}
+		for r, p := range s.pairs {
+			if p[side] == k {
+				return s.start[ViewBoth] + r
+			}
+		}
+	}
+	return s.start[ViewBoth] + k
+}
+
+// hunk returns the index of the hunk side-by-side row i belongs to
+func (x *rowIndex) hunk(i int) int {
+	return x.segs[x.find(ViewBoth, i)].hunk
+}
+
+// at builds side-by-side row i
+func (x *rowIndex) at(i int) SideBySideLine {
+	s := x.segs[x.find(ViewBoth, i)]
+	if s.thread != nil {
+		// Searchable, but drawn by renderThread
+		content := threadRow(s.thread, s.open, i-s.start[ViewBoth])
+		return SideBySideLine{
+			OldContent: content,
+			OldType:    git.DiffLineHeader,
+			NewContent: content,
+			NewType:    git.DiffLineHeader,
+		}
+	}
+	if len(s.elided) > 0 {
+		label := fmt.Sprintf("··· %d unchanged lines ···", len(s.elided))
+		return SideBySideLine{
+			OldContent: label,
+			OldType:    git.DiffLineHeader,
+			NewContent: label,
+			NewType:    git.DiffLineHeader,
+		}
+	}
+	if !s.block {
+		line := SideBySideLine{
+			OldContent: s.line.Content,
+			OldType:    s.line.Type,
+			NewContent: s.line.Content,
+			NewType:    s.line.Type,
+		}
+		if s.line.Type == git.DiffLineContext {
+			line.OldLineNum = s.line.OldLineNum
+			line.NewLineNum = s.line.NewLineNum
+		}
+		return line
+	}
+
+	del, add := s.pair(i - s.start[ViewBoth])
+	var line SideBySideLine
+	if del >= 0 {
+		line.OldLineNum = s.dels[del].OldLineNum
+		line.OldContent = s.dels[del].Content
+		line.OldType = git.DiffLineDeletion
+	}
+	if add >= 0 {
+		line.NewLineNum = s.adds[add].NewLineNum
+		line.NewContent = s.adds[add].Content
+		line.NewType = git.DiffLineAddition
+	}
+	return line
+}
+
+// fold returns the new line number identifying the folded unchanged lines
+// on side-by-side row i, if it is one
+func (x *rowIndex) fold(i int) (int, bool) {
+	if i < 0 || i >= x.count(ViewBoth) {
+		return 0, false
+	}
+	s := x.segs[x.find(ViewBoth, i)]
+	if len(s.elided) == 0 {
+		return 0, false
+	}
+	return s.elided[0].NewLineNum, true
+}
+
+// foldHolding returns the fold hiding the unchanged line with new line
+// number line, or old line number when old is set, if any
+func (x *rowIndex) foldHolding(line int, old bool) (int, bool) {
+	if x == nil {
+		return 0, false
+	}
+	for _, s := range x.segs {
+		if len(s.elided) == 0 {
+			continue
+		}
+		first, last := s.elided[0], s.elided[len(s.elided)-1]
+		if (!old && first.NewLineNum <= line && line <= last.NewLineNum) ||
+			(old && first.OldLineNum <= line && line <= last.OldLineNum) {
+			return first.NewLineNum, true
+		}
+	}
+	return 0, false
+}
+
+// thread returns the comment thread shown on side-by-side row i, the row's
+// offset within the thread's rows and whether the thread is open
+func (x *rowIndex) thread(i int) (*Thread, int, bool) {
+	if i < 0 || i >= x.count(ViewBoth) {
+		return nil, 0, false
+	}
+	s := x.segs[x.find(ViewBoth, i)]
+	return s.thread, i - s.start[ViewBoth], s.open
+}
+
+// changes reports the change kinds in rows [start, end) of a view mode
+func (x *rowIndex) changes(mode ViewMode, start, end int) int {
+	kind := 0
+	for i := x.find(mode, start); i < len(x.segs); i++ {
+		s := x.segs[i]
+		if s.start[mode] >= end {
+			break
+		}
+		if !s.block || s.rows(mode) == 0 {
+			continue
+		}
+		// Rows of a block paired by position hold a deletion while k < dels
+		// and an addition while k < adds, so only the first overlapping row
+		// matters
+		k := max(start-s.start[mode], 0)
+		switch mode {
+		case ViewNew:
+			kind |= changeAdd
+		case ViewOld:
+			kind |= changeDel
+		case ViewBoth:
+			if s.pairs != nil {
+				// Aligned blocks are small, so check every overlapping row
+				last := min(end-s.start[mode], len(s.pairs))
+				for r := k; r < last; r++ {
+					if s.pairs[r][0] >= 0 {
+						kind |= changeDel
+					}
+					if s.pairs[r][1] >= 0 {
+						kind |= changeAdd
+					}
+				}
+				continue
+			}
+			if k < len(s.dels) {
+				kind |= changeDel
+			}
+			if k < len(s.adds) {
+				kind |= changeAdd
+			}
+		}
+	}
+	return kind
+}
+
+// Rows returns all side-by-side rows of diff, aligned as the Both view
+// shows them
+func Rows(diff *git.FileDiff) []SideBySideLine {
+	x := newRowIndex(diff, rowOptions{})
+	rows := make([]SideBySideLine, x.count(ViewBoth))
+	for i := range rows {
+		rows[i] = x.at(i)
+	}
+	return rows
+}
@@ -0,0 +1,69 @@
+package diffview
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+)
+
+// Change kinds shown as ticks on the scrollbar
+const (
+	changeAdd = 1 << iota
+	changeDel
+)
+
+// scrollbar renders one cell per content row. Each cell covers a slice of
+// the file: the thumb marks the visible part and the color marks additions
+// (green), deletions (red) or both (yellow) within that slice.
+func (m Model) scrollbar(height int) []string {
+	total := m.rows.count(m.viewMode)
+	cells := make([]string, height)
+
+	for r := range cells {
+		start := r * total / height
+		end := max((r+1)*total/height, start+1)
+		if start >= total {
+			cells[r] = " "
+			continue
+		}
+		end = min(end, total)
+
+		change := m.rows.changes(m.viewMode, start, end)
+		inThumb := start < m.offset+height && end > m.offset
+
+		glyph := "│"
+		color := ui.ColorSurface
+		if inThumb {
+			glyph = "┃"
+			color = ui.ColorMuted
+		}
+		switch change {
+		case changeAdd:
+			color = ui.ColorSuccess
+		case changeDel:
+			color = ui.ColorDanger
+		case changeAdd | changeDel:
+			color = ui.ColorWarning
+		}
+		cells[r] = lipgloss.NewStyle().Foreground(color).Render(glyph)
+	}
+	return cells
+}
+
+// addScrollbar pads the content rows, which follow the two column header
+// lines of the view, to width and appends the scrollbar
+func (m Model) addScrollbar(lines []string, width, height int) []string {
+	for i, cell := range m.scrollbar(height) {
+		idx := i + 2
+		for len(lines) <= idx {
+			lines = append(lines, "")
+		}
+		row := lines[idx]
+		if w := lipgloss.Width(row); w < width {
+			row += strings.Repeat(" ", width-w)
+		}
+		lines[idx] = row + cell
+	}
+	return lines
+}
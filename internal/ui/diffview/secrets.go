@@ -0,0 +1,44 @@
+package diffview
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// maxSecretsListed caps the lines named in the secrets banner
+const maxSecretsListed = 3
+
+// findSecrets describes the added lines of diff that look like credentials,
+// for the banner above the diff. Empty when there are none.
+func findSecrets(diff *git.FileDiff) string {
+	if diff == nil {
+		return ""
+	}
+	var found []string
+	total := 0
+	for _, hunk := range diff.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Type != git.DiffLineAddition {
+				continue
+			}
+			kind := git.SecretKind(line.Content)
+			if kind == "" {
+				continue
+			}
+			total++
+			if len(found) < maxSecretsListed {
+				found = append(found, fmt.Sprintf("%s on line %d", kind, line.NewLineNum))
+			}
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	banner := "Possible credentials added: " + strings.Join(found, ", ")
+	if more := total - len(found); more > 0 {
+		banner += fmt.Sprintf(" and %d more", more)
+	}
+	return banner
+}
@@ -0,0 +1,72 @@
+package diffview
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
+)
+
+// Thread is a discussion on a hunk, shown below it. Closed threads take one
+// row summing them up; Enter opens them to show every comment.
+type Thread struct {
+	ID       int
+	Hunk     int      // Index of the hunk in the displayed diff
+	Comments []string // The first comment and its replies, oldest first
+}
+
+// SetThreads sets the comment threads of the displayed diff
+func (m *Model) SetThreads(threads []Thread) {
+	m.threads = threads
+	m.rebuildRows()
+}
+
+// OpenThread shows the comments of a thread, e.g. after replying to it
+func (m *Model) OpenThread(id int) {
+	m.openThreads[id] = true
+	m.rebuildRows()
+}
+
+// CursorThread returns the ID of the thread under the cursor
+func (m Model) CursorThread() (int, bool) {
+	thread, _, _ := m.rows.thread(m.cursor)
+	if thread == nil {
+		return 0, false
+	}
+	return thread.ID, true
+}
+
+// threadRow returns the text of row k of a thread: the summary, then one
+// row per comment when open
+func threadRow(thread *Thread, open bool, k int) string {
+	if k == 0 {
+		n := len(thread.Comments)
+		summary := "1 comment"
+		if n != 1 {
+			summary = fmt.Sprintf("%d comments", n)
+		}
+		if open {
+			return "▾ Thread · " + summary
+		}
+		first := ""
+		if n > 0 {
+			first = ": " + thread.Comments[0]
+		}
+		return "▸ Thread · " + summary + first
+	}
+	if k == 1 {
+		return "  │ " + thread.Comments[0]
+	}
+	return "  │ ↳ " + thread.Comments[k-1]
+}
+
+// renderThread draws row k of a thread across the full width of the view
+func (m Model) renderThread(thread *Thread, open bool, k, width int) string {
+	style := ui.CommentStyle
+	if k > 0 {
+		style = style.Bold(false)
+	}
+	row := strings.ReplaceAll(threadRow(thread, open, k), "\t", " ")
+	return style.Render(text.Fit(row, width))
+}
@@ -0,0 +1,67 @@
+package diffview
+
+import (
+	"strings"
+
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// findTrivial finds the changed lines that don't change any code: added or
+// deleted blank lines, and lines replaced by one differing only in
+// whitespace, such as reindented code. It returns the lines, keyed like
+// moved lines, and the number of changes they make up, a replaced pair
+// counting once.
+func findTrivial(diff *git.FileDiff) (map[movedKey]bool, int) {
+	if diff == nil {
+		return nil, 0
+	}
+
+	trivial := make(map[movedKey]bool)
+	changes := 0
+	for _, hunk := range diff.Hunks {
+		var dels, adds []git.DiffLine
+		flush := func() {
+			// Deletions and additions pair up as they're shown side by side
+			for _, row := range alignedRows(dels, adds) {
+				d, a := row[0], row[1]
+				switch {
+				case d >= 0 && a >= 0:
+					if squeeze(dels[d].Content) == squeeze(adds[a].Content) {
+						trivial[movedKey{git.DiffLineDeletion, dels[d].OldLineNum}] = true
+						trivial[movedKey{git.DiffLineAddition, adds[a].NewLineNum}] = true
+						changes++
+					}
+				case d >= 0:
+					if strings.TrimSpace(dels[d].Content) == "" {
+						trivial[movedKey{git.DiffLineDeletion, dels[d].OldLineNum}] = true
+						changes++
+					}
+				default:
+					if strings.TrimSpace(adds[a].Content) == "" {
+						trivial[movedKey{git.DiffLineAddition, adds[a].NewLineNum}] = true
+						changes++
+					}
+				}
+			}
+			dels, adds = nil, nil
+		}
+
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case git.DiffLineDeletion:
+				dels = append(dels, line)
+			case git.DiffLineAddition:
+				adds = append(adds, line)
+			default:
+				flush()
+			}
+		}
+		flush()
+	}
+	return trivial, changes
+}
+
+// squeeze removes all whitespace from s
+func squeeze(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
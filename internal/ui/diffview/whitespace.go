@@ -0,0 +1,59 @@
+package diffview
+
+import (
+	"strings"
+
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
+)
+
+// defaultTabWidth is used when no tab width is configured
+const defaultTabWidth = 4
+
+// crMarker stands in for the carriage return of a CRLF line ending, which
+// would otherwise send the cursor back to the start of the row
+const crMarker = "␍"
+
+// expandTabs replaces tabs with spaces up to the next tab stop so both
+// sides of the diff stay aligned. When visible is set, tabs start with →
+// and trailing spaces are shown as ·. A CRLF line ending is always shown
+// as ␍.
+func expandTabs(s string, tabWidth int, visible bool) string {
+	if tabWidth <= 0 {
+		tabWidth = defaultTabWidth
+	}
+	s, crlf := strings.CutSuffix(s, "\r")
+	eol := ""
+	if crlf {
+		eol = crMarker
+	}
+	if !visible && !strings.Contains(s, "\t") {
+		return s + eol
+	}
+
+	body := s
+	trailing := 0
+	if visible {
+		body = strings.TrimRight(s, " ")
+		trailing = len(s) - len(body)
+	}
+
+	var b strings.Builder
+	col := 0
+	for _, r := range body {
+		if r != '\t' {
+			b.WriteRune(r)
+			col += text.Width(string(r))
+			continue
+		}
+		n := tabWidth - col%tabWidth
+		col += n
+		if visible {
+			b.WriteString("→")
+			n--
+		}
+		b.WriteString(strings.Repeat(" ", n))
+	}
+	b.WriteString(strings.Repeat("·", trailing))
+	b.WriteString(eol)
+	return b.String()
+}
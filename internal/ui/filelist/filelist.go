@@ -2,8 +2,11 @@ package filelist
 
 import (
 	"fmt"
+	"maps"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -12,6 +15,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/matthewmyrick/git-diffs/internal/git"
 	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
 	"github.com/sahilm/fuzzy"
 )
 
@@ -22,8 +26,17 @@ const (
 	ViewFolder ViewMode = iota // Files in tree structure
 	ViewType                   // Files grouped by change type
 	ViewRaw                    // Flat list
+	ViewTop                    // Files grouped by top-level directory
 )
 
+// generatedGroup is the expandedDirs key of the group holding generated
+// files. It can't clash with a folder path.
+const generatedGroup = "\x00generated"
+
+// rootGroup is the expandedDirs key of the top-level view's group of files
+// outside any directory
+const rootGroup = "\x00root"
+
 // FileSelectMsg is sent when a file is selected with Enter
 type FileSelectMsg struct {
 	File *git.ChangedFile
@@ -31,30 +44,42 @@ type FileSelectMsg struct {
 
 // DisplayItem represents an item in the display list
 type DisplayItem struct {
-	IsFolder    bool
-	IsExpanded  bool
-	FolderPath  string
-	File        *git.ChangedFile
-	Indent      int
+	IsFolder     bool
+	IsExpanded   bool
+	FolderPath   string
+	File         *git.ChangedFile
+	Indent       int
 	IsTypeHeader bool
-	TypeHeader  string
+	TypeHeader   string
+	Additions    int    // Lines added (aggregated for folders)
+	Deletions    int    // Lines deleted (aggregated for folders)
+	Label        string // Shown instead of the folder name, e.g. for groups
+	Number       int    // Position among the listed files, from 1; 0 for others
 }
 
 // Model represents the file list component
 type Model struct {
-	files          []git.ChangedFile
-	displayItems   []DisplayItem
-	expandedDirs   map[string]bool
-	cursor         int
-	offset         int
-	width          int
-	height         int
-	focused        bool
-	selected       int
-	viewMode       ViewMode
-	searching      bool
-	searchInput    textinput.Model
-	searchQuery    string
+	files        []git.ChangedFile
+	displayItems []DisplayItem
+	expandedDirs map[string]bool
+	cursor       int
+	offset       int
+	width        int
+	height       int
+	focused      bool
+	selected     int
+	viewMode     ViewMode
+	searching    bool
+	searchInput  textinput.Model
+	searchQuery  string
+	matchOpts    ui.MatchOptions
+	matchCount   int
+	loading      string      // Placeholder shown while the files load
+	icons        bool        // Show Nerd Font file and folder icons
+	link         ui.LinkFunc // Hyperlinks file names when set
+	numbers      bool        // Show each file's number for jumping to it
+	order        Order       // Order of the files within their folder or group
+	sizeBadges   bool        // Show each file's size class, even when ordered by path
 }
 
 // New creates a new file list model
@@ -75,14 +100,63 @@ func New() Model {
 
 // SetFiles sets the list of files to display
 func (m *Model) SetFiles(files []git.ChangedFile) {
+	// Remember our place so reloading the changeset doesn't lose it
+	prevPath, prevFolder := m.CursorPath()
+	prevOffset := m.offset
+	prevExpanded := m.expandedDirs
+
 	m.files = files
 	m.cursor = 0
 	m.offset = 0
 	m.searchQuery = ""
 
-	// Expand all directories by default
+	// Expand all directories by default, keeping folders the user collapsed
 	m.expandedDirs = make(map[string]bool)
-	for _, f := range files {
+	m.setAllExpanded(true)
+	for dir, expanded := range prevExpanded {
+		if _, ok := m.expandedDirs[dir]; ok {
+			m.expandedDirs[dir] = expanded
+		}
+	}
+	// Generated files stay collapsed until asked for
+	m.expandedDirs[generatedGroup] = prevExpanded[generatedGroup]
+	// The top-level view's files outside any folder start expanded
+	rootExpanded, seen := prevExpanded[rootGroup]
+	m.expandedDirs[rootGroup] = rootExpanded || !seen
+
+	m.rebuildDisplayItems()
+	if prevPath == "" {
+		m.findFirstFile()
+		return
+	}
+
+	m.offset = prevOffset
+	if maxOffset := len(m.displayItems) - m.visibleLines(); m.offset > maxOffset {
+		m.offset = max(maxOffset, 0)
+	}
+	m.focusItem(prevPath, prevFolder)
+}
+
+// ExpandAll expands or collapses every folder of the folder view, or every
+// group of the top-level view, keeping the cursor on its item or the folder
+// it ends up in
+func (m *Model) ExpandAll(expanded bool) {
+	if m.viewMode != ViewFolder && m.viewMode != ViewTop {
+		return
+	}
+	path, isFolder := m.CursorPath()
+	if m.viewMode == ViewTop && !expanded {
+		path, isFolder = m.cursorGroup(), true
+	}
+	m.setAllExpanded(expanded)
+	m.expandedDirs[rootGroup] = expanded
+	m.rebuildDisplayItems()
+	m.focusItem(path, isFolder)
+}
+
+// setAllExpanded expands or collapses every directory containing a file
+func (m *Model) setAllExpanded(expanded bool) {
+	for _, f := range m.files {
 		parts := strings.Split(filepath.Dir(f.Path), string(filepath.Separator))
 		path := ""
 		for _, part := range parts {
@@ -94,19 +168,168 @@ func (m *Model) SetFiles(files []git.ChangedFile) {
 			} else {
 				path = path + string(filepath.Separator) + part
 			}
-			m.expandedDirs[path] = true
+			m.expandedDirs[path] = expanded
 		}
 	}
+}
 
+// focusItem moves the cursor to the folder or file with the given path. If
+// the file is hidden inside a collapsed folder, the outermost collapsed
+// folder containing it is focused instead.
+func (m *Model) focusItem(path string, isFolder bool) {
+	for i, di := range m.displayItems {
+		if (isFolder && di.IsFolder && di.FolderPath == path) ||
+			(!isFolder && di.File != nil && di.File.Path == path) {
+			m.SetCursor(i)
+			return
+		}
+	}
+	for i, di := range m.displayItems {
+		if di.IsFolder && !di.IsExpanded && strings.HasPrefix(path, di.FolderPath+string(filepath.Separator)) {
+			m.SetCursor(i)
+			return
+		}
+	}
+	m.findFirstFile()
+}
+
+// focusGroup moves the cursor to the generated files group
+func (m *Model) focusGroup() {
+	for i, di := range m.displayItems {
+		if di.IsFolder && di.FolderPath == generatedGroup {
+			m.SetCursor(i)
+			return
+		}
+	}
+}
+
+// CursorPath returns the path of the item under the cursor and whether it
+// is a folder. The path is empty when the cursor is on a header.
+func (m Model) CursorPath() (string, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.displayItems) {
+		return "", false
+	}
+	item := m.displayItems[m.cursor]
+	if item.IsFolder && (item.FolderPath == generatedGroup || item.FolderPath == rootGroup) {
+		return "", false
+	}
+	if item.IsFolder {
+		return item.FolderPath, true
+	}
+	if item.File != nil {
+		return item.File.Path, false
+	}
+	return "", false
+}
+
+// SetLoading shows a placeholder while the list is empty and files load
+func (m *Model) SetLoading(text string) {
+	m.loading = text
+}
+
+// SetViewMode switches the list to the given view mode
+func (m *Model) SetViewMode(mode ViewMode) {
+	m.viewMode = mode
 	m.rebuildDisplayItems()
+	m.cursor = 0
+	m.offset = 0
 	m.findFirstFile()
 }
 
+// SetIcons sets whether files and folders are shown with Nerd Font icons
+func (m *Model) SetIcons(show bool) {
+	m.icons = show
+}
+
+// SetNumbers sets whether files are shown with their numbers
+func (m *Model) SetNumbers(show bool) {
+	m.numbers = show
+}
+
+// Numbers returns whether files are shown with their numbers
+func (m Model) Numbers() bool {
+	return m.numbers
+}
+
+// SetOrder lists the files in the given order, keeping the cursor on the
+// same item
+func (m *Model) SetOrder(order Order) {
+	path, isFolder := m.CursorPath()
+	m.order = order
+	m.rebuildDisplayItems()
+	m.focusItem(path, isFolder)
+}
+
+// Order returns the order the files are listed in
+func (m Model) Order() Order {
+	return m.order
+}
+
+// SetSizeBadges sets whether the size class of every file is shown. They
+// are always shown while the files are ordered by size.
+func (m *Model) SetSizeBadges(show bool) {
+	m.sizeBadges = show
+}
+
+// JumpToNumber moves the cursor to the nth listed file and returns it, or
+// nil when there are fewer files
+func (m *Model) JumpToNumber(n int) *git.ChangedFile {
+	for i, item := range m.displayItems {
+		if item.Number == n && n > 0 {
+			m.SetCursor(i)
+			return item.File
+		}
+	}
+	return nil
+}
+
+// StepFile moves the cursor to the nth file after the one under it, or
+// before it when n is negative, stopping at the first and last files. It
+// returns the file moved to, or nil when there is none that way.
+func (m *Model) StepFile(n int) *git.ChangedFile {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	target := -1
+	for i := m.cursor + step; i >= 0 && i < len(m.displayItems) && n > 0; i += step {
+		if m.displayItems[i].File != nil {
+			target = i
+			n--
+		}
+	}
+	if target < 0 {
+		return nil
+	}
+	m.SetCursor(target)
+	return m.displayItems[target].File
+}
+
+// SetLinks makes file names terminal hyperlinks to the URLs link returns,
+// or plain text when link is nil
+func (m *Model) SetLinks(link ui.LinkFunc) {
+	m.link = link
+}
+
 // SetSize sets the dimensions of the file list
 func (m *Model) SetSize(width, height int) {
+	before := m.visibleLines()
 	m.width = width
 	m.height = height
-	m.searchInput.Width = width - 6
+	m.rescale(before)
+	// Leave room for the match modifiers and count
+	m.searchInput.Width = width - 16
+}
+
+// rescale keeps the cursor at the same height within the list, in
+// proportion, after the number of visible rows changed from before
+func (m *Model) rescale(before int) {
+	visible := m.visibleLines()
+	if visible == before || len(m.displayItems) == 0 {
+		return
+	}
+	row := min(max((m.cursor-m.offset)*visible/before, 0), visible-1)
+	m.offset = max(min(m.cursor-row, len(m.displayItems)-visible), 0)
 }
 
 // SetFocused sets whether this component is focused
@@ -128,6 +351,11 @@ func (m Model) IsSearching() bool {
 	return m.searching
 }
 
+// SearchQuery returns the query the list is filtered by, if any
+func (m Model) SearchQuery() string {
+	return m.searchQuery
+}
+
 // SelectedFile returns the currently selected file
 func (m Model) SelectedFile() *git.ChangedFile {
 	if m.selected >= 0 && m.selected < len(m.displayItems) {
@@ -139,6 +367,37 @@ func (m Model) SelectedFile() *git.ChangedFile {
 	return nil
 }
 
+// Neighbors returns the file under the cursor followed by up to n files
+// after and n files before it in display order, nearest first
+func (m Model) Neighbors(n int) []git.ChangedFile {
+	var files []git.ChangedFile
+	if m.cursor >= 0 && m.cursor < len(m.displayItems) && m.displayItems[m.cursor].File != nil {
+		files = append(files, *m.displayItems[m.cursor].File)
+	}
+
+	var after, before []git.ChangedFile
+	for i := m.cursor + 1; i < len(m.displayItems) && len(after) < n; i++ {
+		if f := m.displayItems[i].File; f != nil {
+			after = append(after, *f)
+		}
+	}
+	for i := m.cursor - 1; i >= 0 && len(before) < n; i-- {
+		if f := m.displayItems[i].File; f != nil {
+			before = append(before, *f)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		if i < len(after) {
+			files = append(files, after[i])
+		}
+		if i < len(before) {
+			files = append(files, before[i])
+		}
+	}
+	return files
+}
+
 // Files returns all files
 func (m Model) Files() []git.ChangedFile {
 	return m.files
@@ -160,7 +419,16 @@ func (m *Model) rebuildDisplayItems() {
 
 	// Filter files if searching
 	files := m.files
-	if m.searchQuery != "" {
+	if m.searchQuery != "" && m.matchOpts.Literal() {
+		files = nil
+		if re, err := m.matchOpts.Compile(m.searchQuery, false); err == nil {
+			for _, f := range m.files {
+				if re.MatchString(f.Path) {
+					files = append(files, f)
+				}
+			}
+		}
+	} else if m.searchQuery != "" {
 		// Remove spaces from query to allow "greptile client" to match "greptile_client"
 		query := strings.ReplaceAll(m.searchQuery, " ", "")
 
@@ -174,6 +442,8 @@ func (m *Model) rebuildDisplayItems() {
 			files = append(files, m.files[match.Index])
 		}
 	}
+	m.matchCount = len(files)
+	files = sortByEffort(files, m.order)
 
 	switch m.viewMode {
 	case ViewFolder:
@@ -182,6 +452,16 @@ func (m *Model) rebuildDisplayItems() {
 		m.buildTypeView(files)
 	case ViewRaw:
 		m.buildRawView(files)
+	case ViewTop:
+		m.buildTopView(files)
+	}
+
+	n := 0
+	for i := range m.displayItems {
+		if item := &m.displayItems[i]; item.File != nil && !item.IsFolder {
+			n++
+			item.Number = n
+		}
 	}
 }
 
@@ -195,6 +475,8 @@ type TreeNode struct {
 }
 
 func (m *Model) buildTreeView(files []git.ChangedFile) {
+	files, generated := splitGenerated(files)
+
 	// Build tree structure
 	root := &TreeNode{
 		Name:     "",
@@ -238,6 +520,7 @@ func (m *Model) buildTreeView(files []git.ChangedFile) {
 
 	// Flatten tree to display items
 	m.flattenTree(root, 0)
+	m.addGeneratedGroup(generated)
 }
 
 func (m *Model) flattenTree(node *TreeNode, indent int) {
@@ -252,16 +535,22 @@ func (m *Model) flattenTree(node *TreeNode, indent int) {
 	}
 	sort.Strings(dirs)
 	sort.Strings(files)
+	sort.SliceStable(files, func(i, j int) bool {
+		return compareEffort(m.order, *node.Children[files[i]].File, *node.Children[files[j]].File) < 0
+	})
 
 	// Add directories
 	for _, name := range dirs {
 		child := node.Children[name]
 		expanded := m.expandedDirs[child.Path]
+		adds, dels := child.stats()
 		m.displayItems = append(m.displayItems, DisplayItem{
 			IsFolder:   true,
 			IsExpanded: expanded,
 			FolderPath: child.Path,
 			Indent:     indent,
+			Additions:  adds,
+			Deletions:  dels,
 		})
 		if expanded {
 			m.flattenTree(child, indent+1)
@@ -278,21 +567,42 @@ func (m *Model) flattenTree(node *TreeNode, indent int) {
 	}
 }
 
+// stats returns the total additions and deletions of all files under a node
+func (n *TreeNode) stats() (int, int) {
+	if n.File != nil {
+		return n.File.Additions, n.File.Deletions
+	}
+	adds, dels := 0, 0
+	for _, child := range n.Children {
+		a, d := child.stats()
+		adds += a
+		dels += d
+	}
+	return adds, dels
+}
+
 func (m *Model) buildTypeView(files []git.ChangedFile) {
+	files, generated := splitGenerated(files)
 	types := map[git.FileStatus][]git.ChangedFile{
+		git.StatusConflict: {},
 		git.StatusModified: {},
 		git.StatusAdded:    {},
 		git.StatusDeleted:  {},
+		git.StatusUnknown:  {},
 	}
 
 	for _, f := range files {
 		switch f.Status {
+		case git.StatusConflict:
+			types[git.StatusConflict] = append(types[git.StatusConflict], f)
 		case git.StatusModified:
 			types[git.StatusModified] = append(types[git.StatusModified], f)
 		case git.StatusAdded:
 			types[git.StatusAdded] = append(types[git.StatusAdded], f)
 		case git.StatusDeleted:
 			types[git.StatusDeleted] = append(types[git.StatusDeleted], f)
+		case git.StatusUnknown:
+			types[git.StatusUnknown] = append(types[git.StatusUnknown], f)
 		default:
 			types[git.StatusModified] = append(types[git.StatusModified], f)
 		}
@@ -302,9 +612,11 @@ func (m *Model) buildTypeView(files []git.ChangedFile) {
 		status git.FileStatus
 		name   string
 	}{
+		{git.StatusConflict, "Conflicts"},
 		{git.StatusModified, "Modified"},
 		{git.StatusAdded, "Added"},
 		{git.StatusDeleted, "Deleted"},
+		{git.StatusUnknown, "Untracked"},
 	}
 
 	for _, o := range order {
@@ -321,6 +633,114 @@ func (m *Model) buildTypeView(files []git.ChangedFile) {
 			}
 		}
 	}
+	m.addGeneratedGroup(generated)
+}
+
+// topDir returns the top-level directory of path, or "" for a file outside
+// any directory
+func topDir(path string) string {
+	dir, _, found := strings.Cut(path, string(filepath.Separator))
+	if !found {
+		return ""
+	}
+	return dir
+}
+
+// buildTopView groups the files by their top-level directory, the way
+// monorepo reviews are usually split up, with the files outside any
+// directory first
+func (m *Model) buildTopView(files []git.ChangedFile) {
+	files, generated := splitGenerated(files)
+	groups := make(map[string][]git.ChangedFile)
+	for _, f := range files {
+		dir := topDir(f.Path)
+		groups[dir] = append(groups[dir], f)
+	}
+	dirs := slices.Sorted(maps.Keys(groups))
+
+	for _, dir := range dirs {
+		group := DisplayItem{
+			IsFolder:   true,
+			FolderPath: dir,
+			Label:      fmt.Sprintf("%s/ (%d)", dir, len(groups[dir])),
+		}
+		if dir == "" {
+			group.FolderPath = rootGroup
+			group.Label = fmt.Sprintf("Top level (%d)", len(groups[dir]))
+		}
+		group.IsExpanded = m.expandedDirs[group.FolderPath] || m.searchQuery != ""
+		for _, f := range groups[dir] {
+			group.Additions += f.Additions
+			group.Deletions += f.Deletions
+		}
+		m.displayItems = append(m.displayItems, group)
+		if group.IsExpanded {
+			for i := range groups[dir] {
+				m.displayItems = append(m.displayItems, DisplayItem{File: &groups[dir][i], Indent: 1})
+			}
+		}
+	}
+	m.addGeneratedGroup(generated)
+}
+
+// cursorGroup returns the group of the top-level view holding the item
+// under the cursor
+func (m Model) cursorGroup() string {
+	if m.cursor < 0 || m.cursor >= len(m.displayItems) {
+		return ""
+	}
+	item := m.displayItems[m.cursor]
+	switch {
+	case item.IsFolder:
+		return item.FolderPath
+	case item.File == nil:
+		return ""
+	case item.File.Generated:
+		return generatedGroup
+	case topDir(item.File.Path) == "":
+		return rootGroup
+	}
+	return topDir(item.File.Path)
+}
+
+// splitGenerated separates the generated files from the others
+func splitGenerated(files []git.ChangedFile) (others, generated []git.ChangedFile) {
+	for _, f := range files {
+		if f.Generated {
+			generated = append(generated, f)
+		} else {
+			others = append(others, f)
+		}
+	}
+	return others, generated
+}
+
+// addGeneratedGroup lists the generated files, by full path, under a
+// collapsible group at the end. Searching expands it so matches show.
+func (m *Model) addGeneratedGroup(files []git.ChangedFile) {
+	if len(files) == 0 {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	expanded := m.expandedDirs[generatedGroup] || m.searchQuery != ""
+	group := DisplayItem{
+		IsFolder:   true,
+		IsExpanded: expanded,
+		FolderPath: generatedGroup,
+		Label:      fmt.Sprintf("Generated (%d)", len(files)),
+	}
+	for _, f := range files {
+		group.Additions += f.Additions
+		group.Deletions += f.Deletions
+	}
+	m.displayItems = append(m.displayItems, group)
+
+	if expanded {
+		for i := range files {
+			m.displayItems = append(m.displayItems, DisplayItem{File: &files[i], Indent: 1})
+		}
+	}
 }
 
 func (m *Model) buildRawView(files []git.ChangedFile) {
@@ -362,6 +782,17 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			case "up", "down":
 				m.searching = false
 				m.searchInput.Blur()
+			case "alt+c", "alt+w":
+				if msg.String() == "alt+c" {
+					m.matchOpts.CaseSensitive = !m.matchOpts.CaseSensitive
+				} else {
+					m.matchOpts.WholeWord = !m.matchOpts.WholeWord
+				}
+				m.rebuildDisplayItems()
+				m.cursor = 0
+				m.offset = 0
+				m.findFirstFile()
+				return m, nil
 			default:
 				var cmd tea.Cmd
 				m.searchInput, cmd = m.searchInput.Update(msg)
@@ -394,7 +825,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			if m.viewMode > 0 {
 				m.viewMode--
 			} else {
-				m.viewMode = ViewRaw
+				m.viewMode = ViewTop
 			}
 			m.rebuildDisplayItems()
 			m.cursor = 0
@@ -402,7 +833,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			m.findFirstFile()
 
 		case key.Matches(msg, keys.BracketRight):
-			if m.viewMode < ViewRaw {
+			if m.viewMode < ViewTop {
 				m.viewMode++
 			} else {
 				m.viewMode = ViewFolder
@@ -432,7 +863,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				}
 			}
 
-		case key.Matches(msg, keys.PageUp):
+		case key.Matches(msg, keys.PageUp, keys.HalfPageUp):
 			m.cursor -= visibleHeight
 			if m.cursor < 0 {
 				m.cursor = 0
@@ -440,7 +871,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			m.findNearestFile()
 			m.offset = m.cursor
 
-		case key.Matches(msg, keys.PageDown):
+		case key.Matches(msg, keys.PageDown, keys.HalfPageDown):
 			m.cursor += visibleHeight
 			if m.cursor >= len(m.displayItems) {
 				m.cursor = len(m.displayItems) - 1
@@ -451,10 +882,13 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			}
 
 		case key.Matches(msg, keys.Right):
-			// Right arrow expands folder if on a collapsed folder
+			// Right arrow expands folder if on a collapsed folder, or steps
+			// into it if already expanded
 			if m.cursor >= 0 && m.cursor < len(m.displayItems) {
 				item := m.displayItems[m.cursor]
-				if item.IsFolder && !item.IsExpanded {
+				if item.IsFolder && item.IsExpanded && m.cursor+1 < len(m.displayItems) {
+					m.SetCursor(m.cursor + 1)
+				} else if item.IsFolder && !item.IsExpanded {
 					m.expandedDirs[item.FolderPath] = true
 					m.rebuildDisplayItems()
 					// Find the folder again after rebuild
@@ -468,11 +902,32 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				}
 			}
 
+		case key.Matches(msg, keys.CollapseAll), key.Matches(msg, keys.ExpandAll):
+			m.ExpandAll(key.Matches(msg, keys.ExpandAll))
+
+		case key.Matches(msg, keys.Left) && m.viewMode == ViewTop:
+			// Left arrow collapses the group holding the cursor and moves to it
+			if group := m.cursorGroup(); group != "" {
+				m.expandedDirs[group] = false
+				m.rebuildDisplayItems()
+				m.focusItem(group, true)
+			}
+
 		case key.Matches(msg, keys.Left):
-			// Left arrow collapses folder if on an expanded folder
-			if m.cursor >= 0 && m.cursor < len(m.displayItems) {
+			// Left arrow collapses folder if on an expanded folder, otherwise
+			// collapses the folder containing the cursor and moves to it
+			if m.cursor >= 0 && m.cursor < len(m.displayItems) && m.viewMode == ViewFolder {
 				item := m.displayItems[m.cursor]
-				if item.IsFolder && item.IsExpanded {
+				path, isFolder := m.CursorPath()
+				if item.File != nil && item.File.Generated {
+					m.expandedDirs[generatedGroup] = false
+					m.rebuildDisplayItems()
+					m.focusGroup()
+				} else if parent := filepath.Dir(path); (!isFolder || !item.IsExpanded) && parent != "." {
+					m.expandedDirs[parent] = false
+					m.rebuildDisplayItems()
+					m.focusItem(parent, true)
+				} else if item.IsFolder && item.IsExpanded {
 					m.expandedDirs[item.FolderPath] = false
 					m.rebuildDisplayItems()
 					// Find the folder again after rebuild
@@ -602,10 +1057,14 @@ func (m Model) View() string {
 
 	// Search bar (always visible)
 	searchStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted)
+	status := ""
+	if m.searching || m.searchQuery != "" {
+		status = searchStyle.Render(fmt.Sprintf("%s [%d]", m.matchOpts.Indicator(), m.matchCount))
+	}
 	if m.searching {
-		lines = append(lines, m.searchInput.View())
+		lines = append(lines, m.searchInput.View()+status)
 	} else if m.searchQuery != "" {
-		lines = append(lines, searchStyle.Render("/ "+m.searchQuery+" (esc to clear)"))
+		lines = append(lines, searchStyle.Render("/ "+m.searchQuery+" (esc to clear)")+status)
 	} else {
 		lines = append(lines, searchStyle.Render("/ to search"))
 	}
@@ -613,6 +1072,8 @@ func (m Model) View() string {
 	if len(m.displayItems) == 0 {
 		if m.searchQuery != "" {
 			lines = append(lines, ui.EmptyStateStyle.Render("No matches"))
+		} else if m.loading != "" {
+			lines = append(lines, ui.EmptyStateStyle.Render(m.loading))
 		} else {
 			lines = append(lines, ui.EmptyStateStyle.Render("No changes"))
 		}
@@ -659,11 +1120,17 @@ func (m Model) View() string {
 }
 
 func (m Model) renderTabs(width int) string {
-	modes := []string{"Folder", "Type", "Raw"}
+	modes := []string{"Folder", "Type", "Raw", "Top"}
 	var tabs []string
 
+	// Drop the padding around the names when they wouldn't fit otherwise:
+	// padded, the names take a space either side, and the current one brackets
+	padding := 1
+	if len(strings.Join(modes, "   "))+4 > width {
+		padding = 0
+	}
 	for i, mode := range modes {
-		style := lipgloss.NewStyle().Padding(0, 1)
+		style := lipgloss.NewStyle().Padding(0, padding)
 		if ViewMode(i) == m.viewMode {
 			style = style.Bold(true).Foreground(ui.ColorPrimary)
 			tabs = append(tabs, style.Render("["+mode+"]"))
@@ -685,6 +1152,12 @@ func (m Model) renderFolderLine(item DisplayItem, idx int, width int) string {
 	}
 
 	folderName := filepath.Base(item.FolderPath)
+	if item.Label != "" {
+		folderName = item.Label
+	}
+	if m.icons {
+		folderName = folderIcon(item.IsExpanded) + " " + folderName
+	}
 
 	cursor := "  "
 	if idx == m.cursor && m.focused {
@@ -700,7 +1173,54 @@ func (m Model) renderFolderLine(item DisplayItem, idx int, width int) string {
 		style = lipgloss.NewStyle().Foreground(ui.ColorSecondary).Bold(true)
 	}
 
-	return style.Render(line)
+	return m.withStats(style, line, item.Additions, item.Deletions, 0, width)
+}
+
+// withStats renders a list row with its +/- counts, preceded by the number of
+// conflict regions if any, aligned to the right edge
+func (m Model) withStats(style lipgloss.Style, line string, adds, dels, conflicts, width int) string {
+	var parts []string
+	if conflicts > 0 {
+		parts = append(parts, fmt.Sprintf("!%d", conflicts))
+	}
+	if adds > 0 {
+		parts = append(parts, fmt.Sprintf("+%d", adds))
+	}
+	if dels > 0 {
+		parts = append(parts, fmt.Sprintf("-%d", dels))
+	}
+	statsWidth := len(strings.Join(parts, " "))
+
+	gap := width - lipgloss.Width(line) - statsWidth
+	if statsWidth == 0 || gap < 1 {
+		return style.Render(line)
+	}
+
+	addStyle := lipgloss.NewStyle().Foreground(ui.ColorSuccess)
+	delStyle := lipgloss.NewStyle().Foreground(ui.ColorDanger)
+	conflictStyle := ui.StatusConflictStyle
+	if bg := style.GetBackground(); bg != (lipgloss.NoColor{}) {
+		addStyle = addStyle.Background(bg)
+		delStyle = delStyle.Background(bg)
+		conflictStyle = conflictStyle.Background(bg)
+	}
+	if style.GetReverse() {
+		addStyle = addStyle.Reverse(true)
+		delStyle = delStyle.Reverse(true)
+		conflictStyle = conflictStyle.Reverse(true)
+	}
+
+	var stats []string
+	if conflicts > 0 {
+		stats = append(stats, conflictStyle.Render(fmt.Sprintf("!%d", conflicts)))
+	}
+	if adds > 0 {
+		stats = append(stats, addStyle.Render(fmt.Sprintf("+%d", adds)))
+	}
+	if dels > 0 {
+		stats = append(stats, delStyle.Render(fmt.Sprintf("-%d", dels)))
+	}
+	return style.Render(line+strings.Repeat(" ", gap)) + strings.Join(stats, style.Render(" "))
 }
 
 func (m Model) renderTypeHeader(header string, width int) string {
@@ -711,6 +1231,20 @@ func (m Model) renderTypeHeader(header string, width int) string {
 	return style.Render("  " + header)
 }
 
+// secretBadge flags files with added lines that look like credentials
+const secretBadge = "SECRET"
+
+// maxOwnersWidth bounds the owners shown after a file name
+const maxOwnersWidth = 20
+
+// ownersLabel names a file's first owner, with how many others there are
+func ownersLabel(owners []string) string {
+	if len(owners) == 1 {
+		return owners[0]
+	}
+	return fmt.Sprintf("%s &%d", owners[0], len(owners)-1)
+}
+
 func (m Model) renderFileLine(item DisplayItem, idx int, width int) string {
 	file := item.File
 	if file == nil {
@@ -727,6 +1261,8 @@ func (m Model) renderFileLine(item DisplayItem, idx int, width int) string {
 		statusStyle = ui.StatusDeletedStyle
 	case git.StatusRenamed:
 		statusStyle = ui.StatusRenamedStyle
+	case git.StatusConflict:
+		statusStyle = ui.StatusConflictStyle
 	default:
 		statusStyle = lipgloss.NewStyle()
 	}
@@ -740,21 +1276,57 @@ func (m Model) renderFileLine(item DisplayItem, idx int, width int) string {
 
 	indent := strings.Repeat("  ", item.Indent)
 
-	// Show just filename in folder/type view, full path in raw
+	// Show just filename in folder/type view, the path within the group in
+	// the top-level view, full path in raw
 	path := file.Path
-	if m.viewMode == ViewFolder || m.viewMode == ViewType {
+	if (m.viewMode == ViewFolder || m.viewMode == ViewType) && !file.Generated {
 		path = filepath.Base(file.Path)
+	} else if m.viewMode == ViewTop && !file.Generated {
+		path = strings.TrimPrefix(path, topDir(path)+string(filepath.Separator))
 	}
 
-	maxPathWidth := width - 6 - len(indent)
+	statsWidth := len(fmt.Sprintf("+%d -%d", file.Additions, file.Deletions)) + 1
+	if file.Conflicts > 0 {
+		statsWidth += len(fmt.Sprintf("!%d ", file.Conflicts))
+	}
+	maxPathWidth := width - 6 - len(indent) - statsWidth
+	if m.icons {
+		maxPathWidth -= 2
+	}
+	if file.Secrets > 0 {
+		maxPathWidth -= len(secretBadge) + 1
+	}
+	owners := ""
+	if len(file.Owners) > 0 {
+		owners = " " + text.Truncate(ownersLabel(file.Owners), maxOwnersWidth, "…")
+		maxPathWidth -= lipgloss.Width(owners)
+	}
+	size := ""
+	if m.sizeBadges || m.order != OrderPath {
+		size = fmt.Sprintf("%-2s ", sizeClass(*file))
+		maxPathWidth -= len(size)
+	}
+	number := ""
+	if m.numbers {
+		digits := len(strconv.Itoa(m.matchCount))
+		number = fmt.Sprintf("%*d ", digits, item.Number)
+		maxPathWidth -= digits + 1
+	}
 	if maxPathWidth < 10 {
 		maxPathWidth = 10
 	}
-	if len(path) > maxPathWidth {
-		path = "..." + path[len(path)-maxPathWidth+3:]
+	path = text.TruncateLeft(path, maxPathWidth, "...")
+	if m.link != nil && !file.Generated {
+		path = ui.Hyperlink(m.link(file.Path, 0), path)
+	}
+	if m.icons {
+		path = fileIcon(file.Path) + " " + path
+	}
+	if file.Secrets > 0 {
+		path = ui.SecretBadgeStyle.Render(secretBadge) + " " + path
 	}
 
-	line := fmt.Sprintf("%s%s%s %s", cursor, indent, status, path)
+	line := fmt.Sprintf("%s%s%s%s %s%s%s", cursor, number, indent, status, size, path, owners)
 
 	var style lipgloss.Style
 	if idx == m.cursor && m.focused {
@@ -765,7 +1337,7 @@ func (m Model) renderFileLine(item DisplayItem, idx int, width int) string {
 		style = ui.FileItemStyle
 	}
 
-	return style.Render(line)
+	return m.withStats(style, line, file.Additions, file.Deletions, file.Conflicts, width)
 }
 
 // Cursor returns the current cursor position
@@ -0,0 +1,89 @@
+package filelist
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Nerd Font glyphs for folders and files without a more specific icon
+const (
+	iconFolder     = "\uf07b" // nf-fa-folder
+	iconFolderOpen = "\uf07c" // nf-fa-folder_open
+	iconFile       = "\uf016" // nf-fa-file_o
+)
+
+// iconsByName holds the Nerd Font icons of well-known file names
+var iconsByName = map[string]string{
+	".gitignore":     "\ue702", // nf-dev-git
+	".gitattributes": "\ue702",
+	".gitmodules":    "\ue702",
+	"Dockerfile":     "\ue7b0", // nf-dev-docker
+	"Makefile":       "\ue779", // nf-dev-gnu
+	"go.mod":         "\ue627", // nf-seti-go
+	"go.sum":         "\ue627",
+	"LICENSE":        "\uf0e3", // nf-fa-legal
+}
+
+// iconsByExt holds the Nerd Font icons of file extensions
+var iconsByExt = map[string]string{
+	".go":    "\ue627", // nf-seti-go
+	".py":    "\ue606", // nf-seti-python
+	".js":    "\ue74e", // nf-dev-javascript
+	".mjs":   "\ue74e",
+	".cjs":   "\ue74e",
+	".ts":    "\ue628", // nf-seti-typescript
+	".jsx":   "\ue7ba", // nf-dev-react
+	".tsx":   "\ue7ba",
+	".json":  "\ue60b", // nf-seti-json
+	".md":    "\ue73e", // nf-dev-markdown
+	".html":  "\ue736", // nf-dev-html5
+	".css":   "\ue749", // nf-dev-css3
+	".java":  "\ue738", // nf-dev-java
+	".rb":    "\ue739", // nf-dev-ruby
+	".rs":    "\ue7a8", // nf-dev-rust
+	".c":     "\ue61e", // nf-custom-c
+	".h":     "\ue61e",
+	".cpp":   "\ue61d", // nf-custom-cpp
+	".cc":    "\ue61d",
+	".hpp":   "\ue61d",
+	".php":   "\ue73d", // nf-dev-php
+	".lua":   "\ue620", // nf-seti-lua
+	".swift": "\ue755", // nf-dev-swift
+	".sql":   "\ue706", // nf-dev-database
+	".sh":    "\ue795", // nf-dev-terminal
+	".bash":  "\ue795",
+	".zsh":   "\ue795",
+	".yml":   "\ue615", // nf-seti-config
+	".yaml":  "\ue615",
+	".toml":  "\ue615",
+	".ini":   "\ue615",
+	".lock":  "\uf023", // nf-fa-lock
+	".png":   "\uf1c5", // nf-fa-file_image_o
+	".jpg":   "\uf1c5",
+	".jpeg":  "\uf1c5",
+	".gif":   "\uf1c5",
+	".svg":   "\uf1c5",
+	".pdf":   "\uf1c1", // nf-fa-file_pdf_o
+	".zip":   "\uf1c6", // nf-fa-file_archive_o
+	".gz":    "\uf1c6",
+}
+
+// fileIcon returns the Nerd Font icon for a file path
+func fileIcon(path string) string {
+	name := filepath.Base(path)
+	if icon, ok := iconsByName[name]; ok {
+		return icon
+	}
+	if icon, ok := iconsByExt[strings.ToLower(filepath.Ext(name))]; ok {
+		return icon
+	}
+	return iconFile
+}
+
+// folderIcon returns the Nerd Font icon for an open or closed folder
+func folderIcon(expanded bool) string {
+	if expanded {
+		return iconFolderOpen
+	}
+	return iconFolder
+}
@@ -0,0 +1,105 @@
+package filelist
+
+import (
+	"cmp"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/matthewmyrick/git-diffs/internal/git"
+)
+
+// Order is the order files are listed in within their folder or group
+type Order int
+
+const (
+	OrderPath      Order = iota // Alphabetically by path
+	OrderQuickWins              // Smallest changes first
+	OrderHeavy                  // Largest changes first
+)
+
+// String names the order for the status bar
+func (o Order) String() string {
+	switch o {
+	case OrderQuickWins:
+		return "quick wins first"
+	case OrderHeavy:
+		return "heavy first"
+	}
+	return "by path"
+}
+
+// sizeClasses names the size of a change by the upper bound of its weighted
+// line count
+var sizeClasses = []struct {
+	name  string
+	below int
+}{
+	{"XS", 10},
+	{"S", 50},
+	{"M", 200},
+	{"L", 500},
+}
+
+// lineWeight is how much a changed line of path counts toward its size:
+// code counts fully, tests a little less, and docs and data files little,
+// since they are quicker to review
+func lineWeight(path string) float64 {
+	base := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.Contains(base, "_test.") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec."):
+		return 0.75
+	}
+	switch filepath.Ext(base) {
+	case ".md", ".markdown", ".rst", ".txt", ".adoc":
+		return 0.5
+	case ".json", ".yaml", ".yml", ".toml", ".ini", ".csv", ".lock", ".sum", ".svg":
+		return 0.25
+	}
+	return 1
+}
+
+// effort estimates the work of reviewing a file: its changed lines
+// weighted by file type. Generated files take none.
+func effort(f git.ChangedFile) int {
+	if f.Generated {
+		return 0
+	}
+	return int(float64(f.Additions+f.Deletions) * lineWeight(f.Path))
+}
+
+// sizeClass returns the XS-XL size badge of a file's change
+func sizeClass(f git.ChangedFile) string {
+	n := effort(f)
+	for _, c := range sizeClasses {
+		if n < c.below {
+			return c.name
+		}
+	}
+	return "XL"
+}
+
+// compareEffort orders two files by their effort in the given order, or
+// leaves them be for OrderPath
+func compareEffort(order Order, a, b git.ChangedFile) int {
+	switch order {
+	case OrderQuickWins:
+		return cmp.Compare(effort(a), effort(b))
+	case OrderHeavy:
+		return cmp.Compare(effort(b), effort(a))
+	}
+	return 0
+}
+
+// sortByEffort returns the files in the given order, keeping the path order
+// among files of equal effort
+func sortByEffort(files []git.ChangedFile, order Order) []git.ChangedFile {
+	if order == OrderPath {
+		return files
+	}
+	sorted := slices.Clone(files)
+	slices.SortStableFunc(sorted, func(a, b git.ChangedFile) int {
+		return compareEffort(order, a, b)
+	})
+	return sorted
+}
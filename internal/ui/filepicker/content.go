@@ -0,0 +1,176 @@
+package filepicker
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
+)
+
+// maxContentResults caps the number of matching lines collected per query
+const maxContentResults = 2000
+
+// DiffsWantedMsg asks for the diffs of files for content search. The owner
+// of the picker loads them and hands them over with DiffsLoadedMsg as they
+// arrive.
+type DiffsWantedMsg struct {
+	Files []git.ChangedFile
+}
+
+// DiffsLoadedMsg carries diffs loaded in the background for content search.
+// A nil diff is one that couldn't be loaded.
+type DiffsLoadedMsg struct {
+	Diffs map[string]*git.FileDiff
+}
+
+// ContentSelectedMsg is sent when a matching line is selected in content mode
+type ContentSelectedMsg struct {
+	File *git.ChangedFile
+	Line git.DiffLine
+}
+
+// contentResult is a row in the content search results: either a file header
+// or a matching added/removed line within that file
+type contentResult struct {
+	fileIdx int
+	isFile  bool
+	count   int          // Number of matching lines (file rows only)
+	line    git.DiffLine // Matching line (line rows only)
+	lineIdx int          // Index of the line in the flattened diff
+	matched []int        // Byte offsets of matched characters in line.Content
+}
+
+// wantDiffs asks for the diffs content search still lacks, including those
+// that failed or were cancelled before
+func (m *Model) wantDiffs() tea.Cmd {
+	var wanted []git.ChangedFile
+	for _, f := range m.files {
+		if m.diffs[f.Path] == nil && !m.waiting[f.Path] {
+			m.waiting[f.Path] = true
+			wanted = append(wanted, f)
+		}
+	}
+	if len(wanted) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		return DiffsWantedMsg{Files: wanted}
+	}
+}
+
+// addDiffs takes diffs loaded for content search
+func (m *Model) addDiffs(diffs map[string]*git.FileDiff) {
+	for path, diff := range diffs {
+		if diff != nil {
+			m.diffs[path] = diff
+		}
+		delete(m.waiting, path)
+	}
+}
+
+// updateContentResults greps the added and removed lines of every loaded diff
+func (m *Model) updateContentResults() {
+	m.results = nil
+	query := m.searchInput.Value()
+	if query == "" {
+		return
+	}
+	// Matched on the original lines, so the offsets highlight them correctly
+	// even where lowercasing would change a character's length
+	re, err := ui.MatchOptions{}.Compile(query, false)
+	if err != nil {
+		return
+	}
+
+	total := 0
+	for fileIdx, file := range m.files {
+		diff, ok := m.diffs[file.Path]
+		if !ok || diff == nil {
+			continue
+		}
+
+		header := len(m.results)
+		lineIdx := 0
+		for _, hunk := range diff.Hunks {
+			for _, line := range hunk.Lines {
+				idx := lineIdx
+				lineIdx++
+				if line.Type != git.DiffLineAddition && line.Type != git.DiffLineDeletion {
+					continue
+				}
+				if total >= maxContentResults || !re.MatchString(line.Content) {
+					continue
+				}
+				if header == len(m.results) {
+					m.results = append(m.results, contentResult{fileIdx: fileIdx, isFile: true})
+				}
+				m.results = append(m.results, contentResult{
+					fileIdx: fileIdx,
+					line:    line,
+					lineIdx: idx,
+					matched: ui.MatchedOffsets(re, line.Content),
+				})
+				m.results[header].count++
+				total++
+			}
+		}
+	}
+}
+
+// renderContentLine renders a row of the content search results
+func (m Model) renderContentLine(result contentResult, selected bool, width int) string {
+	var lineStr string
+	if result.isFile {
+		file := m.files[result.fileIdx]
+		path := file.Path
+		maxPathWidth := width - 10
+		if maxPathWidth < 10 {
+			maxPathWidth = 10
+		}
+		path = text.TruncateLeft(path, maxPathWidth, "...")
+		count := lipgloss.NewStyle().Foreground(ui.ColorMuted).Render(fmt.Sprintf(" (%d)", result.count))
+		lineStr = lipgloss.NewStyle().Foreground(ui.ColorSecondary).Bold(true).Render(path) + count
+	} else {
+		prefix := lipgloss.NewStyle().Foreground(ui.ColorSuccess).Render("+")
+		lineNum := result.line.NewLineNum
+		if result.line.Type == git.DiffLineDeletion {
+			prefix = lipgloss.NewStyle().Foreground(ui.ColorDanger).Render("-")
+			lineNum = result.line.OldLineNum
+		}
+		num := lipgloss.NewStyle().Foreground(ui.ColorMuted).Render(fmt.Sprintf("%4d", lineNum))
+
+		content := strings.TrimLeft(result.line.Content, " \t")
+		trimmed := len(result.line.Content) - len(content)
+		maxWidth := width - 12
+		if maxWidth < 5 {
+			maxWidth = 5
+		}
+		content = text.Truncate(content, maxWidth, "…")
+
+		var shifted []int
+		for _, idx := range result.matched {
+			shifted = append(shifted, idx-trimmed)
+		}
+		lineStr = "  " + prefix + " " + num + " " + m.highlightMatches(content, shifted, content)
+	}
+
+	cursor := "  "
+	if selected {
+		cursor = lipgloss.NewStyle().Foreground(ui.ColorPrimary).Bold(true).Render("> ")
+	}
+	lineStr = cursor + lineStr
+
+	lineWidth := lipgloss.Width(lineStr)
+	if lineWidth < width {
+		lineStr += strings.Repeat(" ", width-lineWidth)
+	}
+
+	if selected {
+		return ui.SelectedLineStyle.Render(lineStr)
+	}
+	return lineStr
+}
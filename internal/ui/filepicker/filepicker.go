@@ -7,8 +7,10 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/matthewmyrick/git-diffs/internal/git"
 	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
 	"github.com/sahilm/fuzzy"
 )
 
@@ -20,6 +22,9 @@ type FileSelectedMsg struct {
 	File *git.ChangedFile
 }
 
+// DiffLoader loads the diff for a file shown in the preview
+type DiffLoader func(file git.ChangedFile) (*git.FileDiff, error)
+
 // Model represents the file picker overlay
 type Model struct {
 	files       []git.ChangedFile
@@ -31,8 +36,10 @@ type Model struct {
 	width       int
 	height      int
 	active      bool
-	repo        *git.Repo
-	baseBranch  string
+	loadDiffFn  DiffLoader
+	contentMode bool            // Search diff content instead of paths
+	results     []contentResult // Content search results
+	waiting     map[string]bool // Files whose diffs content search is waiting for
 }
 
 // New creates a new file picker model
@@ -45,19 +52,24 @@ func New() Model {
 	return Model{
 		searchInput: ti,
 		diffs:       make(map[string]*git.FileDiff),
+		waiting:     make(map[string]bool),
 	}
 }
 
-// SetFiles sets the list of files
+// SetFiles sets the list of files. Matches are computed lazily when the
+// picker is opened.
 func (m *Model) SetFiles(files []git.ChangedFile) {
 	m.files = files
-	m.updateMatches()
+	m.matches = nil
 }
 
-// SetRepo sets the repo for loading diffs
-func (m *Model) SetRepo(repo *git.Repo, baseBranch string) {
-	m.repo = repo
-	m.baseBranch = baseBranch
+// SetDiffLoader sets the function used to load preview diffs and clears
+// the cache of previously loaded diffs
+func (m *Model) SetDiffLoader(loader DiffLoader) {
+	m.loadDiffFn = loader
+	m.diffs = make(map[string]*git.FileDiff)
+	clear(m.waiting)
+	m.results = nil
 }
 
 // SetSize sets the overlay dimensions
@@ -74,12 +86,15 @@ func (m *Model) Open() {
 	m.cursor = 0
 	m.offset = 0
 	m.updateMatches()
+	m.results = nil
 }
 
 // Close deactivates the file picker
 func (m *Model) Close() {
 	m.active = false
 	m.searchInput.Blur()
+	// The loads still running are cancelled with the picker
+	clear(m.waiting)
 }
 
 // IsActive returns whether the picker is active
@@ -99,13 +114,47 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
+	case DiffsLoadedMsg:
+		m.addDiffs(msg.Diffs)
+		if m.contentMode {
+			m.updateContentResults()
+			m.cursor = min(m.cursor, max(0, len(m.results)-1))
+		}
+		return m, nil
+
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
 			m.Close()
 			return m, func() tea.Msg { return CloseMsg{} }
 
+		case "tab":
+			// Toggle between searching paths and searching diff content
+			m.contentMode = !m.contentMode
+			m.cursor = 0
+			m.offset = 0
+			if m.contentMode {
+				m.searchInput.Placeholder = "Search changed lines..."
+				m.updateContentResults()
+				return m, m.wantDiffs()
+			} else {
+				m.searchInput.Placeholder = "Search files..."
+			}
+			return m, nil
+
 		case "enter":
+			if m.contentMode {
+				if m.cursor < len(m.results) {
+					result := m.results[m.cursor]
+					file := &m.files[result.fileIdx]
+					m.Close()
+					if result.isFile {
+						return m, func() tea.Msg { return FileSelectedMsg{File: file} }
+					}
+					return m, func() tea.Msg { return ContentSelectedMsg{File: file, Line: result.line} }
+				}
+				return m, nil
+			}
 			if len(m.matches) > 0 && m.cursor < len(m.matches) {
 				idx := m.matches[m.cursor].Index
 				file := &m.files[idx]
@@ -122,7 +171,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			return m, nil
 
 		case "down", "ctrl+j":
-			if m.cursor < len(m.matches)-1 {
+			if m.cursor < m.resultCount()-1 {
 				m.cursor++
 				m.ensureVisible()
 			}
@@ -138,8 +187,8 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 		case "ctrl+d":
 			m.cursor += 10
-			if m.cursor >= len(m.matches) {
-				m.cursor = len(m.matches) - 1
+			if m.cursor >= m.resultCount() {
+				m.cursor = m.resultCount() - 1
 			}
 			if m.cursor < 0 {
 				m.cursor = 0
@@ -150,7 +199,11 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		default:
 			var cmd tea.Cmd
 			m.searchInput, cmd = m.searchInput.Update(msg)
-			m.updateMatches()
+			if m.contentMode {
+				m.updateContentResults()
+			} else {
+				m.updateMatches()
+			}
 			m.cursor = 0
 			m.offset = 0
 			return m, cmd
@@ -160,6 +213,14 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	return m, nil
 }
 
+// resultCount returns the number of rows in the current result list
+func (m Model) resultCount() int {
+	if m.contentMode {
+		return len(m.results)
+	}
+	return len(m.matches)
+}
+
 func (m *Model) updateMatches() {
 	query := strings.ReplaceAll(m.searchInput.Value(), " ", "")
 	if query == "" {
@@ -212,24 +273,21 @@ func (m Model) overlayWidth() int {
 }
 
 // loadDiff loads and caches a diff for a file
-func (m *Model) loadDiff(path string) *git.FileDiff {
-	if diff, ok := m.diffs[path]; ok {
+func (m *Model) loadDiff(file git.ChangedFile) *git.FileDiff {
+	if diff, ok := m.diffs[file.Path]; ok {
 		return diff
 	}
 
-	if m.repo == nil {
+	if m.loadDiffFn == nil {
 		return nil
 	}
 
-	diff, err := m.repo.GetFileDiff(m.baseBranch, "HEAD", path)
+	diff, err := m.loadDiffFn(file)
 	if err != nil {
-		diff, err = m.repo.GetFileDiff(m.baseBranch, "", path)
-		if err != nil {
-			return nil
-		}
+		return nil
 	}
 
-	m.diffs[path] = diff
+	m.diffs[file.Path] = diff
 	return diff
 }
 
@@ -251,8 +309,17 @@ func (m Model) RenderOverlay(background string) string {
 	var leftLines []string
 
 	// Title
-	title := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorPrimary).Render("Files")
-	leftLines = append(leftLines, title)
+	titleText := "Files"
+	if m.contentMode {
+		titleText = "Content"
+	}
+	title := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorPrimary).Render(titleText)
+	hintText := "  tab: files/content"
+	if m.contentMode && len(m.waiting) > 0 {
+		hintText = fmt.Sprintf("  loading %d diffs…", len(m.waiting))
+	}
+	hint := lipgloss.NewStyle().Foreground(ui.ColorMuted).Render(hintText)
+	leftLines = append(leftLines, title+hint)
 
 	// Search input
 	searchLine := m.renderSearchInput(leftWidth)
@@ -260,7 +327,24 @@ func (m Model) RenderOverlay(background string) string {
 	leftLines = append(leftLines, lipgloss.NewStyle().Foreground(ui.ColorMuted).Render(strings.Repeat("─", leftWidth)))
 
 	// File list
-	if len(m.matches) == 0 {
+	if m.contentMode {
+		switch {
+		case len(m.results) == 0 && len(m.waiting) > 0:
+			leftLines = append(leftLines, ui.EmptyStateStyle.Render("Loading diffs..."))
+		case len(m.results) == 0 && m.searchInput.Value() == "":
+			leftLines = append(leftLines, ui.EmptyStateStyle.Render("Type to search added/removed lines"))
+		case len(m.results) == 0:
+			leftLines = append(leftLines, ui.EmptyStateStyle.Render("No matches"))
+		default:
+			end := m.offset + contentHeight - 1
+			if end > len(m.results) {
+				end = len(m.results)
+			}
+			for i := m.offset; i < end; i++ {
+				leftLines = append(leftLines, m.renderContentLine(m.results[i], i == m.cursor, leftWidth))
+			}
+		}
+	} else if len(m.matches) == 0 {
 		leftLines = append(leftLines, ui.EmptyStateStyle.Render("No matches"))
 	} else {
 		end := m.offset + contentHeight - 1 // -1 for title
@@ -288,10 +372,17 @@ func (m Model) RenderOverlay(background string) string {
 	rightLines = append(rightLines, lipgloss.NewStyle().Bold(true).Foreground(ui.ColorPrimary).Render("Preview"))
 	rightLines = append(rightLines, lipgloss.NewStyle().Foreground(ui.ColorMuted).Render(strings.Repeat("─", rightWidth)))
 
-	if len(m.matches) > 0 && m.cursor < len(m.matches) {
+	if m.contentMode && m.cursor < len(m.results) {
+		result := m.results[m.cursor]
+		focus := -1
+		if !result.isFile {
+			focus = result.lineIdx
+		}
+		rightLines = append(rightLines, m.renderDiffPreview(m.files[result.fileIdx], rightWidth, contentHeight, focus)...)
+	} else if !m.contentMode && len(m.matches) > 0 && m.cursor < len(m.matches) {
 		idx := m.matches[m.cursor].Index
 		file := m.files[idx]
-		rightLines = append(rightLines, m.renderDiffPreview(file.Path, rightWidth, contentHeight)...)
+		rightLines = append(rightLines, m.renderDiffPreview(file, rightWidth, contentHeight, -1)...)
 	} else {
 		rightLines = append(rightLines, ui.EmptyStateStyle.Render("Select a file"))
 	}
@@ -340,9 +431,9 @@ func (m Model) compositeOverlay(background, overlay string, overlayW, overlayH i
 		bgLines = append(bgLines, "")
 	}
 
-	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#444444"))
+	dimStyle := lipgloss.NewStyle().Foreground(ui.ColorDim)
 	for i := range bgLines {
-		plain := stripAnsi(bgLines[i])
+		plain := ansi.Strip(bgLines[i])
 		if len(plain) < m.width {
 			plain = plain + strings.Repeat(" ", m.width-len(plain))
 		}
@@ -365,48 +456,25 @@ func (m Model) compositeOverlay(background, overlay string, overlayW, overlayH i
 }
 
 func (m Model) insertOverlayLine(bgLine, overlayLine string, startCol int) string {
-	bgRunes := []rune(stripAnsi(bgLine))
-
-	for len(bgRunes) < m.width {
-		bgRunes = append(bgRunes, ' ')
-	}
+	bg := text.Pad(ansi.Strip(bgLine), m.width)
 
-	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#444444"))
+	dimStyle := lipgloss.NewStyle().Foreground(ui.ColorDim)
 
 	left := ""
 	if startCol > 0 {
-		left = dimStyle.Render(string(bgRunes[:startCol]))
+		left = dimStyle.Render(text.Columns(bg, 0, startCol))
 	}
 
 	right := ""
 	overlayWidth := lipgloss.Width(overlayLine)
 	endCol := startCol + overlayWidth
-	if endCol < len(bgRunes) {
-		right = dimStyle.Render(string(bgRunes[endCol:]))
+	if bgWidth := text.Width(bg); endCol < bgWidth {
+		right = dimStyle.Render(text.Columns(bg, endCol, bgWidth))
 	}
 
 	return left + overlayLine + right
 }
 
-func stripAnsi(s string) string {
-	var result strings.Builder
-	inEscape := false
-	for _, r := range s {
-		if r == '\x1b' {
-			inEscape = true
-			continue
-		}
-		if inEscape {
-			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
-				inEscape = false
-			}
-			continue
-		}
-		result.WriteRune(r)
-	}
-	return result.String()
-}
-
 func (m Model) renderSearchInput(width int) string {
 	prefix := lipgloss.NewStyle().Foreground(ui.ColorPrimary).Bold(true).Render("> ")
 
@@ -418,6 +486,15 @@ func (m Model) renderSearchInput(width int) string {
 	input := m.searchInput.View()
 
 	count := fmt.Sprintf(" [%d]", len(m.matches))
+	if m.contentMode {
+		lineMatches := 0
+		for _, r := range m.results {
+			if !r.isFile {
+				lineMatches++
+			}
+		}
+		count = fmt.Sprintf(" [%d]", lineMatches)
+	}
 	countStyled := lipgloss.NewStyle().Foreground(ui.ColorMuted).Render(count)
 
 	return prefix + input + countStyled
@@ -445,9 +522,7 @@ func (m Model) renderFileLine(file git.ChangedFile, selected bool, width int, ma
 	if maxPathWidth < 10 {
 		maxPathWidth = 10
 	}
-	if len(path) > maxPathWidth {
-		path = "..." + path[len(path)-maxPathWidth+3:]
-	}
+	path = text.TruncateLeft(path, maxPathWidth, "...")
 
 	// Highlight matches
 	var styledPath string
@@ -471,9 +546,7 @@ func (m Model) renderFileLine(file git.ChangedFile, selected bool, width int, ma
 	}
 
 	if selected {
-		return lipgloss.NewStyle().
-			Background(lipgloss.Color("#2a2a3a")).
-			Render(lineStr)
+		return ui.SelectedLineStyle.Render(lineStr)
 	}
 
 	return lineStr
@@ -508,10 +581,12 @@ func (m Model) highlightMatches(displayPath string, matchedIndexes []int, origin
 	return result.String()
 }
 
-func (m *Model) renderDiffPreview(path string, width int, height int) []string {
+// renderDiffPreview renders the start of a file's diff, or the lines around
+// focus (an index into the flattened diff lines) when focus >= 0
+func (m *Model) renderDiffPreview(file git.ChangedFile, width int, height int, focus int) []string {
 	var lines []string
 
-	diff := m.loadDiff(path)
+	diff := m.loadDiff(file)
 	if diff == nil {
 		lines = append(lines, ui.EmptyStateStyle.Render("Loading..."))
 		return lines
@@ -538,34 +613,43 @@ func (m *Model) renderDiffPreview(path string, width int, height int) []string {
 		}
 	}
 
-	// Show first `height` lines
-	end := height
+	// Show first `height` lines, or a window centered on the focused line
+	start := 0
+	if focus >= 0 {
+		start = focus - height/2
+		if start > len(allLines)-height {
+			start = len(allLines) - height
+		}
+		if start < 0 {
+			start = 0
+		}
+	}
+	end := start + height
 	if end > len(allLines) {
 		end = len(allLines)
 	}
 
-	for i := 0; i < end; i++ {
+	for i := start; i < end; i++ {
 		line := allLines[i]
 
-		var bgColor lipgloss.Color
-		var fgColor lipgloss.Color
+		var bgColor lipgloss.TerminalColor = lipgloss.NoColor{}
+		var fgColor lipgloss.TerminalColor
 		var prefix string
 
 		switch line.typ {
 		case git.DiffLineAddition:
-			bgColor = lipgloss.Color("#0a1a0a")
-			fgColor = lipgloss.Color("#88cc88")
+			bgColor = ui.ColorAdditionBg
+			fgColor = ui.ColorAdditionFg
 			prefix = "+"
 		case git.DiffLineDeletion:
-			bgColor = lipgloss.Color("#1a0a0a")
-			fgColor = lipgloss.Color("#cc8888")
+			bgColor = ui.ColorDeletionBg
+			fgColor = ui.ColorDeletionFg
 			prefix = "-"
 		case git.DiffLineHeader:
-			bgColor = lipgloss.Color("#0a0a1a")
-			fgColor = lipgloss.Color("#8888cc")
+			bgColor = ui.ColorHunkBg
+			fgColor = ui.ColorHunkFg
 			prefix = "@"
 		default:
-			bgColor = lipgloss.Color("")
 			fgColor = ui.ColorTextMuted
 			prefix = " "
 		}
@@ -575,25 +659,24 @@ func (m *Model) renderDiffPreview(path string, width int, height int) []string {
 			lineNum = "    "
 		}
 
-		content := line.content
+		content := strings.TrimSuffix(line.content, "\r")
 		maxWidth := width - 8
 		if maxWidth < 5 {
 			maxWidth = 5
 		}
-		if len(content) > maxWidth {
-			content = content[:maxWidth-1] + "…"
-		}
-		if len(content) < maxWidth {
-			content = content + strings.Repeat(" ", maxWidth-len(content))
-		}
+		content = text.Fit(content, maxWidth)
 
 		lineNumStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted)
 		contentStyle := lipgloss.NewStyle().Background(bgColor).Foreground(fgColor)
 
-		lines = append(lines, prefix+" "+lineNumStyle.Render(lineNum)+" "+contentStyle.Render(content))
+		rendered := prefix + " " + lineNumStyle.Render(lineNum) + " " + contentStyle.Render(content)
+		if i == focus {
+			rendered = ui.PreviewFocusStyle.Render(rendered)
+		}
+		lines = append(lines, rendered)
 	}
 
-	if len(allLines) > height {
+	if focus < 0 && len(allLines) > height {
 		more := fmt.Sprintf("... +%d more lines", len(allLines)-height)
 		lines = append(lines, ui.EmptyStateStyle.Render(more))
 	}
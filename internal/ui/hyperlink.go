@@ -0,0 +1,14 @@
+package ui
+
+// Hyperlink makes text a terminal hyperlink (OSC 8) to url. Terminals
+// without support show the text alone. An empty url leaves text unchanged.
+func Hyperlink(url, text string) string {
+	if url == "" {
+		return text
+	}
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// LinkFunc returns the URL of line of the file at path, or of the file
+// itself when line is 0. An empty URL means no link.
+type LinkFunc func(path string, line int) string
@@ -1,6 +1,39 @@
 package ui
 
-import "github.com/charmbracelet/bubbles/key"
+import (
+	"slices"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// Chord is a binding made of a sequence of keys, like vim's gg
+type Chord struct {
+	keys []string
+	help string
+}
+
+// NewChord creates a chord pressed as keys, in order
+func NewChord(help string, keys ...string) Chord {
+	return Chord{keys: keys, help: help}
+}
+
+// Matches reports whether seq is the chord's full key sequence
+func (c Chord) Matches(seq []string) bool {
+	return slices.Equal(c.keys, seq)
+}
+
+// HasPrefix reports whether seq starts the chord without completing it
+func (c Chord) HasPrefix(seq []string) bool {
+	return len(seq) < len(c.keys) && slices.Equal(c.keys[:len(seq)], seq)
+}
+
+// Help returns the chord's keys and description
+func (c Chord) Help() (keys, desc string) {
+	for _, k := range c.keys {
+		keys += k
+	}
+	return keys, c.help
+}
 
 // KeyMap defines all the keybindings for the application
 type KeyMap struct {
@@ -11,20 +44,82 @@ type KeyMap struct {
 	Enter         key.Binding
 	Tab           key.Binding
 	ShiftTab      key.Binding
-	Pane1         key.Binding
-	Pane2         key.Binding
 	Search        key.Binding
 	SearchContent key.Binding
 	Escape        key.Binding
 	Quit          key.Binding
 	PageUp        key.Binding
 	PageDown      key.Binding
+	HalfPageUp    key.Binding
+	HalfPageDown  key.Binding
+	ScrollUp      key.Binding
+	ScrollDown    key.Binding
 	Home          key.Binding
 	End           key.Binding
 	BracketLeft   key.Binding
 	BracketRight  key.Binding
 	PaneLeft      key.Binding
 	PaneRight     key.Binding
+	PathFilter    key.Binding
+	CollapseAll   key.Binding
+	ExpandAll     key.Binding
+	Refresh       key.Binding
+	Whitespace    key.Binding
+	CopyPath      key.Binding
+	Reveal        key.Binding
+	Worktrees     key.Binding
+	Repos         key.Binding
+	Head          key.Binding
+	Stage         key.Binding
+	Excluded      key.Binding
+	CompareMode   key.Binding
+	Commits       key.Binding
+	RangeSelect   key.Binding
+	Back          key.Binding
+	RevertHunk    key.Binding
+	RevertFile    key.Binding
+	IntentToAdd   key.Binding
+	LineEndings   key.Binding
+	FullContext   key.Binding
+	FinishReview  key.Binding
+	Export        key.Binding
+	OpenEditor    key.Binding
+	GoTo          key.Binding
+	ReadingMode   key.Binding
+	Pin           key.Binding
+	NextHunk      key.Binding
+	PrevHunk      key.Binding
+	AlternateFile key.Binding
+	RecentFiles   key.Binding
+	FileNumbers   key.Binding
+	FileOrder     key.Binding
+	Mine          key.Binding
+	Summary       key.Binding
+	TmuxSplit     key.Binding
+	Difftool      key.Binding
+	RefDiff       key.Binding
+	LineNumbers   key.Binding
+	RelativeNums  key.Binding
+	CopyHunk      key.Binding
+	CopyPatch     key.Binding
+	Permalink     key.Binding
+	Comment       key.Binding
+	SetMark       key.Binding
+	JumpMark      key.Binding
+	Marks         key.Binding
+	Todos         key.Binding
+	Lint          key.Binding
+	Conflicts     key.Binding
+
+	// Multi-key sequences
+	GoTop       Chord
+	GoDiff      Chord
+	ExpandFolds Chord
+	FoldAll     Chord
+	NextChange  Chord
+	PrevChange  Chord
+	NextFile    Chord
+	PrevFile    Chord
 }
 
 // DefaultKeyMap returns the default keybindings
@@ -58,14 +153,6 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("shift+tab"),
 			key.WithHelp("shift+tab", "switch pane back"),
 		),
-		Pane1: key.NewBinding(
-			key.WithKeys("1"),
-			key.WithHelp("1", "files pane"),
-		),
-		Pane2: key.NewBinding(
-			key.WithKeys("2"),
-			key.WithHelp("2", "diff pane"),
-		),
 		Search: key.NewBinding(
 			key.WithKeys("/"),
 			key.WithHelp("/", "search files"),
@@ -83,16 +170,32 @@ func DefaultKeyMap() KeyMap {
 			key.WithHelp("q", "quit"),
 		),
 		PageUp: key.NewBinding(
-			key.WithKeys("pgup", "ctrl+u"),
+			key.WithKeys("pgup"),
 			key.WithHelp("pgup", "page up"),
 		),
 		PageDown: key.NewBinding(
-			key.WithKeys("pgdown", "ctrl+d"),
+			key.WithKeys("pgdown"),
 			key.WithHelp("pgdn", "page down"),
 		),
+		HalfPageUp: key.NewBinding(
+			key.WithKeys("ctrl+u"),
+			key.WithHelp("ctrl+u", "half page up"),
+		),
+		HalfPageDown: key.NewBinding(
+			key.WithKeys("ctrl+d"),
+			key.WithHelp("ctrl+d", "half page down"),
+		),
+		ScrollUp: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("ctrl+y", "scroll up a line"),
+		),
+		ScrollDown: key.NewBinding(
+			key.WithKeys("ctrl+e"),
+			key.WithHelp("ctrl+e", "scroll down a line"),
+		),
 		Home: key.NewBinding(
 			key.WithKeys("home", "g"),
-			key.WithHelp("home/g", "go to top"),
+			key.WithHelp("home", "go to top"),
 		),
 		End: key.NewBinding(
 			key.WithKeys("end", "G"),
@@ -114,9 +217,271 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+h"),
 			key.WithHelp("ctrl+h", "right pane"),
 		),
+		PathFilter: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "filter paths"),
+		),
+		CollapseAll: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "collapse all folders"),
+		),
+		ExpandAll: key.NewBinding(
+			key.WithKeys("+", "="),
+			key.WithHelp("+", "expand all folders"),
+		),
+		Refresh: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "refresh"),
+		),
+		Whitespace: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "toggle whitespace"),
+		),
+		CopyPath: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy absolute path"),
+		),
+		Reveal: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "open containing folder"),
+		),
+		Worktrees: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "switch worktree"),
+		),
+		Repos: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "switch repository"),
+		),
+		Head: key.NewBinding(
+			key.WithKeys("H"),
+			key.WithHelp("H", "switch head"),
+		),
+		Stage: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "toggle staged/unstaged changes"),
+		),
+		Excluded: key.NewBinding(
+			key.WithKeys("I"),
+			key.WithHelp("I", "show/hide excluded files"),
+		),
+		CompareMode: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "toggle merge-base/direct comparison"),
+		),
+		Commits: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "pick a commit of the range"),
+		),
+		RangeSelect: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "select a range in the commit list"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("backspace"),
+			key.WithHelp("backspace", "back to all commits"),
+		),
+		RevertHunk: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "revert hunk in working tree"),
+		),
+		RevertFile: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "revert file in working tree"),
+		),
+		IntentToAdd: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "add untracked file with intent (git add -N)"),
+		),
+		LineEndings: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "ignore line ending changes"),
+		),
+		FullContext: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "show whole files"),
+		),
+		FinishReview: key.NewBinding(
+			key.WithKeys("V"),
+			key.WithHelp("V", "finish review"),
+		),
+		Export: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "export annotations (RDJSON, SARIF)"),
+		),
+		GoTo: key.NewBinding(
+			key.WithKeys(":"),
+			key.WithHelp(":", "go to file number or line"),
+		),
+		ReadingMode: key.NewBinding(
+			key.WithKeys("Z"),
+			key.WithHelp("Z", "hunk reading mode"),
+		),
+		Pin: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "pin diff above for A/B comparison, or unpin"),
+		),
+		NextHunk: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "next hunk (reading mode)"),
+		),
+		PrevHunk: key.NewBinding(
+			key.WithKeys("shift+space", "b"),
+			key.WithHelp("shift+space/b", "previous hunk (reading mode)"),
+		),
+		AlternateFile: key.NewBinding(
+			key.WithKeys("ctrl+^"),
+			key.WithHelp("ctrl+^", "previously viewed file"),
+		),
+		RecentFiles: key.NewBinding(
+			key.WithKeys("B"),
+			key.WithHelp("B", "recently viewed files"),
+		),
+		FileNumbers: key.NewBinding(
+			key.WithKeys("#"),
+			key.WithHelp("#", "toggle file numbers"),
+		),
+		Summary: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "summary of the changeset"),
+		),
+		Mine: key.NewBinding(
+			key.WithKeys("U"),
+			key.WithHelp("U", "only files you own (CODEOWNERS)"),
+		),
+		FileOrder: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "order files by path, quick wins or heavy first"),
+		),
+		OpenEditor: key.NewBinding(
+			key.WithKeys("O"),
+			key.WithHelp("O", "open in editor at line"),
+		),
+		TmuxSplit: key.NewBinding(
+			key.WithKeys("|"),
+			key.WithHelp("|", "open in tmux split"),
+		),
+		RefDiff: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "compare file between two refs"),
+		),
+		Difftool: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "open in external diff tool"),
+		),
+		LineNumbers: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "toggle line numbers"),
+		),
+		RelativeNums: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "toggle relative line numbers"),
+		),
+		CopyHunk: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "copy hunk as patch"),
+		),
+		CopyPatch: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "copy file diff as patch"),
+		),
+		Permalink: key.NewBinding(
+			key.WithKeys("Y"),
+			key.WithHelp("Y", "copy permalink to line"),
+		),
+		Comment: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "comment on hunk, or reply to thread"),
+		),
+		SetMark: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "mark line (then a letter)"),
+		),
+		JumpMark: key.NewBinding(
+			key.WithKeys("'"),
+			key.WithHelp("'", "jump to mark (then a letter)"),
+		),
+		Marks: key.NewBinding(
+			key.WithKeys("`"),
+			key.WithHelp("`", "list marks"),
+		),
+		Todos: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "list TODO/FIXME/HACK in added lines"),
+		),
+		Lint: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "run the linter"),
+		),
+		Conflicts: key.NewBinding(
+			key.WithKeys("M"),
+			key.WithHelp("M", "list files that would conflict when merging"),
+		),
+		GoTop:       NewChord("go to top", "g", "g"),
+		GoDiff:      NewChord("go to diff pane", "g", "d"),
+		ExpandFolds: NewChord("expand all folders", "z", "R"),
+		FoldAll:     NewChord("collapse all folders", "z", "M"),
+		NextChange:  NewChord("next hunk", "]", "c"),
+		PrevChange:  NewChord("previous hunk", "[", "c"),
+		NextFile:    NewChord("next file", "]", "f"),
+		PrevFile:    NewChord("previous file", "[", "f"),
 	}
 }
 
+// Chords returns the multi-key bindings
+func (k KeyMap) Chords() []Chord {
+	return []Chord{k.GoTop, k.GoDiff, k.ExpandFolds, k.FoldAll, k.NextChange, k.PrevChange, k.NextFile, k.PrevFile}
+}
+
+// KeyGroup is a set of bindings that apply in one context
+type KeyGroup struct {
+	Name     string
+	Bindings []key.Binding
+	Chords   []Chord
+}
+
+// Groups returns the bindings by the context they apply in
+func (k KeyMap) Groups() []KeyGroup {
+	return []KeyGroup{
+		{
+			Name: "Navigation",
+			Bindings: []key.Binding{
+				k.Up, k.Down, k.PageUp, k.PageDown, k.HalfPageUp, k.HalfPageDown, k.ScrollUp, k.ScrollDown, k.Home, k.End, k.Enter, k.Escape,
+				k.Tab, k.ShiftTab, k.PaneLeft, k.PaneRight, k.BracketLeft, k.BracketRight,
+			},
+			Chords: []Chord{k.GoTop, k.GoDiff, k.NextFile, k.PrevFile},
+		},
+		{
+			Name: "File list",
+			Bindings: []key.Binding{
+				k.Left, k.Right, k.CollapseAll, k.ExpandAll, k.Search, k.PathFilter, k.GoTo, k.FileNumbers, k.FileOrder, k.CopyPath, k.Reveal, k.IntentToAdd,
+				k.Worktrees, k.Repos, k.Head, k.Commits, k.RangeSelect, k.Back, k.CompareMode,
+			},
+			Chords: []Chord{k.ExpandFolds, k.FoldAll},
+		},
+		{
+			Name: "Diff view",
+			Bindings: []key.Binding{
+				k.Whitespace, k.LineNumbers, k.RelativeNums, k.RevertHunk, k.RevertFile,
+				k.CopyHunk, k.CopyPatch, k.Permalink, k.Comment, k.SetMark, k.ReadingMode, k.NextHunk, k.PrevHunk, k.Pin,
+			},
+			Chords: []Chord{k.NextChange, k.PrevChange},
+		},
+		{
+			Name: "Global",
+			Bindings: []key.Binding{
+				k.SearchContent, k.Summary, k.Refresh, k.Stage, k.Excluded, k.Mine, k.LineEndings, k.FullContext, k.FinishReview, k.Export, k.OpenEditor, k.TmuxSplit, k.Difftool, k.RefDiff,
+				k.AlternateFile, k.RecentFiles, k.JumpMark, k.Marks, k.Todos, k.Lint, k.Conflicts, k.Quit,
+			},
+		},
+	}
+}
+
+// ChordPrefix reports whether seq starts one of the chords
+func (k KeyMap) ChordPrefix(seq []string) bool {
+	return slices.ContainsFunc(k.Chords(), func(c Chord) bool { return c.HasPrefix(seq) })
+}
+
 // HelpKeys returns the keys to show in help
 func (k KeyMap) HelpKeys() []key.Binding {
 	return []key.Binding{
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MatchOptions are the search modifiers shared by the search overlays and
+// the file list search
+type MatchOptions struct {
+	CaseSensitive bool
+	WholeWord     bool
+}
+
+// Literal reports whether queries should be matched literally rather than
+// fuzzily. Fuzzy matching ignores case and word boundaries, so enabling
+// either modifier switches to literal matching.
+func (o MatchOptions) Literal() bool {
+	return o.CaseSensitive || o.WholeWord
+}
+
+// Compile builds a regexp for the query honoring the modifiers. Literal
+// queries are quoted; regex queries are used as-is.
+func (o MatchOptions) Compile(query string, regex bool) (*regexp.Regexp, error) {
+	pattern := query
+	if !regex {
+		pattern = regexp.QuoteMeta(query)
+	}
+	if o.WholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if !o.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// Indicator renders the active modifiers for display next to a match count
+func (o MatchOptions) Indicator() string {
+	var parts []string
+	if o.CaseSensitive {
+		parts = append(parts, "Aa")
+	}
+	if o.WholeWord {
+		parts = append(parts, "W")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(parts, " ")
+}
+
+// MatchedOffsets returns the byte offset of every rune covered by a match of
+// re in s, for highlighting. Empty matches cover none, so whether s matches
+// is for re.MatchString to tell.
+func MatchedOffsets(re *regexp.Regexp, s string) []int {
+	var offsets []int
+	for _, loc := range re.FindAllStringIndex(s, -1) {
+		for j := range s[loc[0]:loc[1]] {
+			offsets = append(offsets, loc[0]+j)
+		}
+	}
+	return offsets
+}
@@ -0,0 +1,268 @@
+// Package picker is a small overlay for choosing one entry from a list,
+// such as a worktree.
+package picker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
+)
+
+// Item is an entry in the picker
+type Item struct {
+	Label  string
+	Detail string // Shown dimmed after the label
+	Value  string // Identifies the item in SelectedMsg
+}
+
+// SelectedMsg is sent when an item is chosen. Kind is the value passed to
+// Open, so one picker can serve several lists. Range holds the items of a
+// range selection, in list order, when one was made.
+type SelectedMsg struct {
+	Kind  string
+	Item  Item
+	Range []Item
+}
+
+// CloseMsg is sent when the picker is dismissed without a choice
+type CloseMsg struct{}
+
+// Model represents the picker overlay
+type Model struct {
+	kind   string
+	title  string
+	items  []Item
+	cursor int
+	offset int
+	width  int
+	height int
+	active bool
+	ranged bool // Ranges can be selected
+	anchor int  // Item the range selection started at, or -1
+}
+
+// New creates a new picker model
+func New() Model {
+	return Model{}
+}
+
+// Open shows the picker with the cursor on item current
+func (m *Model) Open(kind, title string, items []Item, current int) {
+	m.kind = kind
+	m.title = title
+	m.items = items
+	m.cursor = max(0, min(current, len(items)-1))
+	m.offset = 0
+	m.active = true
+	m.ranged = false
+	m.anchor = -1
+	m.ensureVisible()
+}
+
+// SetRangeSelect lets the open list select a run of items with the range
+// key, instead of just one
+func (m *Model) SetRangeSelect(on bool) {
+	m.ranged = on
+}
+
+// selection returns the first and last item of the range selection
+func (m Model) selection() (int, int, bool) {
+	if m.anchor < 0 {
+		return 0, 0, false
+	}
+	return min(m.anchor, m.cursor), max(m.anchor, m.cursor), true
+}
+
+// Close hides the picker
+func (m *Model) Close() {
+	m.active = false
+}
+
+// IsActive returns whether the picker is shown
+func (m Model) IsActive() bool {
+	return m.active
+}
+
+// SetSize sets the screen dimensions
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ensureVisible()
+}
+
+// Update implements tea.Model
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !m.active || !ok {
+		return m, nil
+	}
+
+	keys := ui.DefaultKeyMap()
+	switch {
+	case key.Matches(keyMsg, keys.Escape) && m.anchor >= 0:
+		m.anchor = -1
+
+	case key.Matches(keyMsg, keys.Escape, keys.Quit):
+		m.Close()
+		return m, func() tea.Msg { return CloseMsg{} }
+
+	case key.Matches(keyMsg, keys.RangeSelect) && m.ranged:
+		if m.anchor >= 0 {
+			m.anchor = -1
+		} else {
+			m.anchor = m.cursor
+		}
+
+	case key.Matches(keyMsg, keys.Enter):
+		if m.cursor < len(m.items) {
+			selected := SelectedMsg{Kind: m.kind, Item: m.items[m.cursor]}
+			if first, last, ok := m.selection(); ok {
+				selected.Range = append([]Item(nil), m.items[first:last+1]...)
+			}
+			m.Close()
+			return m, func() tea.Msg { return selected }
+		}
+
+	case key.Matches(keyMsg, keys.Up):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case key.Matches(keyMsg, keys.Down):
+		if m.cursor < len(m.items)-1 {
+			m.cursor++
+		}
+
+	case key.Matches(keyMsg, keys.Home):
+		m.cursor = 0
+
+	case key.Matches(keyMsg, keys.End):
+		m.cursor = max(0, len(m.items)-1)
+	}
+
+	m.ensureVisible()
+	return m, nil
+}
+
+// visibleItems returns how many items fit in the overlay
+func (m Model) visibleItems() int {
+	// 80% of the screen minus border, title and separator
+	return max(1, min(len(m.items), m.height*80/100-4))
+}
+
+func (m *Model) ensureVisible() {
+	visible := m.visibleItems()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+visible {
+		m.offset = m.cursor - visible + 1
+	}
+}
+
+// RenderOverlay draws the picker centered over background
+func (m Model) RenderOverlay(background string) string {
+	if !m.active || m.width == 0 || m.height == 0 {
+		return background
+	}
+
+	// Size the box to its content, within 85% of the screen
+	innerWidth := text.Width(m.title)
+	for _, item := range m.items {
+		w := text.Width(item.Label) + 2
+		if item.Detail != "" {
+			w += text.Width(item.Detail) + 2
+		}
+		innerWidth = max(innerWidth, w)
+	}
+	innerWidth = max(20, min(innerWidth, m.width*85/100-4))
+
+	titleText := m.title
+	first, last, selecting := m.selection()
+	if selecting {
+		titleText += fmt.Sprintf(" · %d selected, Enter to open, Esc to cancel", last-first+1)
+	} else if m.ranged {
+		titleText += " · v to select a range"
+	}
+	innerWidth = max(innerWidth, min(text.Width(titleText), m.width*85/100-4))
+	title := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorPrimary).Render(text.Truncate(titleText, innerWidth, "…"))
+	lines := []string{title, lipgloss.NewStyle().Foreground(ui.ColorMuted).Render(strings.Repeat("─", innerWidth))}
+
+	if len(m.items) == 0 {
+		lines = append(lines, ui.EmptyStateStyle.Render("Nothing to choose from"))
+	}
+	end := min(m.offset+m.visibleItems(), len(m.items))
+	for i := m.offset; i < end; i++ {
+		inRange := selecting && i >= first && i <= last
+		lines = append(lines, m.renderItem(m.items[i], i == m.cursor, inRange, innerWidth))
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ui.ColorPrimary).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+
+	return m.composite(background, box)
+}
+
+func (m Model) renderItem(item Item, selected, inRange bool, width int) string {
+	cursor := "  "
+	if selected {
+		cursor = lipgloss.NewStyle().Foreground(ui.ColorPrimary).Bold(true).Render("> ")
+	} else if inRange {
+		cursor = lipgloss.NewStyle().Foreground(ui.ColorPrimary).Render("│ ")
+	}
+
+	label := text.Truncate(item.Label, width-2, "…")
+	line := cursor + label
+	if item.Detail != "" {
+		if room := width - 2 - text.Width(label) - 2; room > 0 {
+			line += "  " + lipgloss.NewStyle().Foreground(ui.ColorMuted).Render(text.Truncate(item.Detail, room, "…"))
+		}
+	}
+	if pad := width - lipgloss.Width(line); pad > 0 {
+		line += strings.Repeat(" ", pad)
+	}
+
+	if selected {
+		return ui.SelectedLineStyle.Render(line)
+	}
+	if inRange {
+		return ui.FileItemSelectedStyle.Render(line)
+	}
+	return line
+}
+
+// composite places box in the middle of the dimmed background
+func (m Model) composite(background, box string) string {
+	bgLines := strings.Split(background, "\n")
+	for len(bgLines) < m.height {
+		bgLines = append(bgLines, "")
+	}
+
+	dimStyle := lipgloss.NewStyle().Foreground(ui.ColorDim)
+	boxLines := strings.Split(box, "\n")
+	boxWidth := lipgloss.Width(box)
+	startRow := (m.height - len(boxLines)) / 2
+	startCol := max(0, (m.width-boxWidth)/2)
+
+	for i := range bgLines {
+		plain := text.Pad(ansi.Strip(bgLines[i]), m.width)
+		row := i - startRow
+		if row < 0 || row >= len(boxLines) {
+			bgLines[i] = dimStyle.Render(plain)
+			continue
+		}
+		left := dimStyle.Render(text.Columns(plain, 0, startCol))
+		right := dimStyle.Render(text.Columns(plain, startCol+boxWidth, text.Width(plain)))
+		bgLines[i] = left + boxLines[row] + right
+	}
+
+	return strings.Join(bgLines[:m.height], "\n")
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
 	"github.com/sahilm/fuzzy"
 )
 
@@ -38,12 +40,15 @@ type Model struct {
 	height      int
 	active      bool
 	viewMode    string // "both", "new", "old"
+	regexMode   bool   // Match with regular expressions instead of fuzzy search
+	queryErr    error  // Set when the regex query does not compile
+	matchOpts   ui.MatchOptions
 }
 
 // New creates a new search overlay model
 func New() Model {
 	ti := textinput.New()
-	ti.Placeholder = "Search content..."
+	ti.Placeholder = "Search content... (ctrl+r: regex)"
 	ti.CharLimit = 200
 	ti.Width = 40
 
@@ -134,6 +139,24 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			}
 			return m, nil
 
+		case "ctrl+r":
+			m.regexMode = !m.regexMode
+			m.updateMatches()
+			m.cursor = 0
+			m.offset = 0
+			return m, nil
+
+		case "alt+c", "alt+w":
+			if msg.String() == "alt+c" {
+				m.matchOpts.CaseSensitive = !m.matchOpts.CaseSensitive
+			} else {
+				m.matchOpts.WholeWord = !m.matchOpts.WholeWord
+			}
+			m.updateMatches()
+			m.cursor = 0
+			m.offset = 0
+			return m, nil
+
 		case "ctrl+u":
 			m.cursor -= 10
 			if m.cursor < 0 {
@@ -167,6 +190,20 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 }
 
 func (m *Model) updateMatches() {
+	m.queryErr = nil
+
+	// A "re:" prefix switches to regex matching for a single query
+	if pattern, ok := m.regexQuery(); ok {
+		m.updateRegexMatches(pattern, true)
+		return
+	}
+
+	// Case-sensitive and whole-word matching can't be fuzzy
+	if m.matchOpts.Literal() {
+		m.updateRegexMatches(m.searchInput.Value(), false)
+		return
+	}
+
 	query := strings.ReplaceAll(m.searchInput.Value(), " ", "")
 	if query == "" {
 		// Show all lines when no query
@@ -186,6 +223,45 @@ func (m *Model) updateMatches() {
 	m.matches = fuzzy.Find(query, strs)
 }
 
+// regexQuery returns the regex pattern when the query should be matched as a
+// regular expression
+func (m Model) regexQuery() (string, bool) {
+	query := m.searchInput.Value()
+	if pattern, ok := strings.CutPrefix(query, "re:"); ok {
+		return pattern, true
+	}
+	return query, m.regexMode
+}
+
+// updateRegexMatches matches lines against a regular expression (or a
+// literal string when regex is false), recording the byte offsets of every
+// matched rune for highlighting
+func (m *Model) updateRegexMatches(pattern string, regex bool) {
+	m.matches = nil
+	if pattern == "" {
+		m.matches = make([]fuzzy.Match, len(m.lines))
+		for i := range m.lines {
+			m.matches[i] = fuzzy.Match{Index: i}
+		}
+		return
+	}
+
+	re, err := m.matchOpts.Compile(pattern, regex)
+	if err != nil {
+		m.queryErr = err
+		return
+	}
+
+	// A match may be empty, as for ^$, so it decides which lines are listed
+	// and the offsets only what's highlighted
+	for i, line := range m.lines {
+		if !re.MatchString(line.Content) {
+			continue
+		}
+		m.matches = append(m.matches, fuzzy.Match{Str: line.Content, Index: i, MatchedIndexes: ui.MatchedOffsets(re, line.Content)})
+	}
+}
+
 func (m *Model) ensureVisible() {
 	visibleHeight := m.contentHeight()
 	if m.cursor < m.offset {
@@ -243,7 +319,9 @@ func (m Model) RenderOverlay(background string) string {
 	leftLines = append(leftLines, lipgloss.NewStyle().Foreground(ui.ColorMuted).Render(strings.Repeat("─", leftWidth)))
 
 	// Results
-	if len(m.matches) == 0 {
+	if m.queryErr != nil {
+		leftLines = append(leftLines, ui.ErrorStyle.Render(truncateError(m.queryErr, leftWidth)))
+	} else if len(m.matches) == 0 {
 		leftLines = append(leftLines, ui.EmptyStateStyle.Render("No matches"))
 	} else {
 		end := m.offset + contentHeight
@@ -328,10 +406,10 @@ func (m Model) compositeOverlay(background, overlay string, overlayW, overlayH i
 	}
 
 	// Dim the background
-	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#444444"))
+	dimStyle := lipgloss.NewStyle().Foreground(ui.ColorDim)
 	for i := range bgLines {
 		// Strip ANSI and dim
-		plain := stripAnsi(bgLines[i])
+		plain := ansi.Strip(bgLines[i])
 		// Pad to full width
 		if len(plain) < m.width {
 			plain = plain + strings.Repeat(" ", m.width-len(plain))
@@ -359,51 +437,34 @@ func (m Model) compositeOverlay(background, overlay string, overlayW, overlayH i
 
 // insertOverlayLine inserts overlay content at a specific column position
 func (m Model) insertOverlayLine(bgLine, overlayLine string, startCol int) string {
-	// For simplicity, just replace the middle portion
-	// This is a basic implementation - we're replacing characters
-	bgRunes := []rune(stripAnsi(bgLine))
-
-	// Ensure bg is wide enough
-	for len(bgRunes) < m.width {
-		bgRunes = append(bgRunes, ' ')
-	}
+	// Replace the middle columns of the dimmed background with the overlay
+	bg := text.Pad(ansi.Strip(bgLine), m.width)
 
 	// Create the result: dimmed left + overlay + dimmed right
-	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#444444"))
+	dimStyle := lipgloss.NewStyle().Foreground(ui.ColorDim)
 
 	left := ""
 	if startCol > 0 {
-		left = dimStyle.Render(string(bgRunes[:startCol]))
+		left = dimStyle.Render(text.Columns(bg, 0, startCol))
 	}
 
 	right := ""
 	overlayWidth := lipgloss.Width(overlayLine)
 	endCol := startCol + overlayWidth
-	if endCol < len(bgRunes) {
-		right = dimStyle.Render(string(bgRunes[endCol:]))
+	if bgWidth := text.Width(bg); endCol < bgWidth {
+		right = dimStyle.Render(text.Columns(bg, endCol, bgWidth))
 	}
 
 	return left + overlayLine + right
 }
 
-// stripAnsi removes ANSI escape codes from a string
-func stripAnsi(s string) string {
-	var result strings.Builder
-	inEscape := false
-	for _, r := range s {
-		if r == '\x1b' {
-			inEscape = true
-			continue
-		}
-		if inEscape {
-			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
-				inEscape = false
-			}
-			continue
-		}
-		result.WriteRune(r)
+// truncateError formats a regex compile error to fit on one line
+func truncateError(err error, width int) string {
+	msg := strings.TrimPrefix(err.Error(), "error parsing regexp: ")
+	if width > 1 {
+		msg = text.Truncate(msg, width, "…")
 	}
-	return result.String()
+	return msg
 }
 
 func (m Model) renderSearchInput(width int) string {
@@ -417,7 +478,18 @@ func (m Model) renderSearchInput(width int) string {
 	m.searchInput.Width = inputWidth
 	input := m.searchInput.View()
 
-	count := fmt.Sprintf(" [%d]", len(m.matches))
+	mode := ""
+	if _, ok := m.regexQuery(); ok {
+		mode = " re"
+	}
+	mode += m.matchOpts.Indicator()
+
+	if m.queryErr != nil {
+		errText := lipgloss.NewStyle().Foreground(ui.ColorDanger).Render(mode + " [!]")
+		return prefix + input + errText
+	}
+
+	count := fmt.Sprintf("%s [%d]", mode, len(m.matches))
 	countStyled := lipgloss.NewStyle().Foreground(ui.ColorMuted).Render(count)
 
 	return prefix + input + countStyled
@@ -448,15 +520,13 @@ func (m Model) renderResultLine(line SearchLine, selected bool, width int, match
 	}
 	lineNumStyled := lipgloss.NewStyle().Foreground(ui.ColorMuted).Render(lineNum)
 
-	// Content (truncate if needed)
-	content := line.Content
+	// Content (truncate if needed), without the CR of a CRLF line ending
+	content := strings.TrimSuffix(line.Content, "\r")
 	maxContentWidth := width - 10
 	if maxContentWidth < 5 {
 		maxContentWidth = 5
 	}
-	if len(content) > maxContentWidth {
-		content = content[:maxContentWidth-1] + "…"
-	}
+	content = text.Truncate(content, maxContentWidth, "…")
 
 	// Highlight matched characters
 	var styledContent string
@@ -481,9 +551,7 @@ func (m Model) renderResultLine(line SearchLine, selected bool, width int, match
 	}
 
 	if selected {
-		return lipgloss.NewStyle().
-			Background(lipgloss.Color("#2a2a3a")).
-			Render(lineStr)
+		return ui.SelectedLineStyle.Render(lineStr)
 	}
 
 	return lineStr
@@ -538,25 +606,24 @@ func (m Model) renderPreview(centerIdx int, width int, height int) []string {
 		isCenter := i == centerIdx
 
 		// Styling based on line type
-		var bgColor lipgloss.Color
-		var fgColor lipgloss.Color
+		var bgColor lipgloss.TerminalColor = lipgloss.NoColor{}
+		var fgColor lipgloss.TerminalColor
 		var prefix string
 
 		switch line.Type {
 		case "add":
-			bgColor = lipgloss.Color("#0a1a0a")
-			fgColor = lipgloss.Color("#88cc88")
+			bgColor = ui.ColorAdditionBg
+			fgColor = ui.ColorAdditionFg
 			prefix = "+"
 		case "del":
-			bgColor = lipgloss.Color("#1a0a0a")
-			fgColor = lipgloss.Color("#cc8888")
+			bgColor = ui.ColorDeletionBg
+			fgColor = ui.ColorDeletionFg
 			prefix = "-"
 		case "header":
-			bgColor = lipgloss.Color("#0a0a1a")
-			fgColor = lipgloss.Color("#8888cc")
+			bgColor = ui.ColorHunkBg
+			fgColor = ui.ColorHunkFg
 			prefix = "@"
 		default:
-			bgColor = lipgloss.Color("")
 			fgColor = ui.ColorTextMuted
 			prefix = " "
 		}
@@ -568,19 +635,12 @@ func (m Model) renderPreview(centerIdx int, width int, height int) []string {
 		}
 
 		// Content
-		content := line.Content
+		content := strings.TrimSuffix(line.Content, "\r")
 		maxWidth := width - 8
 		if maxWidth < 5 {
 			maxWidth = 5
 		}
-		if len(content) > maxWidth {
-			content = content[:maxWidth-1] + "…"
-		}
-
-		// Pad content
-		if len(content) < maxWidth {
-			content = content + strings.Repeat(" ", maxWidth-len(content))
-		}
+		content = text.Fit(content, maxWidth)
 
 		// Build line
 		lineNumStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted)
@@ -590,10 +650,7 @@ func (m Model) renderPreview(centerIdx int, width int, height int) []string {
 
 		// Highlight center line
 		if isCenter {
-			renderedLine = lipgloss.NewStyle().
-				Background(lipgloss.Color("#3a3a5a")).
-				Bold(true).
-				Render(renderedLine)
+			renderedLine = ui.PreviewFocusStyle.Render(renderedLine)
 		}
 
 		lines = append(lines, renderedLine)
@@ -15,6 +15,29 @@ var (
 	ColorText       = lipgloss.Color("#F9FAFB") // White
 	ColorTextMuted  = lipgloss.Color("#9CA3AF") // Light gray
 
+	// Diff line tints. These are too dark to survive the automatic
+	// conversion, so each has explicit 256 and 16 color fallbacks; 16 color
+	// terminals drop the backgrounds.
+	ColorAdditionBg     = lipgloss.CompleteColor{TrueColor: "#0a1a0a", ANSI256: "22"}
+	ColorAdditionFg     = lipgloss.CompleteColor{TrueColor: "#88cc88", ANSI256: "114", ANSI: "2"}
+	ColorDeletionBg     = lipgloss.CompleteColor{TrueColor: "#1a0a0a", ANSI256: "52"}
+	ColorDeletionFg     = lipgloss.CompleteColor{TrueColor: "#cc8888", ANSI256: "174", ANSI: "1"}
+	ColorAdditionEmphBg = lipgloss.CompleteColor{TrueColor: "#1f4a1f", ANSI256: "28"}
+	ColorDeletionEmphBg = lipgloss.CompleteColor{TrueColor: "#4a1f1f", ANSI256: "88"}
+	ColorHunkBg         = lipgloss.CompleteColor{TrueColor: "#0a0a1a", ANSI256: "17"}
+	ColorHunkFg         = lipgloss.CompleteColor{TrueColor: "#8888cc", ANSI256: "104", ANSI: "4"}
+	ColorMovedDelBg     = lipgloss.CompleteColor{TrueColor: "#1a0a1a", ANSI256: "53"}
+	ColorMovedDelAltBg  = lipgloss.CompleteColor{TrueColor: "#2a102a", ANSI256: "89"}
+	ColorMovedDelFg     = lipgloss.CompleteColor{TrueColor: "#cc88cc", ANSI256: "176", ANSI: "5"}
+	ColorMovedAddBg     = lipgloss.CompleteColor{TrueColor: "#0a1a1a", ANSI256: "23"}
+	ColorMovedAddAltBg  = lipgloss.CompleteColor{TrueColor: "#102a2a", ANSI256: "30"}
+	ColorMovedAddFg     = lipgloss.CompleteColor{TrueColor: "#88cccc", ANSI256: "116", ANSI: "6"}
+	ColorConflictBg     = lipgloss.CompleteColor{TrueColor: "#3a1a00", ANSI256: "94"}
+	ColorConflictFg     = lipgloss.CompleteColor{TrueColor: "#ffb060", ANSI256: "215", ANSI: "3"}
+	ColorHighlight      = lipgloss.CompleteColor{TrueColor: "#2a2a3a", ANSI256: "236", ANSI: "8"}
+	ColorFocusLine      = lipgloss.CompleteColor{TrueColor: "#3a3a5a", ANSI256: "60", ANSI: "4"}
+	ColorDim            = lipgloss.CompleteColor{TrueColor: "#444444", ANSI256: "238", ANSI: "8"}
+
 	// Header style
 	HeaderStyle = lipgloss.NewStyle().
 			Bold(true).
@@ -28,6 +51,12 @@ var (
 			Background(ColorBackground).
 			Padding(0, 1)
 
+	// Status bar at the start of the footer
+	StatusBarStyle = lipgloss.NewStyle().
+			Foreground(ColorText).
+			Background(ColorSurface).
+			Padding(0, 1)
+
 	// Pane styles
 	PaneStyle = lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
@@ -59,6 +88,11 @@ var (
 				Foreground(ColorSecondary).
 				Bold(true)
 
+	StatusConflictStyle = lipgloss.NewStyle().
+				Foreground(ColorDanger).
+				Bold(true).
+				Underline(true)
+
 	// File list styles
 	FileItemStyle = lipgloss.NewStyle().
 			Foreground(ColorText)
@@ -105,6 +139,46 @@ var (
 				Foreground(ColorWarning).
 				Bold(true)
 
+	// Selected row in the search and file picker results
+	SelectedLineStyle = lipgloss.NewStyle().
+				Background(ColorHighlight)
+
+	// Line the search and file picker previews are centered on
+	PreviewFocusStyle = lipgloss.NewStyle().
+				Background(ColorFocusLine).
+				Bold(true)
+
+	// Line numbers of lines with linter diagnostics
+	LintMarkerStyle = lipgloss.NewStyle().
+			Foreground(ColorWarning).
+			Bold(true).
+			Underline(true)
+
+	// Files and diffs with added lines that look like credentials
+	SecretBadgeStyle = lipgloss.NewStyle().
+				Foreground(ColorText).
+				Background(ColorDanger).
+				Bold(true)
+
+	SecretBannerStyle = lipgloss.NewStyle().
+				Foreground(ColorText).
+				Background(ColorDanger).
+				Bold(true).
+				Padding(0, 1)
+
+	// Comment threads below a hunk of the diff
+	CommentStyle = lipgloss.NewStyle().
+			Foreground(ColorText).
+			Background(ColorSurface).
+			Bold(true)
+
+	// Footer showing a transient error
+	ToastStyle = lipgloss.NewStyle().
+			Foreground(ColorText).
+			Background(ColorDanger).
+			Bold(true).
+			Padding(0, 1)
+
 	// Error style
 	ErrorStyle = lipgloss.NewStyle().
 			Foreground(ColorDanger).
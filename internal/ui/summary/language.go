@@ -0,0 +1,87 @@
+package summary
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
+)
+
+// languageWidth is the width of the language name column
+const languageWidth = 16
+
+// language names the language of a file after the lexer highlighting it, or
+// its extension when there is none
+func language(path string) string {
+	if lexer := lexers.Match(path); lexer != nil {
+		if name := lexer.Config().Name; name != "plaintext" {
+			return name
+		}
+		return "Text"
+	}
+	if ext := filepath.Ext(path); ext != "" {
+		return ext
+	}
+	return "Other"
+}
+
+// languageChart renders a row per language: its share of the changed
+// lines, a bar of its additions and deletions scaled to the largest
+// language, and how much of it is generated
+func languageChart(groups []group, total, width int) []string {
+	mutedStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted)
+	addStyle := lipgloss.NewStyle().Foreground(ui.ColorSuccess)
+	delStyle := lipgloss.NewStyle().Foreground(ui.ColorDanger)
+
+	// The bar takes what the name, share, counts and generated note leave
+	barWidth := max(10, min(40, width-languageWidth-48))
+	largest := 0
+	for _, g := range groups {
+		largest = max(largest, g.lines())
+	}
+
+	var lines []string
+	for i, g := range groups {
+		if i == maxRows {
+			lines = append(lines, "   "+mutedStyle.Render(fmt.Sprintf("and %d more", len(groups)-maxRows)))
+			break
+		}
+		adds := barCells(g.additions, largest, barWidth)
+		dels := min(barCells(g.deletions, largest, barWidth), barWidth-adds)
+		bar := addStyle.Render(strings.Repeat("█", adds)) +
+			delStyle.Render(strings.Repeat("█", dels)) +
+			strings.Repeat(" ", max(0, barWidth-adds-dels))
+		line := fmt.Sprintf("   %s %s  %s %s %s",
+			text.Pad(text.Truncate(g.name, languageWidth, "…"), languageWidth),
+			mutedStyle.Render(fmt.Sprintf("%4s", percent(g.lines(), total))),
+			bar,
+			addStyle.Render(fmt.Sprintf("%7s", fmt.Sprintf("+%d", g.additions))),
+			delStyle.Render(fmt.Sprintf("%7s", fmt.Sprintf("-%d", g.deletions))))
+		if g.generated > 0 {
+			line += mutedStyle.Render(fmt.Sprintf("  %s generated", percent(g.generated, g.lines())))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// barCells scales n lines to the cells of a bar whose full width stands for
+// largest lines, keeping any change visible
+func barCells(n, largest, width int) int {
+	if n == 0 || largest == 0 {
+		return 0
+	}
+	return max(1, n*width/largest)
+}
+
+// percent formats part of whole as a rounded percentage
+func percent(part, whole int) string {
+	if whole == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%d%%", (part*100+whole/2)/whole)
+}
@@ -0,0 +1,206 @@
+// Package summary shows an overview of the changeset before it is reviewed
+// file by file: how much changed, in how many commits, where and in which
+// languages.
+package summary
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
+)
+
+// maxRows bounds the directories and languages listed
+const maxRows = 8
+
+// maxNameWidth bounds the column of directory and language names
+const maxNameWidth = 40
+
+// Model represents the summary pane
+type Model struct {
+	files   []git.ChangedFile
+	commits int // Commits in the range, or -1 when not known
+	width   int
+	height  int
+}
+
+// group totals the changes of the files sharing a directory or language
+type group struct {
+	name      string
+	files     int
+	additions int
+	deletions int
+	generated int // Lines changed in generated files
+}
+
+func (g group) lines() int {
+	return g.additions + g.deletions
+}
+
+// New creates a new summary model
+func New() Model {
+	return Model{commits: -1}
+}
+
+// SetSize sets the dimensions of the pane
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+// SetFiles sets the changed files summed up
+func (m *Model) SetFiles(files []git.ChangedFile) {
+	m.files = files
+}
+
+// SetCommits sets the number of commits in the range, -1 when it isn't
+// known or doesn't apply
+func (m *Model) SetCommits(n int) {
+	m.commits = n
+}
+
+// dir returns the directory a file is counted under: its parent folder, or
+// the top level
+func dir(path string) string {
+	d := filepath.ToSlash(filepath.Dir(path))
+	if d == "." {
+		return "Top level"
+	}
+	return d + "/"
+}
+
+// groupBy totals the files by the name key gives them, largest first
+func groupBy(files []git.ChangedFile, key func(string) string) []group {
+	var groups []group
+	index := make(map[string]int)
+	for _, f := range files {
+		name := key(f.Path)
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, group{name: name})
+		}
+		groups[i].files++
+		groups[i].additions += f.Additions
+		groups[i].deletions += f.Deletions
+		if f.Generated {
+			groups[i].generated += f.Additions + f.Deletions
+		}
+	}
+	slices.SortStableFunc(groups, func(a, b group) int {
+		if c := cmp.Compare(b.lines(), a.lines()); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.files, a.files)
+	})
+	return groups
+}
+
+// statusCounts describes how many files were added, modified and so on
+func statusCounts(files []git.ChangedFile) string {
+	counts := make(map[git.FileStatus]int)
+	for _, f := range files {
+		counts[f.Status]++
+	}
+	var parts []string
+	for _, s := range []struct {
+		status git.FileStatus
+		name   string
+	}{
+		{git.StatusModified, "modified"},
+		{git.StatusAdded, "added"},
+		{git.StatusDeleted, "deleted"},
+		{git.StatusRenamed, "renamed"},
+		{git.StatusUnknown, "untracked"},
+	} {
+		if n := counts[s.status]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, s.name))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// plural returns "n thing" or "n things"
+func plural(n int, thing string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, thing)
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
+
+// View renders the summary pane
+func (m Model) View() string {
+	if m.width == 0 || m.height == 0 {
+		return ""
+	}
+	innerWidth := m.width - 4
+
+	labelStyle := lipgloss.NewStyle().Foreground(ui.ColorMuted)
+	headingStyle := lipgloss.NewStyle().Bold(true).Foreground(ui.ColorPrimary)
+	addStyle := lipgloss.NewStyle().Foreground(ui.ColorSuccess)
+	delStyle := lipgloss.NewStyle().Foreground(ui.ColorDanger)
+
+	var additions, deletions int
+	for _, f := range m.files {
+		additions += f.Additions
+		deletions += f.Deletions
+	}
+
+	lines := []string{ui.PaneTitleStyle.Render("SUMMARY"), ""}
+	row := func(label, value string) {
+		lines = append(lines, " "+labelStyle.Render(fmt.Sprintf("%-9s", label))+value)
+	}
+	files := plural(len(m.files), "file")
+	if counts := statusCounts(m.files); counts != "" {
+		files += labelStyle.Render(" (" + counts + ")")
+	}
+	row("Files", files)
+	row("Lines", addStyle.Render(fmt.Sprintf("+%d", additions))+" "+delStyle.Render(fmt.Sprintf("-%d", deletions)))
+	if m.commits >= 0 {
+		row("Commits", fmt.Sprintf("%d", m.commits))
+	}
+
+	// The name column takes what the counts leave, up to maxNameWidth
+	table := func(title string, groups []group) {
+		lines = append(lines, "", " "+headingStyle.Render(title))
+		nameWidth := max(8, min(maxNameWidth, innerWidth-34))
+		for i, g := range groups {
+			if i == maxRows {
+				lines = append(lines, "   "+labelStyle.Render(fmt.Sprintf("and %d more", len(groups)-maxRows)))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("   %s %s  %s %s",
+				text.Pad(text.TruncateLeft(g.name, nameWidth, "…"), nameWidth),
+				labelStyle.Render(fmt.Sprintf("%9s", plural(g.files, "file"))),
+				addStyle.Render(fmt.Sprintf("%7s", fmt.Sprintf("+%d", g.additions))),
+				delStyle.Render(fmt.Sprintf("%7s", fmt.Sprintf("-%d", g.deletions)))))
+		}
+	}
+	if len(m.files) > 0 {
+		table("Top directories", groupBy(m.files, dir))
+		lines = append(lines, "", " "+headingStyle.Render("Languages"))
+		lines = append(lines, languageChart(groupBy(m.files, language), additions+deletions, innerWidth)...)
+	}
+
+	lines = append(lines, "", " "+labelStyle.Render("s, enter or esc to browse the files"))
+
+	maxLines := m.height - 2
+	for len(lines) < maxLines {
+		lines = append(lines, "")
+	}
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+	}
+
+	return ui.PaneFocusedStyle.
+		Width(m.width - 2).
+		MaxHeight(m.height).
+		Render(strings.Join(lines, "\n"))
+}
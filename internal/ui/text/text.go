@@ -0,0 +1,98 @@
+// Package text measures, truncates and pads strings by terminal cells
+// rather than bytes, so wide (CJK, emoji) and multi-byte characters keep
+// columns aligned.
+package text
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// cells measures like lipgloss does: ambiguous-width characters take one
+// cell regardless of locale
+var cells = func() *runewidth.Condition {
+	c := runewidth.NewCondition()
+	c.EastAsianWidth = false
+	return c
+}()
+
+// Width returns the number of cells s occupies
+func Width(s string) int {
+	return cells.StringWidth(s)
+}
+
+// Truncate cuts s to at most width cells, ending with tail if it was cut
+func Truncate(s string, width int, tail string) string {
+	if width <= 0 {
+		return ""
+	}
+	return cells.Truncate(s, width, tail)
+}
+
+// TruncateLeft keeps the end of s within width cells, starting with head if
+// it was cut
+func TruncateLeft(s string, width int, head string) string {
+	w := Width(s)
+	if w <= width {
+		return s
+	}
+	return cells.TruncateLeft(s, w-width+Width(head), head)
+}
+
+// Pad appends spaces to s until it is width cells wide
+func Pad(s string, width int) string {
+	if w := Width(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// Fit truncates or pads s to exactly width cells
+func Fit(s string, width int) string {
+	return Pad(Truncate(s, width, "…"), width)
+}
+
+// Columns returns the part of s between cell columns start and end. A wide
+// character split by either edge is replaced with spaces.
+func Columns(s string, start, end int) string {
+	if end <= start {
+		return ""
+	}
+	rest := s
+	if start > 0 {
+		rest = cells.TruncateLeft(s, start, "")
+	}
+	prefix, w := Take(rest, end-start)
+	if w < end-start && Width(rest) > w {
+		prefix += strings.Repeat(" ", end-start-w)
+	}
+	return prefix
+}
+
+// Take returns the longest prefix of s that fits in width cells, without a
+// tail, along with its width
+func Take(s string, width int) (string, int) {
+	prefix := cells.Truncate(s, width, "")
+	return prefix, Width(prefix)
+}
+
+// ExpandTabs replaces tabs with spaces up to the next multiple of tabWidth
+func ExpandTabs(s string, tabWidth int) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var b strings.Builder
+	col := 0
+	for _, r := range s {
+		if r == '\t' {
+			n := tabWidth - col%tabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		b.WriteRune(r)
+		col += cells.RuneWidth(r)
+	}
+	return b.String()
+}
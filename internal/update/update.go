@@ -0,0 +1,283 @@
+// Package update replaces the running binary with the latest GitHub release.
+//
+// Releases are expected to carry an archive per platform named like
+// git-diffs_1.2.0_linux_amd64.tar.gz (.zip on Windows) and a checksums.txt
+// listing the SHA-256 of every asset, as goreleaser publishes them.
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Repo is the GitHub repository releases are fetched from
+const Repo = "matthewmyrick/git-diffs"
+
+// binaryName is the executable inside the release archives
+const binaryName = "git-diffs"
+
+// maxAssetSize bounds downloads, so a wrong URL can't fill the disk
+const maxAssetSize = 200 << 20
+
+var client = &http.Client{Timeout: 2 * time.Minute}
+
+// Release is a published GitHub release
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Latest returns the newest release that isn't a draft or prerelease
+func Latest() (*Release, error) {
+	body, err := get("https://api.github.com/repos/" + Repo + "/releases/latest")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check the latest release: %w", err)
+	}
+	var release Release
+	if err := json.Unmarshal(body, &release); err != nil {
+		return nil, fmt.Errorf("failed to read the latest release: %w", err)
+	}
+	return &release, nil
+}
+
+// Version returns the release's version without the "v" prefix
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// IsNewer reports whether the release is newer than current, a version
+// with or without a "v" prefix. Versions are compared numerically by
+// dotted component; anything unparsable counts as older.
+func (r *Release) IsNewer(current string) bool {
+	return compareVersions(r.Version(), strings.TrimPrefix(current, "v")) > 0
+}
+
+// archive returns the asset holding the binary for this platform
+func (r *Release) archive() (Asset, error) {
+	suffix := fmt.Sprintf("_%s_%s", runtime.GOOS, runtime.GOARCH)
+	for _, a := range r.Assets {
+		name := strings.TrimSuffix(strings.TrimSuffix(a.Name, ".tar.gz"), ".zip")
+		if name != a.Name && strings.HasPrefix(name, binaryName+"_") && strings.HasSuffix(name, suffix) {
+			return a, nil
+		}
+	}
+	return Asset{}, fmt.Errorf("release %s has no build for %s/%s", r.Tag, runtime.GOOS, runtime.GOARCH)
+}
+
+// checksum returns the SHA-256 checksums.txt lists for name
+func (r *Release) checksum(name string) (string, error) {
+	for _, a := range r.Assets {
+		if a.Name != "checksums.txt" {
+			continue
+		}
+		body, err := get(a.URL)
+		if err != nil {
+			return "", fmt.Errorf("failed to download checksums: %w", err)
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			// "<sha256>  <file>"
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 2 && fields[1] == name {
+				return fields[0], nil
+			}
+		}
+		return "", fmt.Errorf("checksums.txt doesn't list %s", name)
+	}
+	return "", fmt.Errorf("release %s has no checksums.txt", r.Tag)
+}
+
+// Install downloads the release's build for this platform, verifies its
+// checksum and replaces the executable at exe with it
+func (r *Release) Install(exe string) error {
+	asset, err := r.archive()
+	if err != nil {
+		return err
+	}
+	want, err := r.checksum(asset.Name)
+	if err != nil {
+		return err
+	}
+	data, err := get(asset.URL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, want) {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", asset.Name, got, want)
+	}
+
+	var binary []byte
+	if strings.HasSuffix(asset.Name, ".zip") {
+		binary, err = extractZip(data)
+	} else {
+		binary, err = extractTarGz(data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unpack %s: %w", asset.Name, err)
+	}
+	return replace(exe, binary)
+}
+
+// replace swaps the file at exe for binary. The new file is written next to
+// it and renamed over it, so a failure leaves the old one in place. Windows
+// can't replace a running executable, but it can rename it out of the way.
+func replace(exe string, binary []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), "."+filepath.Base(exe)+".new-*")
+	if err != nil {
+		return fmt.Errorf("can't write next to %s: %w", exe, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+
+	if runtime.GOOS != "windows" {
+		return os.Rename(tmp.Name(), exe)
+	}
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		// Put the running binary back, so a failed update leaves one behind
+		os.Rename(old, exe)
+		return err
+	}
+	return nil
+}
+
+// isBinary reports whether an archive entry is the executable
+func isBinary(name string) bool {
+	base := path.Base(name)
+	return base == binaryName || base == binaryName+".exe"
+}
+
+func extractTarGz(data []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.New(binaryName + " not found in archive")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && isBinary(hdr.Name) {
+			return io.ReadAll(io.LimitReader(tr, maxAssetSize))
+		}
+	}
+}
+
+func extractZip(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if !isBinary(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(io.LimitReader(rc, maxAssetSize))
+	}
+	return nil, errors.New(binaryName + " not found in archive")
+}
+
+// get downloads url
+func get(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", binaryName)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxAssetSize))
+}
+
+// compareVersions compares dotted numeric versions such as 1.10.2,
+// ignoring any "-rc1" style suffix
+func compareVersions(a, b string) int {
+	as, bs := versionParts(a), versionParts(b)
+	for i := range max(len(as), len(bs)) {
+		var x, y int
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	v, _, _ = strings.Cut(v, "-")
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n := 0
+		for _, c := range s {
+			if c < '0' || c > '9' {
+				return parts
+			}
+			n = n*10 + int(c-'0')
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/matthewmyrick/git-diffs/internal/config"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/matthewmyrick/git-diffs/internal/ui/text"
+)
+
+// runKeys implements "git-diffs keys", printing the key bindings in effect
+// with the given config, and returns the exit code
+func runKeys(args []string) int {
+	fs := flag.NewFlagSet("keys", flag.ContinueOnError)
+	format := fs.String("format", "txt", "Output format: txt or md")
+	configPath := fs.String("config", "", "Config file (default: "+config.DefaultPath()+")")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: git-diffs keys [--format=txt|md] [--config FILE]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	groups := keyGroups(ui.DefaultKeyMap(), cfg.Commands)
+
+	switch *format {
+	case "txt":
+		writeKeysText(os.Stdout, groups)
+	case "md":
+		writeKeysMarkdown(os.Stdout, groups)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q: want txt or md\n", *format)
+		return 2
+	}
+	return 0
+}
+
+// keyRow is a key and what it does
+type keyRow struct {
+	keys, desc string
+}
+
+// keyGroup is a titled table of keys
+type keyGroup struct {
+	name string
+	rows []keyRow
+}
+
+// keyGroups lists the keymap's bindings by context, followed by the custom
+// commands, which take precedence over the built-in keys
+func keyGroups(km ui.KeyMap, commands map[string]string) []keyGroup {
+	var groups []keyGroup
+	for _, g := range km.Groups() {
+		group := keyGroup{name: g.Name}
+		for _, b := range g.Bindings {
+			if !b.Enabled() {
+				continue
+			}
+			h := b.Help()
+			desc := h.Desc
+			if slices.ContainsFunc(b.Keys(), func(k string) bool { return commands[k] != "" }) {
+				desc += " (overridden by a custom command)"
+			}
+			group.rows = append(group.rows, keyRow{h.Key, desc})
+		}
+		for _, c := range g.Chords {
+			keys, desc := c.Help()
+			group.rows = append(group.rows, keyRow{keys, desc})
+		}
+		groups = append(groups, group)
+	}
+
+	if len(commands) > 0 {
+		group := keyGroup{name: "Custom commands"}
+		for _, k := range slices.Sorted(maps.Keys(commands)) {
+			group.rows = append(group.rows, keyRow{k, commands[k]})
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// writeKeysText prints the groups as aligned plain text
+func writeKeysText(w io.Writer, groups []keyGroup) {
+	width := 0
+	for _, g := range groups {
+		for _, r := range g.rows {
+			width = max(width, text.Width(r.keys))
+		}
+	}
+	for i, g := range groups {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, g.name)
+		for _, r := range g.rows {
+			pad := strings.Repeat(" ", width-text.Width(r.keys))
+			fmt.Fprintf(w, "  %s%s  %s\n", r.keys, pad, r.desc)
+		}
+	}
+}
+
+// writeKeysMarkdown prints a table per group
+func writeKeysMarkdown(w io.Writer, groups []keyGroup) {
+	fmt.Fprintln(w, "# git-diffs key bindings")
+	for _, g := range groups {
+		fmt.Fprintf(w, "\n## %s\n\n", g.name)
+		fmt.Fprintln(w, "| Key | Action |")
+		fmt.Fprintln(w, "|-----|--------|")
+		for _, r := range g.rows {
+			fmt.Fprintf(w, "| %s | %s |\n", markdownCode(r.keys), markdownCell(r.desc))
+		}
+	}
+}
+
+// markdownCode formats s as inline code that may itself contain backticks
+// or pipes
+func markdownCode(s string) string {
+	s = markdownCell(s)
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
+
+// markdownCell escapes the pipes that would end a table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -4,20 +4,364 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/matthewmyrick/git-diffs/internal/app"
+	"github.com/matthewmyrick/git-diffs/internal/config"
+	"github.com/matthewmyrick/git-diffs/internal/export"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/script"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
 )
 
 func main() {
-	baseBranch := flag.String("base", "", "Base branch to compare against (default: main or master)")
-	flag.Parse()
+	started := time.Now()
 
-	m := app.New(*baseBranch)
+	baseBranch := flag.String("base", "", "Base branch to compare against (default: the pull request's target, upstream, origin/HEAD, then main or master)")
+	head := flag.String("head", "", "Ref to compare with the base instead of HEAD; remote branches such as origin/feature are fetched if missing")
+	workspace := flag.Bool("workspace", false, "Switch between the git repositories found below the current directory")
+	compare := flag.String("compare", "merge-base", "How to compare with the base: merge-base (base...HEAD) or direct (base..HEAD)")
+	fetch := flag.Bool("fetch", false, "Fetch the base branch's remote before diffing")
+	readOnly := flag.Bool("read-only", false, "Disable everything that changes the repository: reverting, fetching, custom commands and the merge check")
+	stash := flag.Int("stash", -1, "Show the changes saved in stash@{N} instead of the branch")
+	worktree := flag.String("worktree", "", "Worktree to diff, as a path or the branch checked out in it (default: current directory)")
+	configPath := flag.String("config", "", "Config file (default: "+config.DefaultPath()+")")
+	noColor := flag.Bool("no-color", false, "Disable colors (same as setting $NO_COLOR)")
+	debug := flag.Bool("debug", false, "Log startup timings to git-diffs-debug.log in the temp directory")
+	scriptPath := flag.String("script", "", "Run a file of key events headlessly and write the rendered frames")
+	scriptOut := flag.String("script-out", "frames", "Directory to write script frames to")
+	scriptSteps := flag.Bool("script-steps", false, "Write a frame after every scripted key, not just the final one")
+	scriptSize := flag.String("script-size", "120x40", "Terminal size (WIDTHxHEIGHT) used for scripted runs")
+	scriptANSI := flag.Bool("script-ansi", false, "Keep colors in script frames as ANSI escape codes instead of writing plain text")
+	pagerMode := flag.String("pager", "auto", "Print the diffs through $PAGER instead of starting the TUI: auto (when stdin or stdout isn't a terminal), always or never")
+	pagerLayout := flag.String("pager-layout", "unified", "Layout of printed diffs: unified or side-by-side")
+	host := flag.String("host", "localhost", "Address git-diffs serve listens on (0.0.0.0 to share on the network)")
+	port := flag.Int("port", 0, "Port git-diffs serve (default 8080) and serve-ssh (default 2222) listen on")
+	authorizedKeys := flag.String("authorized-keys", "", "authorized_keys file of the people git-diffs serve-ssh lets watch (default: anyone who can connect)")
+	inline := flag.Bool("inline", false, "Render in the normal screen buffer instead of the alternate screen, so the diff stays in the scrollback after quitting")
+	inlineHeight := flag.Int("inline-height", 20, "Number of lines the UI takes with --inline")
+	showVersion := flag.Bool("version", false, "Print the version, commit and build date, then exit")
+	flag.Usage = usage
 
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "update" {
+		os.Exit(runUpdate(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "keys" {
+		os.Exit(runKeys(args[1:]))
+	}
+
+	// Subcommands select an alternative source; flags may follow them
+	subcommand := ""
+	if len(args) > 0 && (args[0] == "range-diff" || args[0] == "dir" || args[0] == "pick" || args[0] == "status" || args[0] == "serve" || args[0] == "serve-ssh") {
+		subcommand = args[0]
+		args = args[1:]
+	}
+	parseArgs(args)
+	if *showVersion {
+		fmt.Println(versionString())
+		return
+	}
+
+	var source app.Source
+	var pathFilter []string
+	switch subcommand {
+	case "":
+		// git-diffs [flags] [stash@{N}] [--] [glob...]
+		pathFilter = flag.Args()
+		if len(pathFilter) > 0 && stashRef.MatchString(pathFilter[0]) {
+			source = app.NewStashSource(pathFilter[0], pathFilter[1:])
+		} else if *stash >= 0 {
+			source = app.NewStashSource(fmt.Sprintf("stash@{%d}", *stash), pathFilter)
+		}
+	case "range-diff":
+		if flag.NArg() != 2 {
+			fmt.Fprintln(os.Stderr, "Usage: git-diffs range-diff <old-range> <new-range>")
+			os.Exit(2)
+		}
+		source = app.NewRangeDiffSource(flag.Arg(0), flag.Arg(1))
+	case "dir":
+		if flag.NArg() != 2 {
+			fmt.Fprintln(os.Stderr, "Usage: git-diffs dir <old-dir> <new-dir>")
+			os.Exit(2)
+		}
+		source = app.NewDirSource(flag.Arg(0), flag.Arg(1))
+	case "pick":
+		if flag.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: git-diffs pick <commit>")
+			os.Exit(2)
+		}
+		source = app.NewPickSource(flag.Arg(0))
+	case "status":
+		// git-diffs status [--] [glob...]
+		pathFilter = flag.Args()
+		source = app.NewStatusSource(pathFilter)
+	case "serve", "serve-ssh":
+		// git-diffs serve [--port N] [flags] [--] [glob...]
+		pathFilter = flag.Args()
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	compareMode, err := git.ParseCompareMode(*compare)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	repos, err := workspaceRepos(cfg, *workspace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	colorMode := cfg.Color
+	if *scriptPath != "" && *scriptANSI && (colorMode == "" || colorMode == "auto") {
+		// Frames don't go to the terminal, so don't let it decide
+		colorMode = "truecolor"
+	}
+	if *noColor {
+		colorMode = "none"
+	}
+	if err := ui.SetColorMode(colorMode); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *debug {
+		logPath := filepath.Join(os.TempDir(), "git-diffs-debug.log")
+		f, err := tea.LogToFile(logPath, "git-diffs")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		defer fmt.Fprintf(os.Stderr, "Debug log written to %s\n", logPath)
+	}
+
+	if *readOnly || cfg.ReadOnly {
+		git.SetReadOnly(true)
+		*fetch, cfg.Fetch = false, false
+	}
+
+	if subcommand == "serve" {
+		source = app.NewRepoSource(*baseBranch, *head, compareMode, *worktree, repos, pathFilter)
+		if *port == 0 {
+			*port = 8080
+		}
+		if err := runServe(source, cfg, *fetch || cfg.Fetch, *host, *port); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	paged, err := usePager(*pagerMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	if paged && *scriptPath == "" {
+		layout, err := export.ParseLayout(*pagerLayout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		if source == nil {
+			source = app.NewRepoSource(*baseBranch, *head, compareMode, *worktree, repos, pathFilter)
+		}
+		if err := runPager(source, cfg, *fetch || cfg.Fetch, layout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var feed *frameFeed
+	notice := ""
+	if subcommand == "serve-ssh" {
+		if *port == 0 {
+			*port = 2222
+		}
+		feed = newFrameFeed()
+		command, stop, err := shareSession(feed, *host, *port, *authorizedKeys)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer stop()
+		notice = "Sharing this session read-only: " + command
+	}
+
+	height := 0
+	if *inline {
+		if *inlineHeight < 5 {
+			fmt.Fprintln(os.Stderr, "Error: --inline-height must be at least 5")
+			os.Exit(2)
+		}
+		height = *inlineHeight
+	}
+
+	m := app.New(app.Options{
+		BaseBranch: *baseBranch,
+		Head:       *head,
+		Source:     source,
+		PathFilter: pathFilter,
+		Worktree:   *worktree,
+		Workspace:  repos,
+		Fetch:      *fetch || cfg.Fetch,
+		Compare:    compareMode,
+		Debug:      *debug,
+		Started:    started,
+		Config:     cfg,
+		Notice:     notice,
+		Inline:     height,
+		ReadOnly:   git.ReadOnly(),
+		Version:    headerVersion(),
+	})
+
+	if *scriptPath != "" {
+		if err := runScript(m, *scriptPath, *scriptOut, *scriptSize, *scriptSteps, *scriptANSI); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var model tea.Model = m
+	if feed != nil {
+		model = mirror{Model: m, frames: feed}
+	}
+	guard := newCrashGuard(model)
+	var opts []tea.ProgramOption
+	if !*inline {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	p := tea.NewProgram(guard, opts...)
 	if _, err := p.Run(); err != nil {
+		if path, reportErr := guard.report.Path(); path != "" {
+			fmt.Fprintf(os.Stderr, "git-diffs crashed. A report with the stack trace was written to:\n  %s\nPlease attach it when filing an issue.\n", path)
+			os.Exit(1)
+		} else if reportErr != nil {
+			fmt.Fprintf(os.Stderr, "git-diffs crashed, and writing the crash report failed: %v\n", reportErr)
+			os.Exit(1)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// stashRef matches a stash entry given in place of the first glob
+var stashRef = regexp.MustCompile(`^stash@\{\d+\}$`)
+
+// parseArgs parses flags that may be interleaved with positional arguments,
+// leaving the positional arguments in flag.Args()
+func parseArgs(args []string) {
+	var positional []string
+	for {
+		flag.CommandLine.Parse(args)
+		args = flag.Args()
+		if len(args) == 0 {
+			break
+		}
+		if args[0] == "--" {
+			positional = append(positional, args[1:]...)
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	flag.CommandLine.Parse(append([]string{"--"}, positional...))
+}
+
+// workspaceRepos combines the configured workspace repositories with the
+// discovered ones, without duplicates
+func workspaceRepos(cfg config.Config, discover bool) ([]string, error) {
+	var repos []string
+	seen := make(map[string]bool)
+	add := func(path string) error {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if !seen[abs] {
+			seen[abs] = true
+			repos = append(repos, abs)
+		}
+		return nil
+	}
+
+	for _, repo := range cfg.WorkspaceRepos {
+		if err := add(repo); err != nil {
+			return nil, err
+		}
+	}
+	if discover || cfg.WorkspaceDiscover {
+		found, err := git.DiscoverRepos(".")
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range found {
+			if err := add(repo); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return repos, nil
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage:")
+	fmt.Fprintln(out, "  git-diffs [flags] [--] [glob...]                compare the current branch against a base")
+	fmt.Fprintln(out, "  git-diffs [flags] stash@{N} [--] [glob...]      show the changes saved in a stash entry")
+	fmt.Fprintln(out, "  git-diffs status [--] [glob...]                 show staged and unstaged changes, toggled with S")
+	fmt.Fprintln(out, "  git-diffs range-diff <old-range> <new-range>    compare two iterations of a branch")
+	fmt.Fprintln(out, "  git-diffs dir <old-dir> <new-dir>               compare two directories outside of git")
+	fmt.Fprintln(out, "  git-diffs pick <commit>                         preview cherry-picking a commit onto HEAD")
+	fmt.Fprintln(out, "  git-diffs serve [--port N] [--] [glob...]       serve the diffs as a web page")
+	fmt.Fprintln(out, "  git-diffs serve-ssh [--port N] [flags]          share this session read-only over SSH")
+	fmt.Fprintln(out, "  git-diffs keys [--format=txt|md]                print the key bindings, including custom commands")
+	fmt.Fprintln(out, "  git-diffs update [--check] [--force]            replace this binary with the latest release")
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// runScript feeds a key script to the model and writes the rendered frames
+// to outDir
+func runScript(m tea.Model, path, outDir, size string, steps, ansi bool) error {
+	keys, err := script.ParseFile(path)
+	if err != nil {
+		return err
+	}
+
+	opts := script.DefaultOptions()
+	opts.ANSI = ansi
+	if _, err := fmt.Sscanf(size, "%dx%d", &opts.Width, &opts.Height); err != nil {
+		return fmt.Errorf("invalid script size %q: want WIDTHxHEIGHT", size)
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+
+	if steps {
+		opts.OnFrame = func(i int, s script.Step, frame string) error {
+			name := fmt.Sprintf("step-%03d.txt", i+1)
+			return os.WriteFile(filepath.Join(outDir, name), []byte(frame+"\n"), 0o644)
+		}
+	}
+
+	final, err := script.Run(m, keys, opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, "final.txt"), []byte(final+"\n"), 0o644)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/matthewmyrick/git-diffs/internal/app"
+	"github.com/matthewmyrick/git-diffs/internal/config"
+	"github.com/matthewmyrick/git-diffs/internal/export"
+)
+
+// usePager reports whether to print the diffs instead of starting the TUI:
+// always, never, or in auto mode when stdin or stdout isn't a terminal
+func usePager(mode string) (bool, error) {
+	switch mode {
+	case "auto":
+		return !term.IsTerminal(os.Stdin.Fd()) || !term.IsTerminal(os.Stdout.Fd()), nil
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	}
+	return false, fmt.Errorf("unknown pager mode %q: want auto, always or never", mode)
+}
+
+// runPager prints the changeset of source, through $PAGER when stdout is a
+// terminal
+func runPager(source app.Source, cfg config.Config, fetch bool, layout export.Layout) error {
+	if fetcher, ok := source.(app.Fetcher); ok && fetch {
+		if _, err := fetcher.Fetch(); err != nil {
+			return err
+		}
+	}
+	cs, err := app.LoadChangeset(source, cfg.Exclude)
+	if err != nil {
+		return err
+	}
+
+	opts := export.TextOptions{Layout: layout, TabWidth: cfg.TabWidth}
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		return export.Text(os.Stdout, source, cs, opts)
+	}
+	if width, _, err := term.GetSize(os.Stdout.Fd()); err == nil {
+		opts.Width = width
+	}
+
+	pager := pagerCommand()
+	if pager == nil {
+		return export.Text(os.Stdout, source, cs, opts)
+	}
+	in, err := pager.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := pager.Start(); err != nil {
+		return fmt.Errorf("failed to start the pager: %w", err)
+	}
+	writeErr := export.Text(in, source, cs, opts)
+	in.Close()
+	if err := pager.Wait(); err != nil {
+		return fmt.Errorf("pager: %w", err)
+	}
+	// Quitting the pager early closes the pipe
+	if writeErr != nil && !isClosedPipe(writeErr) {
+		return writeErr
+	}
+	return nil
+}
+
+// pagerCommand returns $PAGER, less by default, or nil when it's set to
+// "cat" or empty
+func pagerCommand() *exec.Cmd {
+	pager, ok := os.LookupEnv("PAGER")
+	if !ok {
+		pager = "less"
+	}
+	args := strings.Fields(pager)
+	if len(args) == 0 || args[0] == "cat" {
+		return nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Like git: raw colors, quit if it fits on one screen, keep the output
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	return cmd
+}
+
+func isClosedPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed)
+}
@@ -0,0 +1,267 @@
+// Package gitdiff parses git's unified diff output into files, hunks and
+// lines, and writes them back out as patches.
+//
+// It is the model git-diffs renders, usable on its own:
+//
+//	out, _ := exec.Command("git", "diff", "main", "--", "main.go").Output()
+//	diff, err := gitdiff.Parse(string(out))
+//	adds, dels := diff.LineCounts()
+package gitdiff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FileStatus represents the type of change for a file
+type FileStatus string
+
+// File statuses, as git diff --name-status prints them
+const (
+	StatusAdded     FileStatus = "A"
+	StatusModified  FileStatus = "M"
+	StatusDeleted   FileStatus = "D"
+	StatusRenamed   FileStatus = "R"
+	StatusCopied    FileStatus = "C"
+	StatusUnchanged FileStatus = "="
+	StatusConflict  FileStatus = "U" // Would conflict when applied
+	StatusUnknown   FileStatus = "?"
+)
+
+// ChangedFile represents a file that has changed between branches
+type ChangedFile struct {
+	Status      FileStatus
+	Path        string
+	OldPath     string // Used for renames
+	Additions   int
+	Deletions   int
+	Conflicts   int      // Conflict regions left in the new version
+	Secrets     int      // Added lines that look like credentials
+	Binary      bool     // No line counts, e.g. images
+	ModeChanged bool     // File mode differs, e.g. made executable
+	Generated   bool     // Produced by a tool, e.g. lock files or protobuf code
+	Owners      []string // Owners named by the repository's CODEOWNERS file
+}
+
+// DiffLine represents a single line in a diff
+type DiffLine struct {
+	Type       DiffLineType
+	Content    string
+	OldLineNum int
+	NewLineNum int
+	NoNewline  bool // Last line of a file that doesn't end with a newline
+}
+
+// DiffLineType represents the type of diff line
+type DiffLineType int
+
+// Diff line types; a header is a hunk's @@ line or a note such as "Binary
+// files differ"
+const (
+	DiffLineContext DiffLineType = iota
+	DiffLineAddition
+	DiffLineDeletion
+	DiffLineHeader
+)
+
+// DiffHunk represents a hunk in a diff
+type DiffHunk struct {
+	OldStart int
+	OldCount int
+	NewStart int
+	NewCount int
+	Lines    []DiffLine
+}
+
+// FileDiff represents the diff for a single file
+type FileDiff struct {
+	OldPath string
+	NewPath string
+	Hunks   []DiffHunk
+	// Binary is set when git found the file binary and showed no lines
+	Binary bool
+	// Similarity of a renamed file to its old version, in percent
+	Similarity int
+	// Encoding the content was converted to UTF-8 from, such as
+	// "UTF-16LE"; empty when it was UTF-8
+	Encoding string
+}
+
+// Parse parses the unified diff of a single file, as printed by git diff,
+// into a FileDiff
+func Parse(diffText string) (*FileDiff, error) {
+	diff := &FileDiff{}
+	// The output ends with a newline, which isn't an empty context line
+	lines := strings.Split(strings.TrimSuffix(diffText, "\n"), "\n")
+
+	var currentHunk *DiffHunk
+	oldLineNum := 0
+	newLineNum := 0
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "@@") {
+			// Parse hunk header: @@ -old,count +new,count @@
+			if currentHunk != nil {
+				diff.Hunks = append(diff.Hunks, *currentHunk)
+			}
+			currentHunk = &DiffHunk{}
+
+			// Parse the line numbers: @@ -old,count +new,count @@
+			var oldStart, oldCount, newStart, newCount int
+			if fields := strings.Fields(line); len(fields) >= 3 {
+				oldStart, oldCount = parseRange(strings.TrimPrefix(fields[1], "-"))
+				newStart, newCount = parseRange(strings.TrimPrefix(fields[2], "+"))
+			}
+
+			currentHunk.OldStart = oldStart
+			currentHunk.OldCount = oldCount
+			currentHunk.NewStart = newStart
+			currentHunk.NewCount = newCount
+
+			oldLineNum = oldStart
+			newLineNum = newStart
+
+			currentHunk.Lines = append(currentHunk.Lines, DiffLine{
+				Type:    DiffLineHeader,
+				Content: line,
+			})
+			continue
+		}
+		if currentHunk == nil {
+			// The ---/+++ file headers come before the first hunk; in a
+			// hunk, such lines remove "--" or add "++" lines
+			if rest, ok := strings.CutPrefix(line, "--- "); ok {
+				diff.OldPath = strings.TrimPrefix(rest, "a/")
+				continue
+			}
+			if rest, ok := strings.CutPrefix(line, "+++ "); ok {
+				diff.NewPath = strings.TrimPrefix(rest, "b/")
+				continue
+			}
+			// Extended headers name both paths of a rename, which a rename
+			// without content changes has no ---/+++ lines for
+			if rest, ok := strings.CutPrefix(line, "rename from "); ok {
+				diff.OldPath = rest
+				continue
+			}
+			if rest, ok := strings.CutPrefix(line, "rename to "); ok {
+				diff.NewPath = rest
+				continue
+			}
+			if rest, ok := strings.CutPrefix(line, "similarity index "); ok {
+				fmt.Sscanf(rest, "%d%%", &diff.Similarity)
+				continue
+			}
+			// Binary files have no hunks, only a note that they differ,
+			// which is kept as a header so the view can show it
+			if strings.HasPrefix(line, "Binary files ") {
+				diff.Binary = true
+				diff.Hunks = append(diff.Hunks, DiffHunk{Lines: []DiffLine{{Type: DiffLineHeader, Content: line}}})
+			}
+			continue
+		}
+
+		if len(line) == 0 {
+			// Empty context line
+			currentHunk.Lines = append(currentHunk.Lines, DiffLine{
+				Type:       DiffLineContext,
+				Content:    "",
+				OldLineNum: oldLineNum,
+				NewLineNum: newLineNum,
+			})
+			oldLineNum++
+			newLineNum++
+		} else if line[0] == '+' {
+			currentHunk.Lines = append(currentHunk.Lines, DiffLine{
+				Type:       DiffLineAddition,
+				Content:    line[1:],
+				NewLineNum: newLineNum,
+			})
+			newLineNum++
+		} else if line[0] == '-' {
+			currentHunk.Lines = append(currentHunk.Lines, DiffLine{
+				Type:       DiffLineDeletion,
+				Content:    line[1:],
+				OldLineNum: oldLineNum,
+			})
+			oldLineNum++
+		} else if line[0] == ' ' {
+			currentHunk.Lines = append(currentHunk.Lines, DiffLine{
+				Type:       DiffLineContext,
+				Content:    line[1:],
+				OldLineNum: oldLineNum,
+				NewLineNum: newLineNum,
+			})
+			oldLineNum++
+			newLineNum++
+		} else if line[0] == '\\' {
+			// "\ No newline at end of file" follows the line it applies to
+			if n := len(currentHunk.Lines); n > 0 {
+				currentHunk.Lines[n-1].NoNewline = true
+			}
+		}
+	}
+
+	if currentHunk != nil {
+		diff.Hunks = append(diff.Hunks, *currentHunk)
+	}
+
+	return diff, nil
+}
+
+// parseRange parses a hunk header range such as "12,3", or "12" for a
+// single line
+func parseRange(r string) (start, count int) {
+	startText, countText, ok := strings.Cut(r, ",")
+	start, _ = strconv.Atoi(startText)
+	count = 1
+	if ok {
+		count, _ = strconv.Atoi(countText)
+	}
+	return start, count
+}
+
+// Split splits the output of a multi-file diff into one chunk per file,
+// each of which Parse accepts
+func Split(text string) []string {
+	var chunks []string
+	start := -1
+	for i := 0; i < len(text); {
+		if strings.HasPrefix(text[i:], "diff --git ") {
+			if start >= 0 {
+				chunks = append(chunks, text[start:i])
+			}
+			start = i
+		}
+		next := strings.IndexByte(text[i:], '\n')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	if start >= 0 {
+		chunks = append(chunks, text[start:])
+	}
+	return chunks
+}
+
+// String returns a human-readable status
+func (s FileStatus) String() string {
+	switch s {
+	case StatusAdded:
+		return "added"
+	case StatusModified:
+		return "modified"
+	case StatusDeleted:
+		return "deleted"
+	case StatusRenamed:
+		return "renamed"
+	case StatusCopied:
+		return "copied"
+	case StatusUnchanged:
+		return "unchanged"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,163 @@
+package gitdiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want FileDiff
+	}{
+		{
+			name: "modified",
+			text: `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var x = 1
++var x = 2
+
+`,
+			want: FileDiff{OldPath: "main.go", NewPath: "main.go", Hunks: []DiffHunk{{
+				OldStart: 1, OldCount: 3, NewStart: 1, NewCount: 3,
+				Lines: []DiffLine{
+					{Type: DiffLineHeader, Content: "@@ -1,3 +1,3 @@"},
+					{Type: DiffLineContext, Content: "package main", OldLineNum: 1, NewLineNum: 1},
+					{Type: DiffLineDeletion, Content: "var x = 1", OldLineNum: 2},
+					{Type: DiffLineAddition, Content: "var x = 2", NewLineNum: 2},
+					{Type: DiffLineContext, Content: "", OldLineNum: 3, NewLineNum: 3},
+				},
+			}}},
+		},
+		{
+			name: "lines that look like file headers inside a hunk",
+			text: `diff --git a/f.sql b/f.sql
+--- a/f.sql
++++ b/f.sql
+@@ -1,2 +1,2 @@
+--- old comment
++++i;
+ x
+`,
+			want: FileDiff{OldPath: "f.sql", NewPath: "f.sql", Hunks: []DiffHunk{{
+				OldStart: 1, OldCount: 2, NewStart: 1, NewCount: 2,
+				Lines: []DiffLine{
+					{Type: DiffLineHeader, Content: "@@ -1,2 +1,2 @@"},
+					{Type: DiffLineDeletion, Content: "-- old comment", OldLineNum: 1},
+					{Type: DiffLineAddition, Content: "++i;", NewLineNum: 1},
+					{Type: DiffLineContext, Content: "x", OldLineNum: 2, NewLineNum: 2},
+				},
+			}}},
+		},
+		{
+			name: "renamed with changes",
+			text: `diff --git a/old.go b/new.go
+similarity index 90%
+rename from old.go
+rename to new.go
+--- a/old.go
++++ b/new.go
+@@ -2 +2 @@
+-a
++b
+`,
+			want: FileDiff{OldPath: "old.go", NewPath: "new.go", Similarity: 90, Hunks: []DiffHunk{{
+				OldStart: 2, OldCount: 1, NewStart: 2, NewCount: 1,
+				Lines: []DiffLine{
+					{Type: DiffLineHeader, Content: "@@ -2 +2 @@"},
+					{Type: DiffLineDeletion, Content: "a", OldLineNum: 2},
+					{Type: DiffLineAddition, Content: "b", NewLineNum: 2},
+				},
+			}}},
+		},
+		{
+			name: "renamed without changes",
+			text: `diff --git a/old.go b/new.go
+similarity index 100%
+rename from old.go
+rename to new.go
+`,
+			want: FileDiff{OldPath: "old.go", NewPath: "new.go", Similarity: 100},
+		},
+		{
+			name: "added",
+			text: `diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..1111111
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++one
++two
+`,
+			want: FileDiff{OldPath: "/dev/null", NewPath: "new.txt", Hunks: []DiffHunk{{
+				OldStart: 0, OldCount: 0, NewStart: 1, NewCount: 2,
+				Lines: []DiffLine{
+					{Type: DiffLineHeader, Content: "@@ -0,0 +1,2 @@"},
+					{Type: DiffLineAddition, Content: "one", NewLineNum: 1},
+					{Type: DiffLineAddition, Content: "two", NewLineNum: 2},
+				},
+			}}},
+		},
+		{
+			name: "binary",
+			text: `diff --git a/logo.png b/logo.png
+index 1111111..2222222 100644
+Binary files a/logo.png and b/logo.png differ
+`,
+			want: FileDiff{Binary: true, Hunks: []DiffHunk{{
+				Lines: []DiffLine{{Type: DiffLineHeader, Content: "Binary files a/logo.png and b/logo.png differ"}},
+			}}},
+		},
+		{
+			name: "no newline at end of file",
+			text: `diff --git a/f b/f
+--- a/f
++++ b/f
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++c
+\ No newline at end of file
+`,
+			want: FileDiff{OldPath: "f", NewPath: "f", Hunks: []DiffHunk{{
+				OldStart: 1, OldCount: 2, NewStart: 1, NewCount: 2,
+				Lines: []DiffLine{
+					{Type: DiffLineHeader, Content: "@@ -1,2 +1,2 @@"},
+					{Type: DiffLineContext, Content: "a", OldLineNum: 1, NewLineNum: 1},
+					{Type: DiffLineDeletion, Content: "b", OldLineNum: 2, NoNewline: true},
+					{Type: DiffLineAddition, Content: "c", NewLineNum: 2, NoNewline: true},
+				},
+			}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	text := "diff --git a/a b/a\n--- a/a\n+++ b/a\n@@ -1 +1 @@\n-diff --git x\n+y\ndiff --git a/b b/b\nBinary files a/b and b/b differ\n"
+	got := Split(text)
+	want := []string{
+		"diff --git a/a b/a\n--- a/a\n+++ b/a\n@@ -1 +1 @@\n-diff --git x\n+y\n",
+		"diff --git a/b b/b\nBinary files a/b and b/b differ\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split() = %q, want %q", got, want)
+	}
+}
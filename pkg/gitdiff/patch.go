@@ -0,0 +1,110 @@
+package gitdiff
+
+import "strings"
+
+// Patch serializes the given hunks of the diff, or all of them if none are
+// given, as a unified patch that git apply accepts. Notes shown as hunks,
+// such as a conflict reason or binary files, are left out, and the patch is
+// empty if nothing remains.
+func (d *FileDiff) Patch(hunks ...int) string {
+	if len(hunks) == 0 {
+		for i := range d.Hunks {
+			hunks = append(hunks, i)
+		}
+	}
+
+	var b strings.Builder
+	for _, i := range hunks {
+		if i < 0 || i >= len(d.Hunks) || !d.Hunks[i].hasRange() {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString("--- " + patchPath("a/", d.OldPath) + "\n")
+			b.WriteString("+++ " + patchPath("b/", d.NewPath) + "\n")
+		}
+		for _, line := range d.Hunks[i].Lines {
+			switch line.Type {
+			case DiffLineHeader:
+				b.WriteString(line.Content)
+			case DiffLineAddition:
+				b.WriteString("+" + line.Content)
+			case DiffLineDeletion:
+				b.WriteString("-" + line.Content)
+			default:
+				b.WriteString(" " + line.Content)
+			}
+			b.WriteString("\n")
+			if line.NoNewline {
+				b.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// hasRange reports whether the hunk starts with an @@ range header, unlike
+// the notes some sources show as hunks
+func (h DiffHunk) hasRange() bool {
+	return len(h.Lines) > 0 && h.Lines[0].Type == DiffLineHeader && strings.HasPrefix(h.Lines[0].Content, "@@")
+}
+
+// patchPath prefixes path for a patch header, leaving /dev/null as is
+func patchPath(prefix, path string) string {
+	if path == "/dev/null" {
+		return path
+	}
+	return prefix + path
+}
+
+// LineCounts returns the number of added and deleted lines in the diff
+func (d *FileDiff) LineCounts() (adds, dels int) {
+	for _, hunk := range d.Hunks {
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case DiffLineAddition:
+				adds++
+			case DiffLineDeletion:
+				dels++
+			}
+		}
+	}
+	return adds, dels
+}
+
+// LineEndingsOnly reports whether every change in the diff only adds or
+// removes a carriage return at the end of a line
+func (d *FileDiff) LineEndingsOnly() bool {
+	changed := false
+	for _, hunk := range d.Hunks {
+		var dels, adds []string
+		flush := func() bool {
+			if len(dels) != len(adds) {
+				return false
+			}
+			for i := range dels {
+				if dels[i] == adds[i] || strings.TrimSuffix(dels[i], "\r") != strings.TrimSuffix(adds[i], "\r") {
+					return false
+				}
+			}
+			changed = changed || len(dels) > 0
+			dels, adds = nil, nil
+			return true
+		}
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case DiffLineDeletion:
+				dels = append(dels, line.Content)
+			case DiffLineAddition:
+				adds = append(adds, line.Content)
+			default:
+				if !flush() {
+					return false
+				}
+			}
+		}
+		if !flush() {
+			return false
+		}
+	}
+	return changed
+}
@@ -0,0 +1,87 @@
+package gitdiff
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRepo creates a repository in a temporary directory with before
+// committed as f and after left in the working tree, and returns its path
+func gitRepo(t *testing.T, before, after string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git(t, dir, "", "init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, "f"), []byte(before), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "", "add", "f")
+	git(t, dir, "", "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "before")
+	if err := os.WriteFile(filepath.Join(dir, "f"), []byte(after), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// git runs git in dir with stdin as its input and returns its output
+func git(t *testing.T, dir, stdin string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+// numbered returns lines 1 to n, one per line
+func numbered(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	return lines
+}
+
+func TestPatchRoundTrip(t *testing.T) {
+	long := numbered(40)
+	edited := append([]string(nil), long...)
+	edited[2], edited[30] = "changed", "also changed"
+
+	tests := []struct {
+		name          string
+		before, after string
+	}{
+		{"modified", "a\nb\nc\n", "a\nB\nc\n"},
+		{"two hunks", strings.Join(long, "\n") + "\n", strings.Join(edited, "\n") + "\n"},
+		{"last line without newline", "a\nb", "a\nc"},
+		{"newline added at the end", "a\nb", "a\nb\n"},
+		{"newline removed at the end", "a\nb\n", "a\nb"},
+		{"lines starting with dashes and pluses", "-- old\n++i;\nx\n", "-- new\n++j;\nx\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := gitRepo(t, tt.before, tt.after)
+			diff, err := Parse(git(t, dir, "", "diff", "--", "f"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Each hunk must revert on its own, and the whole patch
+			// must apply to the old version
+			for i := range diff.Hunks {
+				git(t, dir, diff.Patch(i), "apply", "--check", "-R", "-")
+			}
+			git(t, dir, "", "checkout", "--", "f")
+			git(t, dir, diff.Patch(), "apply", "--check", "-")
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package viewer
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/config"
+	"github.com/matthewmyrick/git-diffs/internal/script"
+)
+
+// RenderOptions configures a headless render
+type RenderOptions struct {
+	Width  int      // Terminal width in cells (default 120)
+	Height int      // Terminal height in cells (default 40)
+	Keys   []string // Keys to press, named as Bubble Tea reports them, or "wait 200ms"
+	ANSI   bool     // Keep colors as ANSI escape codes instead of returning plain text
+	// Settle is how long to wait after each key for loads to finish
+	// (default 100ms)
+	Settle time.Duration
+}
+
+// Render runs the viewer without a terminal, presses the keys and returns
+// the final screen, for golden-file tests and screenshots. Colors don't
+// depend on the terminal: with ANSI set, an "auto" color mode renders
+// truecolor.
+func Render(opts Options, r RenderOptions) (string, error) {
+	cfg, err := config.Load(opts.Config)
+	if err != nil {
+		return "", err
+	}
+	if opts.Color == "" {
+		opts.Color = cfg.Color
+	}
+	if r.ANSI && (opts.Color == "" || opts.Color == "auto") {
+		opts.Color = "truecolor"
+	}
+	m, err := newModel(opts, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	steps, err := script.Parse(strings.NewReader(strings.Join(r.Keys, "\n")))
+	if err != nil {
+		return "", err
+	}
+	run := script.DefaultOptions()
+	if r.Width > 0 && r.Height > 0 {
+		run.Width, run.Height = r.Width, r.Height
+	}
+	if r.Settle > 0 {
+		run.Settle = r.Settle
+	}
+	run.ANSI = r.ANSI
+	return script.Run(program{m}, steps, run)
+}
+
+// program runs a Model as a whole Bubble Tea program
+type program struct {
+	Model
+}
+
+func (p program) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		p.Model = p.SetSize(size.Width, size.Height)
+		return p, nil
+	}
+	if _, ok := msg.(ClosedMsg); ok {
+		return p, tea.Quit
+	}
+	next, cmd := p.Model.Update(msg)
+	p.Model = next.(Model)
+	return p, cmd
+}
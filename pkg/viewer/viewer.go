@@ -0,0 +1,134 @@
+// Package viewer exposes git-diffs' file list and diff view as a Bubble Tea
+// model that other programs can mount inside their own layout.
+//
+// The host forwards every message to Update, sizes the viewer with SetSize
+// and closes it when it receives a ClosedMsg:
+//
+//	v, err := viewer.New(viewer.Options{Dir: repoPath, BaseBranch: "main"})
+//	...
+//	v = v.SetSize(80, 30)
+//	cmd := v.Init()
+//	...
+//	next, cmd := v.Update(msg)
+//	v = next.(viewer.Model)
+//
+// Keys behave as in git-diffs, including the user's config and custom
+// commands; "q" sends ClosedMsg instead of quitting the program. The
+// viewer's color mode only applies to the viewer, not to the host.
+package viewer
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matthewmyrick/git-diffs/internal/app"
+	"github.com/matthewmyrick/git-diffs/internal/config"
+	"github.com/matthewmyrick/git-diffs/internal/git"
+	"github.com/matthewmyrick/git-diffs/internal/ui"
+	"github.com/muesli/termenv"
+)
+
+// ClosedMsg is sent when the user quits the viewer
+type ClosedMsg = app.QuitMsg
+
+// Options configures the viewer
+type Options struct {
+	Dir        string   // Repository or worktree to show (default: current directory)
+	BaseBranch string   // Base branch to compare against (empty: auto-detect)
+	PathFilter []string // Include/exclude globs ("!" excludes)
+	Compare    string   // merge-base (base...HEAD, the default) or direct (base..HEAD)
+	Fetch      bool     // Fetch the base branch's remote before each load
+	Config     string   // Config file (default: the user's git-diffs config)
+	Color      string   // auto, truecolor, 256, 16 or none (default: the config's color setting)
+}
+
+// Model is the embeddable viewer
+type Model struct {
+	app     tea.Model
+	profile termenv.Profile // Colors the viewer renders with
+	width   int
+	height  int
+}
+
+// New creates a viewer. It fails when the config file can't be read or an
+// option is invalid; repository errors are shown inside the viewer.
+func New(opts Options) (Model, error) {
+	cfg, err := config.Load(opts.Config)
+	if err != nil {
+		return Model{}, err
+	}
+	return newModel(opts, cfg)
+}
+
+func newModel(opts Options, cfg config.Config) (Model, error) {
+	if opts.Compare == "" {
+		opts.Compare = "merge-base"
+	}
+	compare, err := git.ParseCompareMode(opts.Compare)
+	if err != nil {
+		return Model{}, err
+	}
+	if opts.Color == "" {
+		opts.Color = cfg.Color
+	}
+	profile, err := ui.ParseColorMode(opts.Color)
+	if err != nil {
+		return Model{}, err
+	}
+	m := Model{profile: profile}
+	ui.WithColorProfile(profile, func() {
+		m.app = app.New(app.Options{
+			BaseBranch: opts.BaseBranch,
+			PathFilter: opts.PathFilter,
+			Worktree:   opts.Dir,
+			Fetch:      opts.Fetch || cfg.Fetch,
+			Compare:    compare,
+			Config:     cfg,
+			Embedded:   true,
+		})
+	})
+	return m, nil
+}
+
+// Init starts loading the changes
+func (m Model) Init() tea.Cmd {
+	return m.app.Init()
+}
+
+// Update implements tea.Model; the returned model is a Model. Window size
+// messages are ignored: the host decides how much room the viewer gets
+// with SetSize.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(tea.WindowSizeMsg); ok {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	ui.WithColorProfile(m.profile, func() {
+		m.app, cmd = m.app.Update(msg)
+	})
+	return m, cmd
+}
+
+// View renders the viewer at its size
+func (m Model) View() string {
+	if m.width == 0 || m.height == 0 {
+		return ""
+	}
+	var view string
+	ui.WithColorProfile(m.profile, func() {
+		view = m.app.View()
+	})
+	return view
+}
+
+// SetSize sets the viewer's width and height in cells
+func (m Model) SetSize(width, height int) Model {
+	m.width, m.height = width, height
+	ui.WithColorProfile(m.profile, func() {
+		m.app, _ = m.app.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	})
+	return m
+}
+
+// Size returns the viewer's width and height
+func (m Model) Size() (width, height int) {
+	return m.width, m.height
+}
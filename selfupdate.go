@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/matthewmyrick/git-diffs/internal/update"
+)
+
+// runUpdate implements "git-diffs update", returning the exit code
+func runUpdate(args []string) int {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	check := fs.Bool("check", false, "Only report whether a newer release exists")
+	force := fs.Bool("force", false, "Install the latest release even if it isn't newer")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: git-diffs update [--check] [--force]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	release, err := update.Latest()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	current := currentVersion()
+	if !release.IsNewer(current) && !*force {
+		fmt.Printf("git-diffs %s is up to date\n", current)
+		return 0
+	}
+	if *check {
+		fmt.Printf("git-diffs %s is available (installed: %s)\n", release.Version(), current)
+		return 0
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: can't find the running executable: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Updating git-diffs %s to %s…\n", current, release.Version())
+	if err := release.Install(exe); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Installed git-diffs %s to %s\n", release.Version(), exe)
+	return 0
+}
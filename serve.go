@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/matthewmyrick/git-diffs/internal/app"
+	"github.com/matthewmyrick/git-diffs/internal/config"
+	"github.com/matthewmyrick/git-diffs/internal/export"
+)
+
+// diffServer serves the changeset of a source as a web page, reloaded on
+// every request so it follows the working tree
+type diffServer struct {
+	mu     sync.Mutex // Sources aren't safe for concurrent loads
+	source app.Source
+	cfg    config.Config
+}
+
+func (s *diffServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	layout := export.SideBySide
+	if name := r.URL.Query().Get("layout"); name != "" {
+		var err error
+		if layout, err = export.ParseLayout(name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs, err := app.LoadChangeset(s.source, s.cfg.Exclude)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var page bytes.Buffer
+	opts := export.HTMLOptions{Layout: layout, TabWidth: s.cfg.TabWidth, LayoutLinks: true}
+	if err := export.HTML(&page, s.source, cs, opts); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	page.WriteTo(w)
+}
+
+// runServe serves source's changeset over HTTP until interrupted
+func runServe(source app.Source, cfg config.Config, fetch bool, host string, port int) error {
+	if fetcher, ok := source.(app.Fetcher); ok && fetch {
+		if _, err := fetcher.Fetch(); err != nil {
+			return err
+		}
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Serving the diffs on http://%s (Ctrl+C to stop)\n", ln.Addr())
+	return http.Serve(ln, &diffServer{source: source, cfg: cfg})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/matthewmyrick/git-diffs/internal/config"
+)
+
+// mirror passes the frames the app renders on to the SSH viewers
+type mirror struct {
+	tea.Model
+	frames *frameFeed
+}
+
+func (m mirror) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.Model, cmd = m.Model.Update(msg)
+	return m, cmd
+}
+
+func (m mirror) View() string {
+	frame := m.Model.View()
+	m.frames.publish(frame)
+	return frame
+}
+
+// frameFeed holds the latest frame and wakes the viewers when it changes
+type frameFeed struct {
+	mu      sync.Mutex
+	frame   string
+	viewers map[chan struct{}]bool
+}
+
+func newFrameFeed() *frameFeed {
+	return &frameFeed{viewers: make(map[chan struct{}]bool)}
+}
+
+func (f *frameFeed) publish(frame string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if frame == f.frame {
+		return
+	}
+	f.frame = frame
+	for ch := range f.viewers {
+		// A viewer still behind skips to the latest frame
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (f *frameFeed) subscribe() chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	ch <- struct{}{}
+	f.viewers[ch] = true
+	return ch
+}
+
+func (f *frameFeed) unsubscribe(ch chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.viewers, ch)
+}
+
+func (f *frameFeed) latest() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.frame
+}
+
+// newShareServer creates an SSH server showing the frames of feed to every
+// connection. Viewers can't send keys to the session; q or ctrl+c leaves.
+// Without authorizedKeys anyone who can reach the address may watch.
+func newShareServer(feed *frameFeed, host string, port int, authorizedKeys string) (*ssh.Server, error) {
+	opts := []ssh.Option{
+		wish.WithAddress(net.JoinHostPort(host, strconv.Itoa(port))),
+		wish.WithHostKeyPath(filepath.Join(filepath.Dir(config.DefaultPath()), "ssh_host_ed25519")),
+		ssh.EmulatePty(),
+		wish.WithMiddleware(func(ssh.Handler) ssh.Handler {
+			return func(s ssh.Session) { watch(s, feed) }
+		}),
+	}
+	if authorizedKeys != "" {
+		opts = append(opts, wish.WithAuthorizedKeys(authorizedKeys))
+	}
+	return wish.NewServer(opts...)
+}
+
+// watch shows the latest frame to a viewer until they leave or disconnect
+func watch(s ssh.Session, feed *frameFeed) {
+	pty, resized, ok := s.Pty()
+	if !ok {
+		wish.Fatalln(s, "git-diffs needs a terminal: connect with ssh -t")
+		return
+	}
+	width, height := pty.Window.Width, pty.Window.Height
+
+	left := make(chan struct{})
+	go func() {
+		defer close(left)
+		buf := make([]byte, 64)
+		for {
+			n, err := s.Read(buf)
+			if err != nil || strings.ContainsAny(string(buf[:n]), "q\x03") {
+				return
+			}
+		}
+	}()
+
+	updates := feed.subscribe()
+	defer feed.unsubscribe(updates)
+
+	// Alt screen, hidden cursor; restored on the way out
+	fmt.Fprint(s, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(s, "\x1b[?25h\x1b[?1049l")
+	for {
+		select {
+		case <-left:
+			return
+		case <-s.Context().Done():
+			return
+		case w := <-resized:
+			width, height = w.Width, w.Height
+		case <-updates:
+		}
+		fmt.Fprint(s, "\x1b[H\x1b[2J"+fitFrame(feed.latest(), width, height))
+	}
+}
+
+// fitFrame cuts frame to a viewer's terminal, which may be smaller than the
+// one it was rendered for
+func fitFrame(frame string, width, height int) string {
+	lines := strings.Split(frame, "\n")
+	if height > 0 && len(lines) > height {
+		lines = lines[:height]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// shareSession starts an SSH server for feed and returns the command
+// viewers connect with and a function stopping the server
+func shareSession(feed *frameFeed, host string, port int, authorizedKeys string) (string, func(), error) {
+	server, err := newShareServer(feed, host, port, authorizedKeys)
+	if err != nil {
+		return "", nil, err
+	}
+	ln, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return "", nil, err
+	}
+	go server.Serve(ln)
+
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		if name, err := os.Hostname(); err == nil {
+			host = name
+		}
+	}
+	return fmt.Sprintf("ssh -t -p %d %s", port, host), func() { server.Close() }, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"runtime/debug"
+	"strings"
+)
+
+// Build metadata, set at release builds with
+//
+//	-ldflags "-X main.version=1.2.0 -X main.commit=abc1234 -X main.buildDate=2024-05-01T12:00:00Z"
+var (
+	version   = ""
+	commit    = ""
+	buildDate = ""
+)
+
+// currentVersion returns the release version, or the module version for
+// builds made with go install, or "dev"
+func currentVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// pseudoVersion matches the timestamp and commit go stamps on untagged builds
+var pseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}`)
+
+// headerVersion returns the version short enough for the header: untagged
+// builds are just "dev", and build metadata after a + is dropped
+func headerVersion() string {
+	v := currentVersion()
+	if pseudoVersion.MatchString(v) {
+		return "dev"
+	}
+	v, _, _ = strings.Cut(v, "+")
+	return v
+}
+
+// buildInfo returns the commit and date of the build, falling back to what
+// the go toolchain stamped from the checkout; either may be empty
+func buildInfo() (rev, date string) {
+	rev, date = commit, buildDate
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return rev, date
+	}
+	for _, s := range info.Settings {
+		switch {
+		case s.Key == "vcs.revision" && rev == "":
+			rev = s.Value
+		case s.Key == "vcs.time" && date == "":
+			date = s.Value
+		}
+	}
+	if len(rev) > 12 {
+		rev = rev[:12]
+	}
+	return rev, date
+}
+
+// versionString describes the build for --version and crash reports, e.g.
+// "git-diffs 1.2.0 (abc1234, built 2024-05-01T12:00:00Z)"
+func versionString() string {
+	s := "git-diffs " + currentVersion()
+	rev, date := buildInfo()
+	switch {
+	case rev != "" && date != "":
+		s += fmt.Sprintf(" (%s, built %s)", rev, date)
+	case rev != "":
+		s += fmt.Sprintf(" (%s)", rev)
+	case date != "":
+		s += fmt.Sprintf(" (built %s)", date)
+	}
+	return s
+}